@@ -43,6 +43,14 @@ func main() {
 	}
 	defer mc.Close(ctx)
 
+	// /faa/notams در مسیر پاسخ می‌نویسد؛ بدون ایندکس یکتا و 2dsphere شروع نمی‌کنیم
+	ictx, icancel := context.WithTimeout(ctx, 30*time.Second)
+	err = mc.EnsureNotamIndexes(ictx)
+	icancel()
+	if err != nil {
+		log.Fatalf("notams indexes: %v", err)
+	}
+
 	c := cron.New()
 	_, err = c.AddFunc(cfg.IngestSchedule, func() {
 		if err := ingest.RunAll(ctx, cfg, mc); err != nil {
@@ -55,6 +63,61 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if cfg.WXStations != "" {
+		_, err = c.AddFunc(cfg.WXCollectSchedule, func() {
+			if err := ingest.CollectWXHistory(ctx, cfg, mc); err != nil {
+				log.Printf(`{"lvl":"error","msg":"wx history collect failed","err":%q}`, err.Error())
+			} else {
+				log.Printf(`{"lvl":"info","msg":"wx history collected"}`)
+			}
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	// آرشیو از WX_STATIONS یا فایل‌های GTS پر می‌شود؛ بدون آرشیو کاری نمی‌کند
+	_, err = c.AddFunc(cfg.TAFVerifySchedule, func() {
+		if err := ingest.VerifyTAFs(ctx, cfg, mc); err != nil {
+			log.Printf(`{"lvl":"error","msg":"taf verification failed","err":%q}`, err.Error())
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.GTSDir != "" {
+		_, err = c.AddFunc(cfg.GTSSchedule, func() {
+			if err := ingest.IngestGTSDir(ctx, cfg, mc); err != nil {
+				log.Printf(`{"lvl":"error","msg":"gts ingest failed","err":%q}`, err.Error())
+			}
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	_, err = c.AddFunc(cfg.SIGMETSchedule, func() {
+		if err := ingest.IngestSIGMETs(ctx, cfg, mc); err != nil {
+			log.Printf(`{"lvl":"error","msg":"sigmet ingest failed","err":%q}`, err.Error())
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = c.AddFunc(cfg.PIREPSchedule, func() {
+		if err := ingest.IngestPIREPs(ctx, cfg, mc); err != nil {
+			log.Printf(`{"lvl":"error","msg":"pirep ingest failed","err":%q}`, err.Error())
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = c.AddFunc(cfg.WatchSchedule, func() {
+		if err := ingest.PollWatchRules(ctx, cfg, mc); err != nil {
+			log.Printf(`{"lvl":"error","msg":"watch poll failed","err":%q}`, err.Error())
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 	c.Start()
 	defer c.Stop()
 
@@ -0,0 +1,190 @@
+// Package alternate checks a candidate alternate's TAF against planning
+// minima over an ETA window and picks suitable runways.
+package alternate
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+const (
+	StatusOK       = "ok"       // worst case (incl. TEMPO/PROB) meets the minima
+	StatusMarginal = "marginal" // prevailing meets, a TEMPO/PROB group does not
+	StatusBelow    = "below"    // prevailing conditions are below the minima
+	StatusNoTAF    = "no_taf"   // no TAF, or the ETA window is outside its validity
+)
+
+// Minima are planning minima; a zero MaxCrosswindKt skips the crosswind check.
+type Minima struct {
+	CeilingFt      int     `json:"ceiling_ft"`
+	VisibilityM    float64 `json:"visibility_m"`
+	MaxCrosswindKt float64 `json:"max_crosswind_kt,omitempty"`
+	IncludeTempo   bool    `json:"include_tempo"`
+}
+
+type Assessment struct {
+	Status   string   `json:"status"`
+	Limiting []string `json:"limiting,omitempty"` // ceiling | visibility | crosswind
+	// worst values in the window (worst case with include_tempo, else prevailing)
+	CeilingFt   *int     `json:"min_ceiling_ft,omitempty"`
+	VisibilityM *float64 `json:"min_visibility_m,omitempty"`
+	CrosswindKt *float64 `json:"max_crosswind_kt,omitempty"` // on the best runway
+	Note        string   `json:"note,omitempty"`
+}
+
+// Rank orders statuses for sorting; lower is better.
+func Rank(status string) int {
+	switch status {
+	case StatusOK:
+		return 0
+	case StatusMarginal:
+		return 1
+	case StatusBelow:
+		return 2
+	}
+	return 3
+}
+
+// Assess evaluates t between from and to. headings are the true headings
+// of the usable runways (either end; crosswind is symmetric).
+func Assess(t *taf.TAF, from, to time.Time, headings []float64, m Minima) Assessment {
+	if t == nil || t.Nil || t.Cancelled {
+		return Assessment{Status: StatusNoTAF}
+	}
+	if from.Before(t.ValidFrom) || to.After(t.ValidTo) {
+		return Assessment{Status: StatusNoTAF, Note: "ETA window outside TAF validity"}
+	}
+
+	a := Assessment{Status: StatusOK}
+	limiting := map[string]bool{}
+	for _, sl := range t.Timeline(from, to, time.Hour) {
+		if fails := check(sl.Prevailing, headings, m); len(fails) > 0 {
+			if Rank(a.Status) < Rank(StatusBelow) {
+				a.Status = StatusBelow
+				limiting = map[string]bool{}
+			}
+			for _, f := range fails {
+				limiting[f] = true
+			}
+		}
+		c := sl.Prevailing
+		if m.IncludeTempo {
+			c = sl.WorstCase
+			if fails := check(c, headings, m); len(fails) > 0 && a.Status != StatusBelow {
+				a.Status = StatusMarginal
+				for _, f := range fails {
+					limiting[f] = true
+				}
+			}
+		}
+		a.observe(c, headings)
+	}
+	for _, k := range []string{"ceiling", "visibility", "crosswind"} {
+		if limiting[k] {
+			a.Limiting = append(a.Limiting, k)
+		}
+	}
+	return a
+}
+
+// check returns the minima that c does not meet. Missing visibility
+// counts as met (it is usually inherited from the base group).
+func check(c metar.Conditions, headings []float64, m Minima) []string {
+	var out []string
+	if ceil := c.Ceiling(); ceil != nil && *ceil < m.CeilingFt {
+		out = append(out, "ceiling")
+	}
+	if c.Visibility != nil && !c.CAVOK && c.Visibility.Meters < m.VisibilityM {
+		out = append(out, "visibility")
+	}
+	if m.MaxCrosswindKt > 0 {
+		if xw := bestCrosswind(c, headings); xw != nil && *xw > m.MaxCrosswindKt {
+			out = append(out, "crosswind")
+		}
+	}
+	return out
+}
+
+func (a *Assessment) observe(c metar.Conditions, headings []float64) {
+	if ceil := c.Ceiling(); ceil != nil && (a.CeilingFt == nil || *ceil < *a.CeilingFt) {
+		v := *ceil
+		a.CeilingFt = &v
+	}
+	if c.Visibility != nil && !c.CAVOK && (a.VisibilityM == nil || c.Visibility.Meters < *a.VisibilityM) {
+		v := c.Visibility.Meters
+		a.VisibilityM = &v
+	}
+	if xw := bestCrosswind(c, headings); xw != nil && (a.CrosswindKt == nil || *xw > *a.CrosswindKt) {
+		a.CrosswindKt = xw
+	}
+}
+
+// bestCrosswind is the lowest crosswind over the runways.
+func bestCrosswind(c metar.Conditions, headings []float64) *float64 {
+	if c.Wind == nil || len(headings) == 0 {
+		return nil
+	}
+	best := math.Inf(1)
+	for _, h := range headings {
+		best = math.Min(best, c.Wind.Crosswind(h))
+	}
+	return &best
+}
+
+// Runway requirements for a candidate.
+type RunwaySpec struct {
+	MinLengthFt int
+	HardSurface bool
+}
+
+// hard surface codes in OurAirports (free text; ASP/ASPH/Asphalt, CON/CONC, PEM, ...)
+var hardSurfaces = []string{"ASP", "CON", "PEM", "BIT", "TAR", "PAV", "MAC", "TRE"}
+
+var identRe = regexp.MustCompile(`^(\d{2})[LCR]?$`)
+
+// Usable returns the open runways that meet spec, the designators
+// ("11L/29R") and the true headings of both ends.
+func (s RunwaySpec) Usable(rwys []mdb.RunwayDoc) (names []string, headings []float64, longestFt int) {
+	for _, r := range rwys {
+		if r.Closed || r.LengthFt == nil || *r.LengthFt < s.MinLengthFt {
+			continue
+		}
+		if s.HardSurface && !isHard(r.Surface) {
+			continue
+		}
+		names = append(names, strings.Trim(r.LeIdent+"/"+r.HeIdent, "/"))
+		for _, end := range []struct {
+			hdg   *float64
+			ident string
+		}{{r.LeHeadingT, r.LeIdent}, {r.HeHeadingT, r.HeIdent}} {
+			if end.hdg != nil {
+				headings = append(headings, *end.hdg)
+			} else if m := identRe.FindStringSubmatch(end.ident); m != nil {
+				// بدون heading واقعی، از شماره‌ی باند (مغناطیسی) استفاده می‌شود
+				n, _ := strconv.Atoi(m[1])
+				headings = append(headings, float64(n*10))
+			}
+		}
+		if *r.LengthFt > longestFt {
+			longestFt = *r.LengthFt
+		}
+	}
+	return names, headings, longestFt
+}
+
+func isHard(surface string) bool {
+	s := strings.ToUpper(strings.TrimSpace(surface))
+	for _, p := range hardSurfaces {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
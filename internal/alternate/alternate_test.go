@@ -0,0 +1,124 @@
+package alternate
+
+import (
+	"testing"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/testutil"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+func utc(d, h int) time.Time { return time.Date(2025, 3, d, h, 0, 0, 0, time.UTC) }
+
+func TestAssess(t *testing.T) {
+	tf, err := taf.Parse("TAF OIII 141100Z 1412/1512 27010KT 9999 BKN030 TEMPO 1418/1422 36025KT "+
+		"FM150300 27005KT 9999 OVC004", utc(14, 11))
+	if err != nil {
+		t.Fatalf("taf.Parse: %v", err)
+	}
+	headings := []float64{270, 90}
+	m := Minima{CeilingFt: 1000, VisibilityM: 5000, MaxCrosswindKt: 20, IncludeTempo: true}
+	noTempo := m
+	noTempo.IncludeTempo = false
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		m        Minima
+		want     Assessment
+	}{
+		{"ok", utc(14, 12), utc(14, 16), m, Assessment{
+			Status: StatusOK, CeilingFt: testutil.Ptr(3000), VisibilityM: testutil.Ptr(10000.0), CrosswindKt: testutil.Ptr(0.0),
+		}},
+		{"tempo crosswind is marginal", utc(14, 17), utc(14, 21), m, Assessment{
+			Status: StatusMarginal, Limiting: []string{"crosswind"},
+			CeilingFt: testutil.Ptr(3000), VisibilityM: testutil.Ptr(10000.0), CrosswindKt: testutil.Ptr(25.0),
+		}},
+		{"tempo ignored", utc(14, 17), utc(14, 21), noTempo, Assessment{
+			Status: StatusOK, CeilingFt: testutil.Ptr(3000), VisibilityM: testutil.Ptr(10000.0), CrosswindKt: testutil.Ptr(0.0),
+		}},
+		// once below, the marginal crosswind no longer counts as limiting
+		{"below resets the limiting set", utc(14, 18), utc(15, 6), m, Assessment{
+			Status: StatusBelow, Limiting: []string{"ceiling"},
+			CeilingFt: testutil.Ptr(400), VisibilityM: testutil.Ptr(10000.0), CrosswindKt: testutil.Ptr(25.0),
+		}},
+		{"ETA after validity", utc(15, 10), utc(15, 14), m, Assessment{
+			Status: StatusNoTAF, Note: "ETA window outside TAF validity",
+		}},
+		{"ETA before validity", utc(14, 11), utc(14, 13), m, Assessment{
+			Status: StatusNoTAF, Note: "ETA window outside TAF validity",
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			testutil.Eq(t, "assessment", Assess(tf, tc.from, tc.to, headings, tc.m), tc.want)
+		})
+	}
+
+	testutil.Eq(t, "nil TAF", Assess(nil, utc(14, 12), utc(14, 13), headings, m), Assessment{Status: StatusNoTAF})
+	cnl, err := taf.Parse("TAF AMD OIII 141500Z 1412/1512 CNL", utc(14, 11))
+	if err != nil {
+		t.Fatalf("taf.Parse: %v", err)
+	}
+	testutil.Eq(t, "cancelled TAF", Assess(cnl, utc(14, 16), utc(14, 17), headings, m).Status, StatusNoTAF)
+}
+
+func TestCheck(t *testing.T) {
+	m := Minima{CeilingFt: 1000, VisibilityM: 5000, MaxCrosswindKt: 20}
+	bkn := func(ft int) []metar.Cloud { return []metar.Cloud{{Cover: "BKN", BaseFt: testutil.Ptr(ft)}} }
+	tests := []struct {
+		name string
+		c    metar.Conditions
+		m    Minima
+		want []string
+	}{
+		{"at minima", metar.Conditions{Clouds: bkn(1000), Visibility: &metar.Visibility{Meters: 5000}}, m, nil},
+		{"ceiling and visibility", metar.Conditions{Clouds: bkn(900), Visibility: &metar.Visibility{Meters: 4000}}, m, []string{"ceiling", "visibility"}},
+		// missing visibility is inherited, not a failure
+		{"no visibility", metar.Conditions{Clouds: bkn(2000)}, m, nil},
+		{"cavok", metar.Conditions{CAVOK: true, Visibility: &metar.Visibility{Meters: 0}}, m, nil},
+		{"crosswind", metar.Conditions{Wind: &metar.Wind{Direction: testutil.Ptr(0), Speed: 25, Unit: "KT"}}, m, []string{"crosswind"}},
+		{"crosswind check off", metar.Conditions{Wind: &metar.Wind{Direction: testutil.Ptr(0), Speed: 25, Unit: "KT"}}, Minima{}, nil},
+	}
+	for _, tc := range tests {
+		testutil.Eq(t, tc.name, check(tc.c, []float64{90, 270}, tc.m), tc.want)
+	}
+}
+
+func TestUsable(t *testing.T) {
+	rwys := []mdb.RunwayDoc{
+		{LeIdent: "11L", HeIdent: "29R", LengthFt: testutil.Ptr(13000), Surface: "ASPH", LeHeadingT: testutil.Ptr(113.2), HeHeadingT: testutil.Ptr(293.2)},
+		// no true headings: the designators are used
+		{LeIdent: "11R", HeIdent: "29L", LengthFt: testutil.Ptr(9000), Surface: "CON"},
+		{LeIdent: "04", HeIdent: "22", LengthFt: testutil.Ptr(7000), Surface: "GRASS"},
+		{LeIdent: "16", HeIdent: "34", LengthFt: testutil.Ptr(12000), Surface: "ASP", Closed: true},
+		{LeIdent: "H1", LengthFt: testutil.Ptr(100), Surface: "CONC"},
+		{LeIdent: "18", HeIdent: "36", Surface: "ASP"}, // unknown length
+	}
+
+	names, headings, longest := RunwaySpec{MinLengthFt: 6000, HardSurface: true}.Usable(rwys)
+	testutil.Eq(t, "names", names, []string{"11L/29R", "11R/29L"})
+	testutil.Eq(t, "headings", headings, []float64{113.2, 293.2, 110, 290})
+	testutil.Eq(t, "longest", longest, 13000)
+
+	names, headings, _ = RunwaySpec{MinLengthFt: 6000}.Usable(rwys)
+	testutil.Eq(t, "any surface", names, []string{"11L/29R", "11R/29L", "04/22"})
+	testutil.Eq(t, "any surface headings", headings, []float64{113.2, 293.2, 110, 290, 40, 220})
+
+	names, _, longest = RunwaySpec{MinLengthFt: 20000}.Usable(rwys)
+	testutil.Eq(t, "none", names, []string(nil))
+	testutil.Eq(t, "none longest", longest, 0)
+}
+
+func TestIsHard(t *testing.T) {
+	for s, want := range map[string]bool{
+		"ASPH": true, "Asphalt": true, " conc ": true, "PEM": true, "BIT": true,
+		"GRASS": false, "GRE": false, "TURF": false, "": false,
+	} {
+		if got := isHard(s); got != want {
+			t.Errorf("isHard(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
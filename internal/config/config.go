@@ -25,6 +25,7 @@ type Config struct {
 	URLAirports     string
 	URLCountries    string
 	URLRegions      string
+	URLRunways      string
 	IngestSchedule  string
 	URLFIRs         string
 	FIRCountry      string
@@ -37,7 +38,45 @@ type Config struct {
 	NonceTTLSeconds   int    // مثلا 600
 	DefaultRatePerMin int    // fallback اگر در داکیومنت مشتری نبود (مثلا 29)
 	MasterKeyBase64   string // برای رمزکردن secret‌ها (فعلا می‌تونه خالی باشه)
-
+	//WX cache
+	CacheTTLSeconds      int  // تازه بودن پاسخ AWC
+	CacheStaleSeconds    int  // تا این سن، نسخه‌ی قدیمی سرو و در پس‌زمینه بازسازی می‌شود
+	CacheMaxStaleSeconds int  // تا این سن، نسخه‌ی قدیمی فقط در خطای upstream سرو می‌شود
+	CacheMongo           bool // لایه‌ی دوم مشترک بین replicaها
+	//WX history
+	WXStations        string // لیست ICAO با کاما، مثلا "OIII,OIIE,OISS"
+	WXCollectSchedule string
+	WXHistoryDays     int    // نگهداری در metar_history/taf_history
+	TAFVerifySchedule string // مقایسه‌ی TAFهای تمام‌شده با METARها
+	FltCatRules       string // "FAA" یا مثلا "LIFR<500/1,IFR<1000/3,MVFR<=3000/5"
+	GTSDir            string // پوشه‌ی فایل‌های bulletin اداره هواشناسی (خالی = غیرفعال)
+	GTSSchedule       string
+	//Alternates: حداقل‌های برنامه‌ریزی (قابل override با query)
+	AltMinCeilingFt   int
+	AltMinVisM        int
+	AltMaxCrosswindKt int // 0 = بررسی نشود
+	AltMinRunwayFt    int
+	//Upstreams
+	AWCBaseURL   string // بدون /api/data
+	FAABaseURL   string
+	UpstreamMode string // live | record | replay
+	UpstreamDir  string // محل فایل‌های record/replay
+	// اگر ست شود، <feed>.json دست‌نویس (isigmet, airsigmet, pirep, metar, taf) از این پوشه خوانده می‌شود
+	AWCFeedFixtureDir string
+	//FAA NOTAM: بودجه‌ی مشترک همه‌ی درخواست‌های FAA
+	FAARatePerMin  int // 0 = بدون محدودیت
+	FAAConcurrency int // درخواست هم‌زمان در fan-out و all=true
+	FAAAllMaxPages int // سقف صفحه‌ها در all=true
+	// سقف location در /notams؛ فرودگاه‌های کم‌اولویت‌تر در skipped می‌آیند
+	NotamMaxLocations int
+	//SIGMET/AIRMET, PIREP
+	SIGMETSchedule string
+	PIREPSchedule  string
+	PIREPAgeHours  int // بازه‌ی دریافت از AWC
+	PIREPKeepHours int
+	//Watch rules / webhooks
+	WatchSchedule    string
+	WebhookAllowHTTP bool // فقط برای dev؛ در غیر این صورت webhook باید https باشد
 }
 
 func getenv(k, def string) string {
@@ -59,22 +98,55 @@ func getenvInt(key string, def int) int {
 }
 
 func Load() Config {
+	// AWC_FEED_BASE_URL (با /api/data) نام قبلی AWC_BASE_URL است
+	awcBase := strings.TrimSuffix(strings.TrimRight(getenv("AWC_FEED_BASE_URL", "https://aviationweather.gov"), "/"), "/api/data")
 	return Config{
-		Port:              getenv("PORT", "8086"),
-		MongoURI:          getenv("MONGO_URI", "mongodb://localhost:27017"),
-		MongoDB:           getenv("MONGO_DB", "aviation"),
-		URLAirports:       getenv("DATA_URL_AIRPORTS", "https://ourairports.com/data/airports.csv"),
-		URLCountries:      getenv("DATA_URL_COUNTRIES", "https://ourairports.com/data/countries.csv"),
-		URLRegions:        getenv("DATA_URL_REGIONS", "https://ourairports.com/data/regions.csv"),
-		IngestSchedule:    getenv("INGEST_SCHEDULE", "@every 240h"), // 10 روز
-		FIRCountry:        getenv("FIR_COUNTRY", "IR"),
-		WIKIAPI:           getenv("WIKI_API", "https://www.wikiapi.com/"),
-		FAACLIENTID:       getenv("FAACLIENTID", ""),
-		FAACLIENTSECRET:   getenv("FAACLIENTSECRET", ""),
-		AuthStrictMode:    getenvBool("AUTH_STRICT_MODE", false),
-		DateSkewSeconds:   getenvInt("DATE_SKETW_EXTRACT_SECONDS", 60),
-		NonceTTLSeconds:   getenvInt("NONCE_TTL_SECONDS", 60),
-		DefaultRatePerMin: getenvInt("DEFAULT_RATE_PER_MIN", 0),
-		MasterKeyBase64:   getenv("MASTER_KEY_BASE64", ""),
+		Port:                 getenv("PORT", "8086"),
+		MongoURI:             getenv("MONGO_URI", "mongodb://localhost:27017"),
+		MongoDB:              getenv("MONGO_DB", "aviation"),
+		URLAirports:          getenv("DATA_URL_AIRPORTS", "https://ourairports.com/data/airports.csv"),
+		URLCountries:         getenv("DATA_URL_COUNTRIES", "https://ourairports.com/data/countries.csv"),
+		URLRegions:           getenv("DATA_URL_REGIONS", "https://ourairports.com/data/regions.csv"),
+		URLRunways:           getenv("DATA_URL_RUNWAYS", "https://ourairports.com/data/runways.csv"),
+		IngestSchedule:       getenv("INGEST_SCHEDULE", "@every 240h"), // 10 روز
+		FIRCountry:           getenv("FIR_COUNTRY", "IR"),
+		WIKIAPI:              getenv("WIKI_API", "https://www.wikiapi.com/"),
+		FAACLIENTID:          getenv("FAACLIENTID", ""),
+		FAACLIENTSECRET:      getenv("FAACLIENTSECRET", ""),
+		AuthStrictMode:       getenvBool("AUTH_STRICT_MODE", false),
+		DateSkewSeconds:      getenvInt("DATE_SKETW_EXTRACT_SECONDS", 60),
+		NonceTTLSeconds:      getenvInt("NONCE_TTL_SECONDS", 60),
+		DefaultRatePerMin:    getenvInt("DEFAULT_RATE_PER_MIN", 0),
+		MasterKeyBase64:      getenv("MASTER_KEY_BASE64", ""),
+		CacheTTLSeconds:      getenvInt("CACHE_TTL", 60),
+		CacheStaleSeconds:    getenvInt("CACHE_STALE_SECONDS", 3600),
+		CacheMaxStaleSeconds: getenvInt("CACHE_MAX_STALE_SECONDS", 6*3600),
+		CacheMongo:           getenvBool("CACHE_MONGO", false),
+		WXStations:           getenv("WX_STATIONS", ""),
+		WXCollectSchedule:    getenv("WX_COLLECT_SCHEDULE", "@every 10m"),
+		WXHistoryDays:        getenvInt("WX_HISTORY_DAYS", 90),
+		TAFVerifySchedule:    getenv("TAF_VERIFY_SCHEDULE", "@every 1h"),
+		FltCatRules:          getenv("FLTCAT_RULES", "FAA"),
+		GTSDir:               getenv("GTS_DIR", ""),
+		GTSSchedule:          getenv("GTS_SCHEDULE", "@every 1m"),
+		AltMinCeilingFt:      getenvInt("ALT_MIN_CEILING_FT", 600),
+		AltMinVisM:           getenvInt("ALT_MIN_VIS_M", 3000),
+		AltMaxCrosswindKt:    getenvInt("ALT_MAX_CROSSWIND_KT", 25),
+		AltMinRunwayFt:       getenvInt("ALT_MIN_RUNWAY_FT", 6000),
+		AWCBaseURL:           getenv("AWC_BASE_URL", awcBase),
+		FAABaseURL:           getenv("FAA_BASE_URL", "https://external-api.faa.gov"),
+		UpstreamMode:         getenv("UPSTREAM_MODE", "live"),
+		UpstreamDir:          getenv("UPSTREAM_DIR", "./fixtures"),
+		AWCFeedFixtureDir:    getenv("AWC_FEED_FIXTURE_DIR", getenv("SIGMET_FIXTURE_DIR", "")),
+		FAARatePerMin:        getenvInt("FAA_RATE_PER_MIN", 29),
+		FAAConcurrency:       getenvInt("FAA_CONCURRENCY", 4),
+		FAAAllMaxPages:       getenvInt("FAA_ALL_MAX_PAGES", 50),
+		NotamMaxLocations:    getenvInt("NOTAM_MAX_LOCATIONS", 40),
+		SIGMETSchedule:       getenv("SIGMET_SCHEDULE", "@every 5m"),
+		PIREPSchedule:        getenv("PIREP_SCHEDULE", "@every 10m"),
+		PIREPAgeHours:        getenvInt("PIREP_AGE_HOURS", 2),
+		PIREPKeepHours:       getenvInt("PIREP_RETENTION_HOURS", 24),
+		WatchSchedule:        getenv("WATCH_SCHEDULE", "@every 2m"),
+		WebhookAllowHTTP:     getenvBool("WEBHOOK_ALLOW_HTTP", false),
 	}
 }
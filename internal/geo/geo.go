@@ -0,0 +1,143 @@
+// Package geo has the spherical helpers shared by the weather and NOTAM
+// code: great-circle distance, interpolation, route corridors and wind
+// components.
+package geo
+
+import "math"
+
+const (
+	EarthRadiusNM = 3440.065
+	MetersPerNM   = 1852.0
+)
+
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+func rad(d float64) float64 { return d * math.Pi / 180 }
+func deg(r float64) float64 { return r * 180 / math.Pi }
+
+// DistanceNM is the great-circle (haversine) distance in nautical miles.
+func DistanceNM(a, b Point) float64 {
+	return EarthRadiusNM * angle(a, b)
+}
+
+// angle is the central angle between two points in radians.
+func angle(a, b Point) float64 {
+	dLat := rad(b.Lat - a.Lat)
+	dLon := rad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(a.Lat))*math.Cos(rad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Bearing is the initial true course from a to b in degrees [0,360).
+func Bearing(a, b Point) float64 {
+	p1, p2 := rad(a.Lat), rad(b.Lat)
+	dl := rad(b.Lon - a.Lon)
+	y := math.Sin(dl) * math.Cos(p2)
+	x := math.Cos(p1)*math.Sin(p2) - math.Sin(p1)*math.Cos(p2)*math.Cos(dl)
+	return math.Mod(deg(math.Atan2(y, x))+360, 360)
+}
+
+// Intermediate returns the point at fraction f (0..1) along the great circle
+// from a to b.
+func Intermediate(a, b Point, f float64) Point {
+	d := angle(a, b)
+	if d == 0 {
+		return a
+	}
+	A := math.Sin((1-f)*d) / math.Sin(d)
+	B := math.Sin(f*d) / math.Sin(d)
+	p1, l1 := rad(a.Lat), rad(a.Lon)
+	p2, l2 := rad(b.Lat), rad(b.Lon)
+	x := A*math.Cos(p1)*math.Cos(l1) + B*math.Cos(p2)*math.Cos(l2)
+	y := A*math.Cos(p1)*math.Sin(l1) + B*math.Cos(p2)*math.Sin(l2)
+	z := A*math.Sin(p1) + B*math.Sin(p2)
+	return Point{
+		Lat: deg(math.Atan2(z, math.Sqrt(x*x+y*y))),
+		Lon: normLon(deg(math.Atan2(y, x))),
+	}
+}
+
+// Destination is the point reached from p on an initial course of
+// bearingDeg after distNM.
+func Destination(p Point, bearingDeg, distNM float64) Point {
+	d := distNM / EarthRadiusNM
+	t := rad(bearingDeg)
+	p1, l1 := rad(p.Lat), rad(p.Lon)
+	p2 := math.Asin(math.Sin(p1)*math.Cos(d) + math.Cos(p1)*math.Sin(d)*math.Cos(t))
+	l2 := l1 + math.Atan2(math.Sin(t)*math.Sin(d)*math.Cos(p1), math.Cos(d)-math.Sin(p1)*math.Sin(p2))
+	return Point{Lat: deg(p2), Lon: normLon(deg(l2))}
+}
+
+// DistanceToSegmentNM is the shortest distance from p to the great-circle
+// segment a→b (cross-track inside the segment, else to the nearer end),
+// plus the along-track distance from a of the closest point.
+func DistanceToSegmentNM(a, b, p Point) (dist, along float64) {
+	d13 := angle(a, p)
+	t13 := rad(Bearing(a, p))
+	t12 := rad(Bearing(a, b))
+	xt := math.Asin(math.Sin(d13) * math.Sin(t13-t12))
+	at := math.Acos(math.Max(-1, math.Min(1, math.Cos(d13)/math.Cos(xt))))
+	if math.Cos(t13-t12) < 0 {
+		at = -at
+	}
+	total := angle(a, b)
+	switch {
+	case at < 0:
+		return DistanceNM(a, p), 0
+	case at > total:
+		return DistanceNM(b, p), total * EarthRadiusNM
+	}
+	return math.Abs(xt) * EarthRadiusNM, at * EarthRadiusNM
+}
+
+// Corridor builds a closed GeoJSON ring ([lon,lat] pairs) covering
+// halfWidthNM either side of the route a→b, extended by the same distance
+// past both ends. The route is sampled so long legs follow the great circle.
+func Corridor(a, b Point, halfWidthNM float64) [][]float64 {
+	n := int(DistanceNM(a, b)/100) + 2
+	if n > 64 {
+		n = 64
+	}
+	start := Destination(a, Bearing(b, a), halfWidthNM)
+	end := Destination(b, Bearing(a, b), halfWidthNM)
+
+	left := make([][]float64, 0, n+1)
+	right := make([][]float64, 0, n+1)
+	for i := 0; i <= n; i++ {
+		p := Intermediate(start, end, float64(i)/float64(n))
+		var brg float64
+		if i < n {
+			brg = Bearing(p, end)
+		} else {
+			brg = math.Mod(Bearing(end, start)+180, 360)
+		}
+		l := Destination(p, brg-90, halfWidthNM)
+		r := Destination(p, brg+90, halfWidthNM)
+		left = append(left, []float64{l.Lon, l.Lat})
+		right = append(right, []float64{r.Lon, r.Lat})
+	}
+
+	ring := make([][]float64, 0, 2*len(left)+1)
+	ring = append(ring, right...)
+	for i := len(left) - 1; i >= 0; i-- {
+		ring = append(ring, left[i])
+	}
+	return append(ring, ring[0])
+}
+
+// WindComponents splits a wind (direction it blows from, true) into the
+// along-course part (positive = tailwind) and the cross part (positive =
+// from the right) for a course or runway heading.
+func WindComponents(dirDeg, spd, courseDeg float64) (tail, cross float64) {
+	// جهت باد «از» است؛ زاویه نسبت به مسیر
+	a := rad(dirDeg - courseDeg)
+	return -spd * math.Cos(a), spd * math.Sin(a)
+}
+
+func normLon(l float64) float64 {
+	return math.Mod(l+540, 360) - 180
+}
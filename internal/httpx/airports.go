@@ -29,8 +29,7 @@ type AirportDTO struct {
 	ISOCountry   string        `bson:"iso_country,omitempty"  json:"iso_country,omitempty"`
 	ISORegion    string        `bson:"iso_region,omitempty"   json:"iso_region,omitempty"`
 	Location     *GeoJSONPoint `bson:"location,omitempty"     json:"location,omitempty"`
-	// اگر elevation هم می‌خواهید:
-	// ElevationFT  int          `bson:"elevation_ft,omitempty" json:"elevation_ft,omitempty"`
+	ElevationFt  *int          `bson:"elevation_ft,omitempty" json:"elevation_ft,omitempty"`
 }
 type AirportsResponse struct {
 	Items []AirportDTO `json:"items"`
@@ -105,7 +104,7 @@ func airportsList(w http.ResponseWriter, r *http.Request) {
 	skip := int64(page-1) * limit
 
 	opts := options.Find().
-		SetProjection(bson.M{"_id": 0, "id_csv": 0, "continent": 0, "elevation_ft": 0}).
+		SetProjection(bson.M{"_id": 0, "id_csv": 0, "continent": 0}).
 		SetSkip(skip).SetLimit(limit)
 	if len(sort) == 0 {
 		sort = bson.D{{Key: "name", Value: 1}}
@@ -3,14 +3,24 @@ package httpx
 import (
 	"SepTaf/internal/config"
 	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/upstream"
 )
 
 var (
 	depMC  *mdb.Client
 	depCfg config.Config
+	awcUp  *upstream.Provider
+	faaUp  *upstream.Provider
+	// بودجه‌ی سراسری FAA؛ nil = بدون محدودیت
+	faaBudget *RateLimiter
 )
 
 func SetDeps(mc *mdb.Client, cfg config.Config) {
 	depMC = mc
 	depCfg = cfg
+	awcUp = upstream.AWC(cfg)
+	faaUp = upstream.FAA(cfg)
+	if cfg.FAARatePerMin > 0 {
+		faaBudget = NewRateLimiter(cfg.FAARatePerMin)
+	}
 }
@@ -0,0 +1,97 @@
+package httpx
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/notam"
+)
+
+// activeWindow is the active_at / active_between filter; at is [t, t+1s).
+type activeWindow struct{ from, to time.Time }
+
+// parseActiveWindow reads active_at=<time> or active_between=<from>,<to>
+// (RFC3339 or epoch seconds). nil means no filter.
+func parseActiveWindow(q url.Values) (*activeWindow, error) {
+	at, between := strings.TrimSpace(q.Get("active_at")), strings.TrimSpace(q.Get("active_between"))
+	switch {
+	case at != "" && between != "":
+		return nil, badRequest{"use either active_at or active_between"}
+	case at != "":
+		t, err := parseTimeParam(at)
+		if err != nil || t.IsZero() {
+			return nil, badRequest{"invalid active_at"}
+		}
+		return &activeWindow{t, t.Add(time.Second)}, nil
+	case between != "":
+		parts := strings.Split(between, ",")
+		if len(parts) != 2 {
+			return nil, badRequest{"active_between must be <from>,<to>"}
+		}
+		from, err1 := parseTimeParam(strings.TrimSpace(parts[0]))
+		to, err2 := parseTimeParam(strings.TrimSpace(parts[1]))
+		if err1 != nil || err2 != nil || from.IsZero() || !to.After(from) {
+			return nil, badRequest{"invalid active_between"}
+		}
+		return &activeWindow{from, to}, nil
+	}
+	return nil, nil
+}
+
+// filterActive keeps the items in force during win and returns how many
+// were dropped. An unreadable item D is judged on B/C alone and the item
+// is flagged scheduleUnparsed.
+func filterActive(items []NotamFeature, win *activeWindow) ([]NotamFeature, int) {
+	if win == nil {
+		return items, 0
+	}
+	out := items[:0]
+	for _, it := range items {
+		v := notamValidity(it)
+		if v.ActiveBetween(win.from, win.to) {
+			it.ScheduleUnparsed = v.ScheduleErr != nil
+			out = append(out, it)
+		}
+	}
+	return out, len(items) - len(out)
+}
+
+// notamValidity takes B/C/D from the decoded ICAO text and falls back to
+// the FAA effectiveStart/effectiveEnd/schedule strings.
+func notamValidity(it NotamFeature) notam.Validity {
+	var v notam.Validity
+	if it.Decoded != nil {
+		v = it.Decoded.Validity()
+	}
+	if it.core == nil {
+		return v
+	}
+	n := it.core.Notam
+	if v.From == nil {
+		v.From = faaTime(n.EffectiveStart)
+	}
+	if v.To == nil && (it.Decoded == nil || !it.Decoded.Permanent) {
+		v.To = faaTime(n.EffectiveEnd)
+		v.Estimated = v.Estimated || strings.HasSuffix(strings.ToUpper(strings.TrimSpace(n.EffectiveEnd)), "EST")
+	}
+	if v.Schedule == nil {
+		if s, err := notam.ParseSchedule(n.Schedule); s != nil {
+			v.Schedule, v.ScheduleErr = s, nil
+		} else if err != nil {
+			v.ScheduleErr = err
+		}
+	}
+	return v
+}
+
+// docValidity is notamValidity for a stored NOTAM.
+func docValidity(d mdb.NotamDoc) notam.Validity {
+	v := notam.Validity{From: d.EffectiveStart, To: d.EffectiveEnd, Estimated: d.Estimated}
+	v.Schedule, v.ScheduleErr = notam.ParseSchedule(d.Schedule)
+	if d.Decoded != nil && d.Decoded.Q != nil {
+		v.Lat, v.Lon = d.Decoded.Q.Lat, d.Decoded.Q.Lon
+	}
+	return v
+}
@@ -0,0 +1,251 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// هر صفحه حداکثر این‌قدر برای نوشتن وقت دارد (WriteTimeout سرور کوتاه است)
+const notamPageWriteWindow = time.Minute
+
+// wantsNDJSON: format=ndjson or Accept: application/x-ndjson.
+func wantsNDJSON(r *http.Request) bool {
+	if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("format")), "ndjson") {
+		return true
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(mt, "application/x-ndjson") || strings.EqualFold(mt, "application/ndjson") {
+			return true
+		}
+	}
+	return false
+}
+
+// getAllNOTAMs walks every FAA page of q (all=true) and streams the merged,
+// de-duplicated items as they arrive, in page order. Pages are fetched
+// faaConcurrency at a time under the global FAA budget and at most
+// FAAAllMaxPages are read. Once streaming has started the status is 200;
+// an upstream failure is reported in the summary (JSON) or as a last
+// {"error":...} line (NDJSON), and in the X-Notam-Error trailer.
+func getAllNOTAMs(w http.ResponseWriter, r *http.Request, q url.Values, hdr http.Header, win *activeWindow) {
+	if r.URL.Query().Get("pageSize") == "" {
+		q.Set("pageSize", strconv.Itoa(notamLocPageSize))
+	}
+	q.Set("pageNum", "1")
+	first, err := fetchFAANotams(r.Context(), q, hdr)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+
+	pages := first.TotalPages
+	truncated := false
+	if max := depCfg.FAAAllMaxPages; max > 0 && pages > max {
+		pages, truncated = max, true
+	}
+
+	s := newNotamStream(w, wantsNDJSON(r), win)
+	s.begin(first.TotalCount, first.TotalPages)
+	s.page(r.Context(), first.Items)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	// faaConcurrency workers; at most `ahead` pages are fetched before the
+	// writer has streamed them, so memory does not grow with FAA_ALL_MAX_PAGES
+	workers := faaConcurrency()
+	ahead := 2 * workers
+	jobs := make(chan int)
+	slots := make(chan struct{}, ahead)
+	done := make(chan notamPage, ahead)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for p := 2; p <= pages; p++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				pq := url.Values{}
+				for k, v := range q {
+					pq[k] = v
+				}
+				pq.Set("pageNum", strconv.Itoa(p))
+				resp, err := fetchFAANotams(ctx, pq, hdr)
+				done <- notamPage{num: p, resp: resp, err: err}
+			}
+		}()
+	}
+
+	var errMsg string
+	read := 1
+	pending := map[int]notamPage{}
+loop:
+	for p := 2; p <= pages; {
+		pg, ok := pending[p]
+		if !ok {
+			select {
+			case got := <-done:
+				pending[got.num] = got
+			case <-ctx.Done():
+				// درخواست کلاینت قطع شد؛ صفحه‌ی p شاید هرگز فرستاده نشود
+				errMsg = fmt.Sprintf("page %d: %s", p, ctx.Err().Error())
+				break loop
+			}
+			continue
+		}
+		delete(pending, p)
+		<-slots
+		if pg.err != nil {
+			errMsg = fmt.Sprintf("page %d: %s", p, pg.err.Error())
+			break loop // بقیه‌ی صفحه‌ها لازم نیست
+		}
+		s.page(r.Context(), pg.resp.Items)
+		read++
+		p++
+	}
+	cancel()
+	wg.Wait()
+
+	if truncated && errMsg == "" {
+		errMsg = fmt.Sprintf("stopped after %d of %d pages (FAA_ALL_MAX_PAGES)", pages, first.TotalPages)
+	}
+	if errMsg != "" {
+		log.Printf(`{"lvl":"warn","msg":"faa notams all","pages":%d,"total_pages":%d,"err":%q}`, read, first.TotalPages, errMsg)
+	}
+	s.end(read, errMsg)
+}
+
+// notamStream writes NOTAM items as one JSON object or as NDJSON.
+type notamStream struct {
+	w        http.ResponseWriter
+	rc       *http.ResponseController
+	enc      *json.Encoder
+	ndjson   bool
+	win      *activeWindow
+	seen     map[string]bool
+	count    int
+	dup      int
+	inactive int
+}
+
+// NotamAllSummary closes the all=true JSON object after "items".
+type NotamAllSummary struct {
+	Count      int    `json:"count"`      // items written
+	Pages      int    `json:"pages"`      // FAA pages read
+	Duplicates int    `json:"duplicates"` // items seen on more than one page
+	Inactive   int    `json:"inactive,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func newNotamStream(w http.ResponseWriter, ndjson bool, win *activeWindow) *notamStream {
+	return &notamStream{
+		w:      w,
+		rc:     http.NewResponseController(w),
+		enc:    json.NewEncoder(w),
+		ndjson: ndjson,
+		win:    win,
+		seen:   map[string]bool{},
+	}
+}
+
+func (s *notamStream) begin(totalCount, totalPages int) {
+	h := s.w.Header()
+	h.Set("Trailer", "X-Notam-Count, X-Notam-Error")
+	h.Set("X-Notam-Total-Count", strconv.Itoa(totalCount))
+	h.Set("X-Notam-Total-Pages", strconv.Itoa(totalPages))
+	if s.ndjson {
+		h.Set("Content-Type", "application/x-ndjson")
+	} else {
+		h.Set("Content-Type", "application/json")
+	}
+	_ = s.rc.SetWriteDeadline(time.Now().Add(notamPageWriteWindow))
+	s.w.WriteHeader(http.StatusOK)
+	if !s.ndjson {
+		fmt.Fprintf(s.w, `{"totalCount":%d,"totalPages":%d,"items":[`, totalCount, totalPages)
+	}
+}
+
+// page decodes, stores, filters and writes one FAA page.
+func (s *notamStream) page(ctx context.Context, items []NotamFeature) {
+	fresh := items[:0]
+	for _, it := range items {
+		if k := notamKey(it); k != "" {
+			if s.seen[k] {
+				s.dup++
+				continue
+			}
+			s.seen[k] = true
+		}
+		fresh = append(fresh, it)
+	}
+	decodeNotams(fresh)
+	storeNotams(fresh)
+	fresh, dropped := filterActive(fresh, s.win)
+	s.inactive += dropped
+
+	for _, it := range fresh {
+		if s.ndjson {
+			_ = s.enc.Encode(it)
+		} else {
+			b, err := json.Marshal(it)
+			if err != nil {
+				continue
+			}
+			if s.count > 0 {
+				_, _ = s.w.Write([]byte(","))
+			}
+			_, _ = s.w.Write(b)
+		}
+		s.count++
+	}
+	_ = s.rc.Flush()
+	_ = s.rc.SetWriteDeadline(time.Now().Add(notamPageWriteWindow))
+}
+
+func (s *notamStream) end(pages int, errMsg string) {
+	if s.ndjson {
+		if errMsg != "" {
+			fmt.Fprintf(s.w, "{\"error\":%q}\n", errMsg)
+		}
+	} else {
+		b, _ := json.Marshal(NotamAllSummary{
+			Count:      s.count,
+			Pages:      pages,
+			Duplicates: s.dup,
+			Inactive:   s.inactive,
+			Error:      errMsg,
+		})
+		// `{"count":...}` → `],"count":...}`
+		_, _ = s.w.Write([]byte("],"))
+		_, _ = s.w.Write(b[1:])
+	}
+	s.w.Header().Set("X-Notam-Count", strconv.Itoa(s.count))
+	if errMsg != "" {
+		s.w.Header().Set("X-Notam-Error", errMsg)
+	}
+	_ = s.rc.Flush()
+}
@@ -0,0 +1,133 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/geo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NOTAM area godoc
+// @Summary      NOTAMs by point, bbox or route corridor
+// @Description  Stored NOTAMs in force at `at` (as /notams/current) whose geometry intersects a point (lat/lon), a bbox, or the corridor_nm either side of the great circle from→to. Geometry comes from an item-E polygon or circle, else the Q-line centre and radius. fl_min/fl_max keep NOTAMs whose Q-line FL band overlaps; NOTAMs without limits always pass.
+// @Tags         NOTAM
+// @Produce      json
+// @Param        lat          query  number  false  "Point latitude (with lon)"
+// @Param        lon          query  number  false  "Point longitude"
+// @Param        bbox         query  string  false  "minLon,minLat,maxLon,maxLat"
+// @Param        from         query  string  false  "Route departure airport (with to)"
+// @Param        to           query  string  false  "Route destination airport (with from)"
+// @Param        corridor_nm  query  number  false  "Half-width of the route corridor in NM (default 25, max 200)"
+// @Param        fl_min       query  int     false  "Lowest flight level (hundreds of ft)"
+// @Param        fl_max       query  int     false  "Highest flight level (hundreds of ft)"
+// @Param        at           query  string  false  "RFC3339 or epoch seconds (default now)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  NotamsResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /notams/area [get]
+func GetNotamsArea(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	var shape any
+	from := strings.ToUpper(strings.TrimSpace(q.Get("from")))
+	to := strings.ToUpper(strings.TrimSpace(q.Get("to")))
+	switch {
+	case q.Get("lat") != "" || q.Get("lon") != "":
+		lat, err1 := strconv.ParseFloat(strings.TrimSpace(q.Get("lat")), 64)
+		lon, err2 := strconv.ParseFloat(strings.TrimSpace(q.Get("lon")), 64)
+		if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			http.Error(w, `{"error":"lat and lon are required together and must be valid coordinates"}`, http.StatusBadRequest)
+			return
+		}
+		shape = bson.M{"type": "Point", "coordinates": []float64{lon, lat}}
+	case strings.TrimSpace(q.Get("bbox")) != "":
+		poly, err := parseBBox(q.Get("bbox"))
+		if err != nil {
+			writeStationError(w, err)
+			return
+		}
+		shape = poly
+	case from != "" && to != "":
+		a, err := airportPoint(ctx, from)
+		if err != nil {
+			writeStationError(w, err)
+			return
+		}
+		b, err := airportPoint(ctx, to)
+		if err != nil {
+			writeStationError(w, err)
+			return
+		}
+		width, err := floatParam(q.Get("corridor_nm"), 25, 200)
+		if err != nil {
+			http.Error(w, `{"error":"invalid corridor_nm"}`, http.StatusBadRequest)
+			return
+		}
+		shape = bson.M{"type": "Polygon", "coordinates": [][][]float64{geo.Corridor(a, b, width)}}
+	default:
+		http.Error(w, `{"error":"lat/lon, bbox or from/to is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	conds := []bson.M{{"geometry": bson.M{"$geoIntersects": bson.M{"$geometry": shape}}}}
+	// هم‌پوشانی بازه‌ی FL نوتام با [fl_min, fl_max]
+	if v := strings.TrimSpace(q.Get("fl_min")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, `{"error":"invalid fl_min"}`, http.StatusBadRequest)
+			return
+		}
+		conds = append(conds, bson.M{"$or": []bson.M{{"upper_fl": nil}, {"upper_fl": bson.M{"$gte": n}}}})
+	}
+	if v := strings.TrimSpace(q.Get("fl_max")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, `{"error":"invalid fl_max"}`, http.StatusBadRequest)
+			return
+		}
+		conds = append(conds, bson.M{"$or": []bson.M{{"lower_fl": nil}, {"lower_fl": bson.M{"$lte": n}}}})
+	}
+
+	at, err := parseTimeParam(q.Get("at"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid at"}`, http.StatusBadRequest)
+		return
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	docs, err := depMC.CurrentNotams(ctx, bson.M{"$and": conds}, at)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	items := docs[:0]
+	for _, d := range docs {
+		if v := docValidity(d); v.ActiveAt(at) {
+			d.ScheduleUnparsed = v.ScheduleErr != nil
+			items = append(items, d)
+		}
+	}
+	_ = json.NewEncoder(w).Encode(NotamsResponse{At: &at, Count: len(items), Items: items})
+}
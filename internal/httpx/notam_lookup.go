@@ -0,0 +1,392 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"SepTaf/internal/geo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const notamLocPageSize = 1000 // سقف pageSize در FAA
+
+// notamMaxLocations caps the locations of one /notams request; each
+// location is one FAA request (NOTAM_MAX_LOCATIONS).
+func notamMaxLocations() int {
+	if depCfg.NotamMaxLocations < 1 {
+		return 40
+	}
+	return depCfg.NotamMaxLocations
+}
+
+type NotamLookupResponse struct {
+	Locations []string       `json:"locations"` // ICAO locations queried upstream
+	Count     int            `json:"count"`
+	Items     []NotamFeature `json:"items"`
+	Inactive  int            `json:"inactive,omitempty"` // dropped by active_at / active_between
+	// locations with more NOTAMs than one upstream page
+	Truncated []string `json:"truncated,omitempty"`
+	// FIR/radius airports beyond NOTAM_MAX_LOCATIONS, lowest ranked; not queried
+	Skipped []string `json:"skipped,omitempty"`
+	// upstream failures by location; the other locations are still returned
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// NOTAM lookup godoc
+// @Summary      NOTAMs by airport, FIR or radius
+// @Description  Resolves airport= (comma list, e.g. departure,destination), fir_code= (comma list; the FIR itself plus its airports of type=) and lat/lon/radius_nm (airports of type= in range) through the airports and firs collections into ICAO locations, queries FAA once per location (at most NOTAM_MAX_LOCATIONS, default 40: airport= and the FIR codes first, then the FIR/radius airports ranked by the order of type= and ICAO code; the rest are listed in skipped) and merges the results (de-duplicated). The selectors can be combined. notamType, classification, featureType and effectiveStartDate/effectiveEndDate are passed through. Items are decoded and stored like /faa/notams.
+// @Tags         NOTAM
+// @Produce      json
+// @Param        airport    query  string  false  "Airport codes (ICAO/GPS/ident), comma separated"
+// @Param        fir_code   query  string  false  "FIR codes, comma separated (e.g. OIIX)"
+// @Param        lat        query  number  false  "Centre latitude (with lon and radius_nm)"
+// @Param        lon        query  number  false  "Centre longitude"
+// @Param        radius_nm  query  number  false  "Radius in NM (default 50, max 300)"
+// @Param        type       query  string  false  "Airport types for fir_code/radius, in priority order (default large_airport,medium_airport)"
+// @Param        notamType  query  string  false  "N | R | C"
+// @Param        classification  query  string  false  "INTL | MIL | DOM | LMIL | FDC"
+// @Param        featureType     query  string  false  "RWY,TWY,APRON,AD,OBST,NAV,COM,SVC,AIRSPACE,..."
+// @Param        active_at       query  string  false  "Only NOTAMs in force at this time, incl. item-D schedule (RFC3339 or epoch seconds); an unreadable item D is judged on B/C and flagged scheduleUnparsed"
+// @Param        active_between  query  string  false  "Only NOTAMs in force at some point of <from>,<to>"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  NotamLookupResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      401  {object}  HTTPError
+// @Failure      502  {object}  HTTPError
+// @Router       /notams [get]
+func GetNotams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	base, err := buildFAAQuery(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	win, err := parseActiveWindow(r.URL.Query())
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+	hdr, ok := faaHeaders()
+	if !ok {
+		http.Error(w, `{"error":"missing FAA client_id/client_secret"}`, http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	locs, skipped, err := resolveNotamLocations(ctx, r.URL.Query())
+	cancel()
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+
+	// پارامترهای مکانی FAA با location های ما جایگزین می‌شوند
+	base.Del("domesticLocation")
+	base.Set("pageSize", strconv.Itoa(notamLocPageSize))
+	base.Set("pageNum", "1")
+
+	pages := fetchNotamLocations(r.Context(), locs, base, hdr)
+	resp := mergeNotamPages(locs, pages)
+	resp.Skipped = skipped
+	if len(resp.Errors) == len(locs) {
+		http.Error(w, fmt.Sprintf(`{"error":"all upstream requests failed","upstream":%q}`, resp.Errors[locs[0]]), http.StatusBadGateway)
+		return
+	}
+
+	decodeNotams(resp.Items)
+	storeNotams(resp.Items)
+	resp.Items, resp.Inactive = filterActive(resp.Items, win)
+	resp.Count = len(resp.Items)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// resolveNotamLocations turns airport / fir_code / lat+lon+radius_nm into
+// a de-duplicated list of ICAO locations. airport= and the FIR codes come
+// first and are always queried; the FIR/radius airports follow by rank up
+// to notamMaxLocations, and the ones left over are returned as skipped.
+func resolveNotamLocations(ctx context.Context, q url.Values) (locs, skipped []string, err error) {
+	types := airportTypes(q)
+	var named []string
+	var area []areaAirport
+	for _, code := range splitCodes(q.Get("airport")) {
+		icao, err := airportICAO(ctx, code)
+		if err != nil {
+			return nil, nil, err
+		}
+		named = append(named, icao)
+	}
+	for _, code := range splitCodes(q.Get("fir_code")) {
+		as, err := firLocations(ctx, code, types)
+		if err != nil {
+			return nil, nil, err
+		}
+		named = append(named, code)
+		area = append(area, as...)
+	}
+	if q.Get("lat") != "" || q.Get("lon") != "" {
+		as, err := radiusLocations(ctx, q, types)
+		if err != nil {
+			return nil, nil, err
+		}
+		area = append(area, as...)
+	}
+
+	max := notamMaxLocations()
+	seen := map[string]bool{}
+	for _, c := range named {
+		if !seen[c] {
+			seen[c] = true
+			locs = append(locs, c)
+		}
+	}
+	if len(locs) > max {
+		return nil, nil, badRequest{fmt.Sprintf("request names %d airports/FIRs (max %d)", len(locs), max)}
+	}
+	rankAirports(area, types)
+	for _, a := range area {
+		if seen[a.ICAO] {
+			continue
+		}
+		seen[a.ICAO] = true
+		if len(locs) < max {
+			locs = append(locs, a.ICAO)
+		} else {
+			skipped = append(skipped, a.ICAO)
+		}
+	}
+
+	if len(locs) == 0 {
+		if q.Get("airport") == "" && q.Get("fir_code") == "" && q.Get("lat") == "" {
+			return nil, nil, badRequest{"one of airport, fir_code or lat/lon/radius_nm is required"}
+		}
+		return nil, nil, badRequest{"no ICAO locations found for the request"}
+	}
+	return locs, skipped, nil
+}
+
+func splitCodes(v string) []string {
+	var out []string
+	for _, c := range strings.Split(v, ",") {
+		if c = strings.ToUpper(strings.TrimSpace(c)); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// airportICAO looks up an airport by icao_code, gps_code or ident and
+// returns the code FAA knows it by.
+func airportICAO(ctx context.Context, code string) (string, error) {
+	var a AirportDTO
+	err := depMC.DB.Collection("airports").FindOne(ctx,
+		bson.M{"$or": []bson.M{{"icao_code": code}, {"gps_code": code}, {"ident": code}}},
+		options.FindOne().SetProjection(bson.M{"icao_code": 1, "gps_code": 1, "ident": 1})).Decode(&a)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", badRequest{fmt.Sprintf("unknown airport %s", code)}
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, c := range []string{a.IcaoCode, a.GPSCode, a.Ident} {
+		if icaoRe.MatchString(c) {
+			return c, nil
+		}
+	}
+	return "", badRequest{fmt.Sprintf("airport %s has no ICAO location", code)}
+}
+
+// firLocations checks the FIR and returns the airports inside its stored
+// geometry. FIRs without geometry give no airports (the FIR code itself is
+// queried by the caller).
+func firLocations(ctx context.Context, code string, types []string) ([]areaAirport, error) {
+	n, err := depMC.FIRsCollection().CountDocuments(ctx, bson.M{"fir_code": code})
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, badRequest{fmt.Sprintf("unknown FIR %s", code)}
+	}
+	geom, err := firGeometry(ctx, code)
+	var br badRequest
+	if errors.As(err, &br) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return areaAirports(ctx, bson.M{"location": bson.M{"$geoWithin": bson.M{"$geometry": geom}}}, types)
+}
+
+func radiusLocations(ctx context.Context, q url.Values, types []string) ([]areaAirport, error) {
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(q.Get("lat")), 64)
+	lon, err2 := strconv.ParseFloat(strings.TrimSpace(q.Get("lon")), 64)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil, badRequest{"lat and lon are required together and must be valid coordinates"}
+	}
+	radius, err := floatParam(q.Get("radius_nm"), 50, 300)
+	if err != nil {
+		return nil, badRequest{"invalid radius_nm"}
+	}
+	return areaAirports(ctx, bson.M{"location": bson.M{"$geoWithin": bson.M{
+		"$centerSphere": []any{[]float64{lon, lat}, radius / geo.EarthRadiusNM},
+	}}}, types)
+}
+
+// areaAirport is an airport found inside a FIR or radius.
+type areaAirport struct {
+	ICAO string `bson:"icao_code"`
+	Type string `bson:"type"`
+}
+
+// areaAirports lists the airports of types matching filter, by ICAO code.
+// Unlike stationsForFilter it has no hard limit; the caller caps the list.
+func areaAirports(ctx context.Context, filter bson.M, types []string) ([]areaAirport, error) {
+	filter["icao_code"] = bson.M{"$regex": `^[A-Z0-9]{4}$`}
+	if len(types) > 0 {
+		filter["type"] = bson.M{"$in": types}
+	}
+	cur, err := depMC.DB.Collection("airports").Find(ctx, filter, options.Find().
+		SetProjection(bson.M{"_id": 0, "icao_code": 1, "type": 1}).
+		SetSort(bson.D{{Key: "icao_code", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	out := []areaAirport{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// rankAirports orders airports by the position of their type in types
+// (large_airport before medium_airport by default), keeping the order
+// within a type.
+func rankAirports(as []areaAirport, types []string) {
+	pos := make(map[string]int, len(types))
+	for i, t := range types {
+		if _, ok := pos[t]; !ok {
+			pos[t] = i
+		}
+	}
+	rank := func(t string) int {
+		if i, ok := pos[t]; ok {
+			return i
+		}
+		return len(types)
+	}
+	sort.SliceStable(as, func(i, j int) bool { return rank(as[i].Type) < rank(as[j].Type) })
+}
+
+type notamPage struct {
+	num  int // FAA page number (all=true)
+	resp *NotamResponse
+	err  error
+}
+
+// fetchNotamLocations queries FAA once per location, faaConcurrency at a time.
+func fetchNotamLocations(ctx context.Context, locs []string, base url.Values, hdr http.Header) []notamPage {
+	pages := make([]notamPage, len(locs))
+	sem := make(chan struct{}, faaConcurrency())
+	var wg sync.WaitGroup
+	for i, loc := range locs {
+		wg.Add(1)
+		go func(i int, loc string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			q := url.Values{}
+			for k, v := range base {
+				q[k] = v
+			}
+			q.Set("icaoLocation", loc)
+			pages[i].resp, pages[i].err = fetchFAANotams(ctx, q, hdr)
+		}(i, loc)
+	}
+	wg.Wait()
+	return pages
+}
+
+// fetchFAANotams performs one FAA NOTAM request and decodes the page.
+func fetchFAANotams(ctx context.Context, q url.Values, hdr http.Header) (*NotamResponse, error) {
+	resp, err := faaGet(ctx, q, hdr)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != http.StatusOK {
+		return nil, fmt.Errorf("upstream %d: %s", resp.Status, strings.TrimSpace(string(resp.Body)))
+	}
+	var out NotamResponse
+	if err := json.Unmarshal(resp.Body, &out); err != nil {
+		return nil, fmt.Errorf("invalid upstream json")
+	}
+	return &out, nil
+}
+
+// mergeNotamPages concatenates the pages in location order and drops
+// NOTAMs returned for more than one location.
+func mergeNotamPages(locs []string, pages []notamPage) NotamLookupResponse {
+	out := NotamLookupResponse{Locations: locs, Items: []NotamFeature{}}
+	seen := map[string]bool{}
+	for i, p := range pages {
+		if p.err != nil {
+			if out.Errors == nil {
+				out.Errors = map[string]string{}
+			}
+			out.Errors[locs[i]] = p.err.Error()
+			continue
+		}
+		if p.resp.TotalPages > 1 {
+			out.Truncated = append(out.Truncated, locs[i])
+		}
+		for _, it := range p.resp.Items {
+			if k := notamKey(it); k != "" {
+				if seen[k] {
+					continue
+				}
+				seen[k] = true
+			}
+			out.Items = append(out.Items, it)
+		}
+	}
+	out.Count = len(out.Items)
+	return out
+}
+
+// notamKey identifies an FAA item across requests: the FAA id, else
+// location + series/number.
+func notamKey(it NotamFeature) string {
+	if it.core == nil {
+		return ""
+	}
+	n := it.core.Notam
+	if n.Id != "" {
+		return n.Id
+	}
+	if n.Number == "" {
+		return ""
+	}
+	return firstNonEmpty(n.IcaoLocation, n.Location) + "|" + n.Series + n.Number
+}
@@ -2,23 +2,26 @@ package httpx
 
 import (
 	"SepTaf/internal/config"
+	"SepTaf/internal/notam"
+	"SepTaf/internal/upstream"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
-	"time"
 )
 
-const faaBase = "https://external-api.faa.gov/notamapi/v1/notams"
+const faaNotamsPath = "/notamapi/v1/notams"
 
 type GeoMetry struct {
-	Type string `json:"type"`
+	Type        string     `json:"type"`
+	Coordinates any        `json:"coordinates,omitempty"`
+	Geometries  []GeoMetry `json:"geometries,omitempty"` // GeometryCollection
 }
 type NotamEventType struct {
-	Scenario string `json:"type"`
+	Scenario string `json:"scenario"`
 }
 type NotamType struct {
 	Id             string `json:"id"`
@@ -49,23 +52,46 @@ type ArrayOfNotamTranslationType struct {
 	Type          string `json:"type"`
 	FormattedText string `json:"formattedText"`
 }
-type NotamTranslationType struct {
-	Items []ArrayOfNotamTranslationType `json:"items"`
-}
 
 type CoreNOTAMDataType struct {
-	NotamEvent       NotamEventType       `json:"notam_event"`
-	Notam            NotamType            `json:"notam"`
-	NotamTranslation NotamTranslationType `json:"notam_translation"`
+	NotamEvent       NotamEventType                `json:"notamEvent"`
+	Notam            NotamType                     `json:"notam"`
+	NotamTranslation []ArrayOfNotamTranslationType `json:"notamTranslation"`
 }
+
+// PropertiesType is the part of the FAA properties read server-side; the
+// response carries the properties unchanged.
 type PropertiesType struct {
 	CoreNOTAMData CoreNOTAMDataType `json:"coreNOTAMData"`
 }
 
 type NotamFeature struct {
-	Type       string           `json:"type"`               // "Point" | ...
-	Geometry   []GeoMetry       `json:"geometry,omitempty"` // GeoJSON-like
-	Properties []PropertiesType `json:"properties,omitempty"`
+	Type     string    `json:"type"`               // "Feature"
+	Geometry *GeoMetry `json:"geometry,omitempty"` // GeoJSON
+	// FAA properties as received (coreNOTAMData with every FAA field)
+	Properties json.RawMessage `json:"properties,omitempty" swaggertype:"object"`
+	// ICAO text decoded server-side (Q-line, items A–G)
+	Decoded     *notam.NOTAM `json:"decoded,omitempty"`
+	DecodeError string       `json:"decodeError,omitempty"`
+	// item D could not be read; active_at/active_between used B/C only
+	ScheduleUnparsed bool `json:"scheduleUnparsed,omitempty"`
+
+	core *CoreNOTAMDataType // read from Properties; nil when absent or unreadable
+}
+
+// UnmarshalJSON keeps the properties verbatim and reads the fields the
+// server uses from them.
+func (f *NotamFeature) UnmarshalJSON(b []byte) error {
+	type plain NotamFeature
+	if err := json.Unmarshal(b, (*plain)(f)); err != nil {
+		return err
+	}
+	f.core = nil
+	var p *PropertiesType
+	if len(f.Properties) > 0 && json.Unmarshal(f.Properties, &p) == nil && p != nil {
+		f.core = &p.CoreNOTAMData
+	}
+	return nil
 }
 
 type NotamResponse struct {
@@ -74,6 +100,8 @@ type NotamResponse struct {
 	TotalCount int            `json:"totalCount"`
 	TotalPages int            `json:"totalPages"`
 	Items      []NotamFeature `json:"items"`
+	// items of this page dropped by active_at / active_between
+	Inactive int `json:"inactive,omitempty"`
 }
 
 // ENV/Config خواندن کلیدها
@@ -83,6 +111,36 @@ func getFAAKeys() (id, secret string) {
 	return cfg.FAACLIENTID, cfg.FAACLIENTSECRET
 }
 
+// faaHeaders builds the upstream headers; credentials go in headers, never
+// into recordings. ok is false when the keys are not configured.
+func faaHeaders() (http.Header, bool) {
+	id, secret := getFAAKeys()
+	if id == "" || secret == "" {
+		return nil, false
+	}
+	hdr := http.Header{}
+	hdr.Set("client_id", id)
+	hdr.Set("client_secret", secret)
+	hdr.Set("Accept", "application/json")
+	return hdr, true
+}
+
+// faaGet is faaUp.Get for the NOTAM path behind the global FAA budget.
+func faaGet(ctx context.Context, q url.Values, hdr http.Header) (*upstream.Response, error) {
+	if err := faaBudget.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return faaUp.Get(ctx, faaNotamsPath, q, hdr)
+}
+
+// faaConcurrency is the number of FAA requests one handler runs at once.
+func faaConcurrency() int {
+	if depCfg.FAAConcurrency < 1 {
+		return 1
+	}
+	return depCfg.FAAConcurrency
+}
+
 // enum helpers
 func inSet(v string, set ...string) bool {
 	if v == "" {
@@ -192,10 +250,10 @@ func buildFAAQuery(r *http.Request) (url.Values, error) {
 // ---------- Handler ----------
 
 // GetNOTAM godoc
-// @Summary      FAA NOTAM proxy (rate-limited 29/min)
-// @Description  Pass-through to FAA NOTAM API with input validation & global rate limit.
+// @Summary      FAA NOTAM proxy (global FAA budget, FAA_RATE_PER_MIN)
+// @Description  Pass-through to FAA NOTAM API with input validation & global rate limit. Each item gets "decoded": the ICAO text parsed into series/number/type, Q-line and items A–G. With all=true every page is read server-side and streamed as {"totalCount","totalPages","items":[...],"count","pages","duplicates","error"} or NDJSON (one item per line); an upstream failure after streaming starts is reported in "error" / a last {"error"} line and the X-Notam-Error trailer.
 // @Tags         NOTAM
-// @Produce      json
+// @Produce      json,application/x-ndjson
 // @Param        domesticLocation  query  string  false  "Domestic/FIR/ICAO location (e.g., OIIX)"
 // @Param        notamType         query  string  false  "N | R | C"
 // @Param        classification    query  string  false  "INTL | MIL | DOM | LMIL | FDC"
@@ -207,6 +265,10 @@ func buildFAAQuery(r *http.Request) (url.Values, error) {
 // @Param        sortOrder         query  string  false  "Asc | Desc"
 // @Param        pageSize          query  int     false  "Default 50 (max 1000)"
 // @Param        pageNum           query  int     false  "Default 1"
+// @Param        all               query  bool    false  "Walk every FAA page (pageSize default 1000, up to FAA_ALL_MAX_PAGES) and stream the de-duplicated items; pageNum is ignored"
+// @Param        format            query  string  false  "With all=true: json (default) | ndjson (also Accept: application/x-ndjson)"
+// @Param        active_at         query  string  false  "Only NOTAMs in force at this time, incl. item-D schedule (RFC3339 or epoch seconds); an unreadable item D is judged on B/C and flagged scheduleUnparsed"
+// @Param        active_between    query  string  false  "Only NOTAMs in force at some point of <from>,<to>"
 /*Headers Params*/
 // @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
 // @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
@@ -232,33 +294,38 @@ func GetNOTAM(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
 		return
 	}
+	win, err := parseActiveWindow(r.URL.Query())
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+
+	all, err := optionalBool(r.URL.Query().Get("all"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid all"}`, http.StatusBadRequest)
+		return
+	}
 
 	// credentials
-	id, secret := getFAAKeys()
-	if id == "" || secret == "" {
+	hdr, ok := faaHeaders()
+	if !ok {
 		http.Error(w, `{"error":"missing FAA client_id/client_secret"}`, http.StatusUnauthorized)
 		return
 	}
+	if all != nil && *all {
+		getAllNOTAMs(w, r, q, hdr, win)
+		return
+	}
 
-	// build upstream request
-	u := fmt.Sprintf("%s?%s", faaBase, q.Encode())
-	req, _ := http.NewRequestWithContext(r.Context(), "GET", u, nil)
-	req.Header.Set("client_id", id)
-	req.Header.Set("client_secret", secret)
-	req.Header.Set("User-Agent", "SepTaf-NOTAM/1.0 (contact: you@example.com)")
-	req.Header.Set("Accept", "application/json")
-
-	client := &http.Client{Timeout: 20 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := faaGet(r.Context(), q, hdr)
 	if err != nil {
 		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
 		return
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		http.Error(w, fmt.Sprintf(`{"error":"upstream %d","upstream":%q}`, resp.StatusCode, string(body)), http.StatusBadGateway)
+	body := resp.Body
+	if resp.Status != http.StatusOK {
+		http.Error(w, fmt.Sprintf(`{"error":"upstream %d","upstream":%q}`, resp.Status, string(body)), http.StatusBadGateway)
 		return
 	}
 
@@ -270,6 +337,42 @@ func GetNOTAM(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write(body)
 		return
 	}
+	decodeNotams(out.Items)
+	storeNotams(out.Items)
+	out.Items, out.Inactive = filterActive(out.Items, win)
 
 	_ = json.NewEncoder(w).Encode(out)
 }
+
+// decodeNotams parses the ICAO text of each item: the ICAO translation
+// when FAA provides one, otherwise notam.text. Items without an FAA
+// geometry get the decoded one.
+func decodeNotams(items []NotamFeature) {
+	for i := range items {
+		d := items[i].core
+		if d == nil {
+			continue
+		}
+		n, err := notam.Parse(notamText(*d))
+		if err != nil {
+			items[i].DecodeError = err.Error()
+			continue
+		}
+		items[i].Decoded = n
+		// FAA فقط گاهی geometry می‌دهد؛ در غیر این صورت از Q-line / item E
+		if g := items[i].Geometry; g == nil || (g.Coordinates == nil && len(g.Geometries) == 0) {
+			if geom, _ := n.Geometry(); geom != nil {
+				items[i].Geometry = &GeoMetry{Type: geom.Type, Coordinates: geom.Coordinates}
+			}
+		}
+	}
+}
+
+func notamText(d CoreNOTAMDataType) string {
+	for _, t := range d.NotamTranslation {
+		if strings.EqualFold(t.Type, "ICAO") && strings.TrimSpace(t.FormattedText) != "" {
+			return t.FormattedText
+		}
+	}
+	return d.Notam.Text
+}
@@ -0,0 +1,267 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type NotamsResponse struct {
+	At    *time.Time     `json:"at,omitempty"`
+	Count int            `json:"count"`
+	Items []mdb.NotamDoc `json:"items"`
+}
+
+// notamStoreQueue is how many FAA pages may wait for the background
+// writer; when it is full a page is dropped and stored on its next fetch.
+const notamStoreQueue = 64
+
+var (
+	notamStoreOnce sync.Once
+	notamStoreCh   chan []mdb.NotamDoc
+)
+
+// storeNotams queues the FAA items for the notams collection (write-through
+// from the NOTAM handlers). The write runs in the background, off the
+// response path; a full queue or a failed write is logged only.
+func storeNotams(items []NotamFeature) {
+	if depMC == nil {
+		return
+	}
+	now := time.Now().UTC()
+	docs := make([]mdb.NotamDoc, 0, len(items))
+	for _, it := range items {
+		if d, ok := notamDoc(it, now); ok {
+			docs = append(docs, d)
+		}
+	}
+	if len(docs) == 0 {
+		return
+	}
+	notamStoreOnce.Do(startNotamStore)
+	select {
+	case notamStoreCh <- docs:
+	default:
+		log.Printf(`{"lvl":"warn","msg":"notams store queue full","dropped":%d}`, len(docs))
+	}
+}
+
+// startNotamStore runs the single writer; one writer keeps the NOTAMR/NOTAMC
+// linking of consecutive pages in arrival order.
+func startNotamStore() {
+	notamStoreCh = make(chan []mdb.NotamDoc, notamStoreQueue)
+	go func() {
+		for docs := range notamStoreCh {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			start := time.Now()
+			err := depMC.BulkUpsertNotams(ctx, docs)
+			cancel()
+			if err != nil {
+				log.Printf(`{"lvl":"error","msg":"notams store","docs":%d,"ms":%d,"err":%q}`, len(docs), time.Since(start).Milliseconds(), err.Error())
+			}
+		}
+	}()
+}
+
+// notamDoc maps an FAA item; the decoded ICAO text wins over the FAA fields.
+func notamDoc(it NotamFeature, now time.Time) (mdb.NotamDoc, bool) {
+	if it.core == nil {
+		return mdb.NotamDoc{}, false
+	}
+	n := it.core.Notam
+	d := mdb.NotamDoc{
+		Location:       strings.ToUpper(firstNonEmpty(n.IcaoLocation, n.Location)),
+		Series:         n.Series,
+		Type:           strings.ToUpper(n.Type),
+		FAAID:          n.Id,
+		Classification: n.Classification,
+		AffectedFIR:    n.AffectedFIR,
+		Issued:         faaTime(n.Issued),
+		EffectiveStart: faaTime(n.EffectiveStart),
+		EffectiveEnd:   faaTime(n.EffectiveEnd),
+		Permanent:      strings.EqualFold(n.EffectiveEnd, "PERM"),
+		Estimated:      strings.HasSuffix(strings.ToUpper(n.EffectiveEnd), "EST"),
+		Schedule:       n.Schedule,
+		Text:           notamText(*it.core),
+		Decoded:        it.Decoded,
+		LastSeen:       now,
+	}
+	// FAA: number گاهی با حرف سری می‌آید (A1234/25) و گاهی بدون آن
+	d.NotamID = n.Number
+	if d.Series != "" && !strings.HasPrefix(n.Number, d.Series) {
+		d.NotamID = d.Series + n.Number
+	}
+	if p := it.Decoded; p != nil {
+		if p.ID != "" {
+			d.NotamID, d.Series, d.Number, d.Year = p.ID, p.Series, p.Number, p.Year
+		}
+		if p.Type != "" {
+			d.Type, d.Ref = p.Type, p.Ref
+		}
+		if d.Location == "" && len(p.Locations) > 0 {
+			d.Location = p.Locations[0]
+		}
+		if p.Q != nil {
+			if d.AffectedFIR == "" {
+				d.AffectedFIR = p.Q.FIR
+			}
+			d.LowerFL, d.UpperFL = p.Q.LowerFL, p.Q.UpperFL
+		}
+		d.Geometry, d.GeometrySource = p.Geometry()
+		if p.From != nil {
+			d.EffectiveStart = p.From
+		}
+		if p.Schedule != "" {
+			d.Schedule = p.Schedule
+		}
+		if p.To != nil || p.Permanent {
+			d.EffectiveEnd, d.Permanent, d.Estimated = p.To, p.Permanent, p.Estimated
+		}
+	}
+	return d, d.Location != "" && d.NotamID != ""
+}
+
+func firstNonEmpty(vs ...string) string {
+	for _, v := range vs {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// faaTime reads FAA ISO timestamps; PERM and anything else unreadable give nil.
+func faaTime(v string) *time.Time {
+	v = strings.TrimSpace(v)
+	if v == "" || strings.EqualFold(v, "PERM") {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(strings.TrimSuffix(v, "EST"))); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
+
+// Current NOTAMs godoc
+// @Summary      NOTAMs in force
+// @Description  NOTAMs stored from /faa/notams and /notams that are in force at `at`: issued, not yet replaced (NOTAMR) or cancelled (NOTAMC) by then, started and not yet ended (EST ends stay in force), and inside their item-D schedule at that time. An unreadable item D is judged on B/C only and flagged schedule_unparsed. NOTAMC themselves are never listed. Give location or fir.
+// @Tags         NOTAM
+// @Produce      json
+// @Param        location  query  string  false  "ICAO location (item A)"
+// @Param        fir       query  string  false  "Affected FIR"
+// @Param        at        query  string  false  "RFC3339 or epoch seconds (default now)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  NotamsResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /notams/current [get]
+func GetCurrentNotams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	filter := bson.M{}
+	if v := strings.ToUpper(strings.TrimSpace(q.Get("location"))); v != "" {
+		filter["location"] = v
+	}
+	if v := strings.ToUpper(strings.TrimSpace(q.Get("fir"))); v != "" {
+		filter["affected_fir"] = v
+	}
+	if len(filter) == 0 {
+		http.Error(w, `{"error":"location or fir is required"}`, http.StatusBadRequest)
+		return
+	}
+	at, err := parseTimeParam(q.Get("at"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid at"}`, http.StatusBadRequest)
+		return
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	docs, err := depMC.CurrentNotams(ctx, filter, at)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	// برنامه‌ی item D (مثلا فقط شب‌ها) در همان لحظه
+	items := docs[:0]
+	for _, d := range docs {
+		if v := docValidity(d); v.ActiveAt(at) {
+			d.ScheduleUnparsed = v.ScheduleErr != nil
+			items = append(items, d)
+		}
+	}
+	_ = json.NewEncoder(w).Encode(NotamsResponse{At: &at, Count: len(items), Items: items})
+}
+
+// NOTAM lineage godoc
+// @Summary      NOTAM lineage
+// @Description  The replace/cancel chain of a stored NOTAM, oldest first: the original NOTAMN, each NOTAMR, and the NOTAMC that ended it, with status and superseded_by on each.
+// @Tags         NOTAM
+// @Produce      json
+// @Param        id        query  string  true  "NOTAM id, e.g. A1234/25"
+// @Param        location  query  string  true  "ICAO location (item A)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  NotamsResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      404  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /notams/lineage [get]
+func GetNotamLineage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	id := strings.ToUpper(strings.TrimSpace(q.Get("id")))
+	loc := strings.ToUpper(strings.TrimSpace(q.Get("location")))
+	if id == "" || loc == "" {
+		http.Error(w, `{"error":"id and location are required"}`, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	items, err := depMC.NotamLineage(ctx, loc, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	if len(items) == 0 {
+		http.Error(w, `{"error":"notam not found"}`, http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(NotamsResponse{Count: len(items), Items: items})
+}
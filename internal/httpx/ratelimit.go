@@ -1,6 +1,7 @@
 package httpx
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"time"
@@ -11,15 +12,18 @@ type RateLimiter struct {
 	mu     sync.Mutex
 	tokens int
 	max    int
+	every  time.Duration
 	tick   *time.Ticker
 }
 
 func NewRateLimiter(maxPerMinute int) *RateLimiter {
+	// هر دقیقه / max → فاصله‌ی شارژ
+	every := time.Minute / time.Duration(maxPerMinute)
 	rl := &RateLimiter{
 		max:    maxPerMinute,
 		tokens: maxPerMinute,
-		// هر دقیقه / max → فاصله‌ی شارژ
-		tick: time.NewTicker(time.Minute / time.Duration(maxPerMinute)),
+		every:  every,
+		tick:   time.NewTicker(every),
 	}
 	go func() {
 		for range rl.tick.C {
@@ -43,6 +47,24 @@ func (rl *RateLimiter) Allow() bool {
 	return false
 }
 
+// Wait blocks until a token is available or ctx is done. A nil limiter
+// never waits.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	if rl == nil {
+		return nil
+	}
+	for !rl.Allow() {
+		t := time.NewTimer(rl.every)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
 // میدل‌ویر
 func LimitMiddleware(rl *RateLimiter, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -86,10 +86,30 @@ func NewRouter(mc *mdb.Client, cfg config.Config) http.Handler {
 	//Proxy
 	protected.HandleFunc("/wx/metar", http.HandlerFunc(GetMETAR))
 	protected.HandleFunc("/wx/taf", http.HandlerFunc(GetTAF))
+	protected.HandleFunc("/wx/taf/decoded", http.HandlerFunc(GetTAFDecoded))
+	protected.HandleFunc("/wx/taf/timeline", http.HandlerFunc(GetTAFTimeline))
+	protected.HandleFunc("/wx/metar/history", http.HandlerFunc(GetMETARHistory))
+	protected.HandleFunc("/wx/taf/history", http.HandlerFunc(GetTAFHistory))
+	protected.HandleFunc("/wx/taf/verification", http.HandlerFunc(GetTAFVerification))
+	protected.HandleFunc("/wx/overview", http.HandlerFunc(GetWXOverview))
+	protected.HandleFunc("/wx/sigmet", http.HandlerFunc(GetSIGMET))
+	protected.HandleFunc("/wx/pirep", http.HandlerFunc(GetPIREP))
+	protected.HandleFunc("/wx/windtemp", http.HandlerFunc(GetWindsAloft))
+	protected.HandleFunc("/wx/windtemp/route", http.HandlerFunc(GetWindsRoute))
+	protected.HandleFunc("/wx/stations", http.HandlerFunc(GetWXStations))
+	protected.HandleFunc("/wx/derived", http.HandlerFunc(GetWXDerived))
+	protected.HandleFunc("/wx/alternates", http.HandlerFunc(GetAlternates))
+	protected.HandleFunc("GET /wx/watch", http.HandlerFunc(ListWatchRules))
+	protected.HandleFunc("POST /wx/watch", http.HandlerFunc(CreateWatchRule))
+	protected.HandleFunc("DELETE /wx/watch/{id}", http.HandlerFunc(DeleteWatchRule))
 	protected.HandleFunc("/countries_find", http.HandlerFunc(findacountries))
 	//rl := NewRateLimiter(29)
 	//protected.Handle("/faa/notams", LimitMiddleware(rl, http.HandlerFunc(GetNOTAM)))
 	protected.HandleFunc("/faa/notams", http.HandlerFunc(GetNOTAM))
+	protected.HandleFunc("GET /notams", http.HandlerFunc(GetNotams))
+	protected.HandleFunc("GET /notams/current", http.HandlerFunc(GetCurrentNotams))
+	protected.HandleFunc("GET /notams/lineage", http.HandlerFunc(GetNotamLineage))
+	protected.HandleFunc("GET /notams/area", http.HandlerFunc(GetNotamsArea))
 
 	auth := NewAuthMiddleware(cfg, mc)
 	root := http.NewServeMux()
@@ -100,6 +120,8 @@ func NewRouter(mc *mdb.Client, cfg config.Config) http.Handler {
 	root.Handle("/fir_list", auth.Handler(protected))
 	root.Handle("/wx/", auth.Handler(protected))
 	root.Handle("/faa/", auth.Handler(protected))
+	root.Handle("/notams", auth.Handler(protected))
+	root.Handle("/notams/", auth.Handler(protected))
 
 	return root
 }
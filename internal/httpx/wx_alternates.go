@@ -0,0 +1,267 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"SepTaf/internal/alternate"
+	"SepTaf/internal/geo"
+	"SepTaf/internal/wx/taf"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxAltCandidates = 100 // فرودگاه‌های نزدیک قبل از فیلتر باند
+
+type AlternateDTO struct {
+	ICAO            string   `json:"icao"`
+	Name            string   `json:"name,omitempty"`
+	Type            string   `json:"type,omitempty"`
+	DistanceNM      float64  `json:"distance_nm"`
+	BearingDeg      float64  `json:"bearing_deg"` // from the destination
+	ElevationFt     *int     `json:"elevation_ft,omitempty"`
+	LongestRunwayFt int      `json:"longest_runway_ft"`
+	Runways         []string `json:"runways"` // runways meeting min_runway_ft / hard_surface
+	alternate.Assessment
+	RawTAF string `json:"raw_taf,omitempty"`
+}
+
+type AlternatesResponse struct {
+	Destination string           `json:"destination"`
+	ETAFrom     time.Time        `json:"eta_from"`
+	ETATo       time.Time        `json:"eta_to"`
+	Minima      alternate.Minima `json:"minima"`
+	MinRunwayFt int              `json:"min_runway_ft"`
+	HardSurface bool             `json:"hard_surface"`
+	Items       []AlternateDTO   `json:"items"`
+	// candidates in range that were dropped, by reason
+	Excluded map[string]int `json:"excluded,omitempty"`
+}
+
+// WX alternates godoc
+// @Summary      Weather-aware alternates
+// @Description  Airports within radius_nm of the destination (airports 2dsphere index), filtered by type and runway (length, hard surface, open), ranked by their current TAF over the ETA window against planning minima: ok (worst case incl. TEMPO/PROB meets), marginal (only a TEMPO/PROB group is below), below, no_taf; then by distance. Minima default to ALT_* config.
+// @Tags         Weather
+// @Produce      json
+// @Param        dest              query   string  true   "Destination airport (ICAO/GPS code)"
+// @Param        eta_from          query   string  false  "RFC3339 or epoch seconds (default now)"
+// @Param        eta_to            query   string  false  "RFC3339 or epoch seconds (default eta_from+1h)"
+// @Param        radius_nm         query   number  false  "Search radius (default 200, max 500)"
+// @Param        type              query   string  false  "Airport types (default large_airport,medium_airport)"
+// @Param        min_runway_ft     query   int     false  "Minimum runway length (default ALT_MIN_RUNWAY_FT)"
+// @Param        hard_surface      query   bool    false  "Require a paved runway (default true)"
+// @Param        min_ceiling_ft    query   int     false  "Ceiling minimum (default ALT_MIN_CEILING_FT)"
+// @Param        min_vis_m         query   int     false  "Visibility minimum in m (default ALT_MIN_VIS_M)"
+// @Param        max_crosswind_kt  query   number  false  "Crosswind limit on the best runway, 0 = off (default ALT_MAX_CROSSWIND_KT)"
+// @Param        include_tempo     query   bool    false  "Check TEMPO/PROB groups too (default true)"
+// @Param        limit             query   int     false  "Alternates to evaluate (default 10, max 25)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  AlternatesResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      502  {object}  HTTPError
+// @Router       /wx/alternates [get]
+func GetAlternates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	dest := strings.ToUpper(strings.TrimSpace(q.Get("dest")))
+	if dest == "" {
+		http.Error(w, `{"error":"dest is required"}`, http.StatusBadRequest)
+		return
+	}
+	from, err1 := parseTimeParam(q.Get("eta_from"))
+	to, err2 := parseTimeParam(q.Get("eta_to"))
+	if err1 != nil || err2 != nil {
+		http.Error(w, `{"error":"invalid eta_from/eta_to"}`, http.StatusBadRequest)
+		return
+	}
+	if from.IsZero() {
+		from = time.Now().UTC()
+	}
+	if to.IsZero() {
+		to = from.Add(time.Hour)
+	}
+	if !to.After(from) || to.Sub(from) > 30*time.Hour {
+		http.Error(w, `{"error":"eta_to must be after eta_from and within 30h"}`, http.StatusBadRequest)
+		return
+	}
+
+	resp := AlternatesResponse{
+		Destination: dest,
+		ETAFrom:     from,
+		ETATo:       to,
+		Minima: alternate.Minima{
+			CeilingFt:      depCfg.AltMinCeilingFt,
+			VisibilityM:    float64(depCfg.AltMinVisM),
+			MaxCrosswindKt: float64(depCfg.AltMaxCrosswindKt),
+			IncludeTempo:   true,
+		},
+		MinRunwayFt: depCfg.AltMinRunwayFt,
+		HardSurface: true,
+		Items:       []AlternateDTO{},
+		Excluded:    map[string]int{},
+	}
+	radius, err := floatParam(q.Get("radius_nm"), 200, 500)
+	if err != nil {
+		http.Error(w, `{"error":"invalid radius_nm"}`, http.StatusBadRequest)
+		return
+	}
+	ceil, err1 := floatParam(q.Get("min_ceiling_ft"), float64(resp.Minima.CeilingFt), 10000)
+	vis, err2 := floatParam(q.Get("min_vis_m"), resp.Minima.VisibilityM, 10000)
+	rwy, err3 := floatParam(q.Get("min_runway_ft"), float64(resp.MinRunwayFt), 20000)
+	if err1 != nil || err2 != nil || err3 != nil {
+		http.Error(w, `{"error":"invalid min_ceiling_ft/min_vis_m/min_runway_ft"}`, http.StatusBadRequest)
+		return
+	}
+	resp.Minima.CeilingFt, resp.Minima.VisibilityM, resp.MinRunwayFt = int(ceil), vis, int(rwy)
+	// 0 = بدون بررسی crosswind
+	if v := strings.TrimSpace(q.Get("max_crosswind_kt")); v == "0" {
+		resp.Minima.MaxCrosswindKt = 0
+	} else if resp.Minima.MaxCrosswindKt, err = floatParam(v, resp.Minima.MaxCrosswindKt, 100); err != nil {
+		http.Error(w, `{"error":"invalid max_crosswind_kt"}`, http.StatusBadRequest)
+		return
+	}
+	for name, dst := range map[string]*bool{"hard_surface": &resp.HardSurface, "include_tempo": &resp.Minima.IncludeTempo} {
+		b, err := optionalBool(q.Get(name))
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"invalid %s"}`, name), http.StatusBadRequest)
+			return
+		}
+		if b != nil {
+			*dst = *b
+		}
+	}
+	limit := int(getLimit(r, 10, 25))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	center, err := airportPoint(ctx, dest)
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+
+	// $nearSphere: به ترتیب فاصله، با ایندکس 2dsphere
+	cur, err := depMC.DB.Collection("airports").Find(ctx, bson.M{
+		"location": bson.M{"$nearSphere": bson.M{
+			"$geometry":    bson.M{"type": "Point", "coordinates": []float64{center.Lon, center.Lat}},
+			"$maxDistance": radius * geo.MetersPerNM,
+		}},
+		"type":      bson.M{"$in": airportTypes(q)},
+		"icao_code": bson.M{"$regex": `^[A-Z0-9]{4}$`, "$ne": dest},
+		"gps_code":  bson.M{"$ne": dest},
+		"ident":     bson.M{"$ne": dest},
+	}, options.Find().
+		SetProjection(bson.M{"_id": 0, "ident": 1, "icao_code": 1, "name": 1, "type": 1, "location": 1, "elevation_ft": 1}).
+		SetLimit(maxAltCandidates))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	var airports []AirportDTO
+	if err := cur.All(ctx, &airports); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	idents := make([]string, 0, len(airports))
+	for _, a := range airports {
+		idents = append(idents, a.Ident)
+	}
+	runways, err := depMC.RunwaysByAirport(ctx, idents)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	spec := alternate.RunwaySpec{MinLengthFt: resp.MinRunwayFt, HardSurface: resp.HardSurface}
+	headings := map[string][]float64{}
+	var codes []string
+	for _, a := range airports {
+		if a.Location == nil {
+			continue
+		}
+		if len(resp.Items) >= limit {
+			resp.Excluded["beyond_limit"]++
+			continue
+		}
+		rw, ok := runways[a.Ident]
+		if !ok {
+			resp.Excluded["no_runway_data"]++
+			continue
+		}
+		names, hdgs, longest := spec.Usable(rw)
+		if len(names) == 0 {
+			resp.Excluded["runway"]++
+			continue
+		}
+		p := geo.Point{Lat: a.Location.Coordinates[1], Lon: a.Location.Coordinates[0]}
+		resp.Items = append(resp.Items, AlternateDTO{
+			ICAO:            a.IcaoCode,
+			Name:            a.Name,
+			Type:            a.Type,
+			DistanceNM:      round2(geo.DistanceNM(center, p)),
+			BearingDeg:      round2(geo.Bearing(center, p)),
+			ElevationFt:     a.ElevationFt,
+			LongestRunwayFt: longest,
+			Runways:         names,
+		})
+		headings[a.IcaoCode] = hdgs
+		codes = append(codes, a.IcaoCode)
+	}
+
+	tafs := map[string]TafDTO{}
+	if len(codes) > 0 {
+		body, code, meta, err := fetchAWCStations(r.Context(), "taf", codes, 24)
+		setCacheHeaders(w, meta)
+		if err != nil && code != http.StatusNoContent {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+			return
+		}
+		if code != http.StatusNoContent {
+			var items []TafDTO
+			if e := json.Unmarshal(body, &items); e != nil {
+				http.Error(w, `{"error":"invalid upstream json"}`, http.StatusBadGateway)
+				return
+			}
+			for _, t := range currentTafs(items) {
+				tafs[t.ICAOId] = t
+			}
+		}
+	}
+
+	for i := range resp.Items {
+		it := &resp.Items[i]
+		var parsed *taf.TAF
+		if t, ok := tafs[it.ICAO]; ok {
+			it.RawTAF = t.RawTAF
+			if p, err := taf.Parse(t.RawTAF, t.IssueTime); err == nil {
+				parsed = p
+			}
+		}
+		it.Assessment = alternate.Assess(parsed, from, to, headings[it.ICAO], resp.Minima)
+	}
+	sort.SliceStable(resp.Items, func(i, j int) bool {
+		a, b := resp.Items[i], resp.Items[j]
+		if ra, rb := alternate.Rank(a.Status), alternate.Rank(b.Status); ra != rb {
+			return ra < rb
+		}
+		return a.DistanceNM < b.DistanceNM
+	})
+	_ = json.NewEncoder(w).Encode(resp)
+}
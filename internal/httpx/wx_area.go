@@ -0,0 +1,257 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/geo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxAreaStations = 300 // سقف ایستگاه برای یک درخواست ناحیه‌ای
+	awcBatchSize    = 100 // تعداد ids در هر فراخوانی AWC
+)
+
+// badRequest marks errors caused by client input (→ 400) as opposed to
+// upstream/DB failures.
+type badRequest struct{ msg string }
+
+func (e badRequest) Error() string { return e.msg }
+
+// resolveStations turns the station selectors of a /wx request into a list
+// of ICAO ids. Exactly one of icao, ids, country, fir_code or bbox is used,
+// in that order of precedence.
+func resolveStations(ctx context.Context, q url.Values) ([]string, error) {
+	if v := strings.TrimSpace(q.Get("icao")); v != "" {
+		icao := strings.ToUpper(v)
+		if !icaoRe.MatchString(icao) {
+			return nil, badRequest{"invalid ICAO"}
+		}
+		return []string{icao}, nil
+	}
+	if v := strings.TrimSpace(q.Get("ids")); v != "" {
+		var out []string
+		seen := map[string]bool{}
+		for _, id := range strings.Split(v, ",") {
+			id = strings.ToUpper(strings.TrimSpace(id))
+			if id == "" || seen[id] {
+				continue
+			}
+			if !icaoRe.MatchString(id) {
+				return nil, badRequest{fmt.Sprintf("invalid ICAO in ids: %s", id)}
+			}
+			seen[id] = true
+			out = append(out, id)
+		}
+		if len(out) > maxAreaStations {
+			return nil, badRequest{fmt.Sprintf("too many ids (max %d)", maxAreaStations)}
+		}
+		return out, nil
+	}
+
+	filter, err := areaFilter(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		return nil, badRequest{"one of icao, ids, country, fir_code or bbox is required"}
+	}
+	return stationsForFilter(ctx, filter, airportTypes(q))
+}
+
+// areaFilter builds an airports filter for country / fir_code / bbox, or
+// returns nil if none of them is set.
+func areaFilter(ctx context.Context, q url.Values) (bson.M, error) {
+	if v := strings.TrimSpace(q.Get("country")); v != "" {
+		return bson.M{"iso_country": strings.ToUpper(v)}, nil
+	}
+	if v := strings.TrimSpace(q.Get("fir_code")); v != "" {
+		geom, err := firGeometry(ctx, strings.ToUpper(v))
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"location": bson.M{"$geoWithin": bson.M{"$geometry": geom}}}, nil
+	}
+	if v := strings.TrimSpace(q.Get("bbox")); v != "" {
+		poly, err := parseBBox(v)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"location": bson.M{"$geoWithin": bson.M{"$geometry": poly}}}, nil
+	}
+	return nil, nil
+}
+
+// firGeometry returns the stored GeoJSON of a FIR. FIRs imported only from
+// Wikipedia have no geometry and cannot be used for spatial queries.
+func firGeometry(ctx context.Context, code string) (any, error) {
+	var doc struct {
+		Geometry any `bson:"geometry"`
+	}
+	err := depMC.FIRsCollection().FindOne(ctx,
+		bson.M{"fir_code": code, "geometry": bson.M{"$ne": nil}},
+		options.FindOne().SetProjection(bson.M{"geometry": 1})).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, badRequest{fmt.Sprintf("no geometry stored for FIR %s", code)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Geometry, nil
+}
+
+// parseBBox parses "minLon,minLat,maxLon,maxLat" into a GeoJSON polygon.
+func parseBBox(v string) (bson.M, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) != 4 {
+		return nil, badRequest{"bbox must be minLon,minLat,maxLon,maxLat"}
+	}
+	var f [4]float64
+	for i, p := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, badRequest{"bbox must be minLon,minLat,maxLon,maxLat"}
+		}
+		f[i] = x
+	}
+	minLon, minLat, maxLon, maxLat := f[0], f[1], f[2], f[3]
+	if minLon >= maxLon || minLat >= maxLat || minLat < -90 || maxLat > 90 || minLon < -180 || maxLon > 180 {
+		return nil, badRequest{"invalid bbox"}
+	}
+	return bson.M{
+		"type": "Polygon",
+		"coordinates": [][][]float64{{
+			{minLon, minLat}, {maxLon, minLat}, {maxLon, maxLat}, {minLon, maxLat}, {minLon, minLat},
+		}},
+	}, nil
+}
+
+// airportTypes reads ?type= (comma list); by default only large/medium
+// airports are considered, which is where METAR/TAF service usually is.
+func airportTypes(q url.Values) []string {
+	v := strings.TrimSpace(q.Get("type"))
+	if v == "" {
+		return []string{"large_airport", "medium_airport"}
+	}
+	var out []string
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func stationsForFilter(ctx context.Context, filter bson.M, types []string) ([]string, error) {
+	filter["icao_code"] = bson.M{"$regex": `^[A-Z0-9]{4}$`}
+	if len(types) > 0 {
+		filter["type"] = bson.M{"$in": types}
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0, "icao_code": 1}).
+		SetSort(bson.D{{Key: "icao_code", Value: 1}}).
+		SetLimit(maxAreaStations + 1)
+
+	cur, err := depMC.DB.Collection("airports").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []struct {
+		IcaoCode string `bson:"icao_code"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) > maxAreaStations {
+		return nil, badRequest{fmt.Sprintf("area resolves to more than %d stations; narrow it with type= or a smaller area", maxAreaStations)}
+	}
+	out := make([]string, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, r.IcaoCode)
+	}
+	return out, nil
+}
+
+// fetchAWCStations fetches a resource for many stations in batches and
+// merges the JSON arrays. The cache meta reflects the oldest batch.
+func fetchAWCStations(ctx context.Context, resource string, stations []string, hours int) ([]byte, int, cacheMeta, error) {
+	if len(stations) == 1 {
+		return fetchAWCCached(ctx, resource, stations[0], hours)
+	}
+
+	merged := []json.RawMessage{}
+	meta := cacheMeta{State: "HIT"}
+	for start := 0; start < len(stations); start += awcBatchSize {
+		end := start + awcBatchSize
+		if end > len(stations) {
+			end = len(stations)
+		}
+		b, code, m, err := fetchAWCCached(ctx, resource, strings.Join(stations[start:end], ","), hours)
+		if code == http.StatusNoContent {
+			continue // AWC: no data for this batch
+		}
+		if err != nil {
+			return b, code, m, err
+		}
+		var part []json.RawMessage
+		if e := json.Unmarshal(b, &part); e != nil {
+			return b, http.StatusBadGateway, m, fmt.Errorf("invalid upstream json")
+		}
+		merged = append(merged, part...)
+		if m.State != "HIT" {
+			meta.State = m.State
+		}
+		if m.Age > meta.Age {
+			meta.Age = m.Age
+		}
+	}
+	out, _ := json.Marshal(merged)
+	return out, http.StatusOK, meta, nil
+}
+
+// stationsForRequest resolves the station selectors of r with a short DB timeout.
+func stationsForRequest(r *http.Request) ([]string, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	return resolveStations(ctx, r.URL.Query())
+}
+
+// writeStationError maps resolveStations errors to 400 (bad input) or 500.
+func writeStationError(w http.ResponseWriter, err error) {
+	var br badRequest
+	if errors.As(err, &br) {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, br.msg), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+}
+
+// airportPoint looks up an airport by icao_code, gps_code or ident and
+// returns its position.
+func airportPoint(ctx context.Context, code string) (geo.Point, error) {
+	var doc struct {
+		Location *GeoJSONPoint `bson:"location"`
+	}
+	err := depMC.DB.Collection("airports").FindOne(ctx,
+		bson.M{"$or": []bson.M{{"icao_code": code}, {"gps_code": code}, {"ident": code}}, "location": bson.M{"$ne": nil}},
+		options.FindOne().SetProjection(bson.M{"location": 1})).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && doc.Location == nil) {
+		return geo.Point{}, badRequest{fmt.Sprintf("unknown airport %s", code)}
+	}
+	if err != nil {
+		return geo.Point{}, err
+	}
+	return geo.Point{Lat: doc.Location.Coordinates[1], Lon: doc.Location.Coordinates[0]}, nil
+}
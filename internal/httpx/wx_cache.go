@@ -0,0 +1,193 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"golang.org/x/sync/singleflight"
+)
+
+// کش جلوی fetchAWC:
+//   - تازه (سن < TTL): مستقیم از کش
+//   - کهنه ولی در بازه‌ی stale: همان را سرو کن و در پس‌زمینه refresh کن
+//   - خطای upstream: اگر نسخه‌ای جوان‌تر از max-stale داریم، همان را بده
+//   - درخواست‌های هم‌زمان برای یک کلید فقط یک فراخوانی upstream دارند
+type awcEntry struct {
+	Body      []byte
+	Status    int
+	FetchedAt time.Time
+}
+
+type cacheMeta struct {
+	State string // HIT | MISS | STALE | BYPASS
+	Age   time.Duration
+}
+
+type awcCache struct {
+	mu       sync.Mutex
+	mem      map[string]awcEntry
+	sf       singleflight.Group
+	ttl      time.Duration
+	stale    time.Duration
+	maxStale time.Duration // سقف سن برای stale-if-error
+	mc       *mdb.Client   // nil => فقط حافظه
+	fetch    func(ctx context.Context, resource, icao string, hours int) ([]byte, int, error)
+}
+
+var (
+	wxCacheOnce sync.Once
+	wxCache     *awcCache
+)
+
+func getWXCache() *awcCache {
+	wxCacheOnce.Do(func() { wxCache = newAWCCache(fetchAWC) })
+	return wxCache
+}
+
+// newAWCCache builds a cache from the TTL/stale config around one upstream
+// fetch function; all caches share the wx_cache collection, keyed by resource.
+func newAWCCache(fetch func(ctx context.Context, resource, icao string, hours int) ([]byte, int, error)) *awcCache {
+	ttl := time.Duration(depCfg.CacheTTLSeconds) * time.Second
+	stale := time.Duration(depCfg.CacheStaleSeconds) * time.Second
+	if stale < ttl {
+		stale = ttl
+	}
+	maxStale := time.Duration(depCfg.CacheMaxStaleSeconds) * time.Second
+	if maxStale < stale {
+		maxStale = stale
+	}
+	c := &awcCache{
+		mem:      make(map[string]awcEntry),
+		ttl:      ttl,
+		stale:    stale,
+		maxStale: maxStale,
+		fetch:    fetch,
+	}
+	if depCfg.CacheMongo && depMC != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := depMC.EnsureWXCacheIndexes(ctx, maxStale); err != nil {
+			log.Printf(`{"lvl":"warn","msg":"wx cache index","err":%q}`, err.Error())
+		}
+		c.mc = depMC
+	}
+	return c
+}
+
+func cacheKey(resource, icao string, hours int) string {
+	return fmt.Sprintf("%s|%s|%d", resource, strings.ToUpper(icao), hours)
+}
+
+// fetchAWCCached is fetchAWC behind the cache. Only 200 responses are stored.
+func fetchAWCCached(ctx context.Context, resource, icao string, hours int) ([]byte, int, cacheMeta, error) {
+	return getWXCache().get(ctx, resource, icao, hours)
+}
+
+func (c *awcCache) get(ctx context.Context, resource, icao string, hours int) ([]byte, int, cacheMeta, error) {
+	if c.ttl <= 0 {
+		b, code, err := c.fetch(ctx, resource, icao, hours)
+		return b, code, cacheMeta{State: "BYPASS"}, err
+	}
+
+	key := cacheKey(resource, icao, hours)
+	e, ok := c.lookup(ctx, key)
+	age := time.Since(e.FetchedAt)
+	if ok && age < c.ttl {
+		return e.Body, e.Status, cacheMeta{State: "HIT", Age: age}, nil
+	}
+	if ok && age < c.stale {
+		c.revalidate(key, resource, icao, hours)
+		return e.Body, e.Status, cacheMeta{State: "STALE", Age: age}, nil
+	}
+
+	f, err := c.load(ctx, key, resource, icao, hours)
+	if err != nil {
+		// stale-if-error, تا سقف max-stale
+		if ok && age < c.maxStale {
+			return e.Body, e.Status, cacheMeta{State: "STALE", Age: age}, nil
+		}
+		return f.Body, f.Status, cacheMeta{State: "MISS"}, err
+	}
+	return f.Body, f.Status, cacheMeta{State: "MISS"}, nil
+}
+
+// load fetches key upstream and stores the result. Concurrent callers for
+// the same resource/icao/hours share one request; each still gives up when
+// its own ctx ends.
+func (c *awcCache) load(ctx context.Context, key, resource, icao string, hours int) (awcEntry, error) {
+	ch := c.sf.DoChan(key, func() (any, error) {
+		// درخواست مشترک نباید با لغو اولین caller قطع شود
+		fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 20*time.Second)
+		defer cancel()
+		b, code, err := c.fetch(fctx, resource, icao, hours)
+		e := awcEntry{Body: b, Status: code, FetchedAt: time.Now()}
+		if err == nil {
+			c.store(fctx, key, e)
+		}
+		return e, err
+	})
+	select {
+	case res := <-ch:
+		return res.Val.(awcEntry), res.Err
+	case <-ctx.Done():
+		return awcEntry{}, ctx.Err()
+	}
+}
+
+func (c *awcCache) lookup(ctx context.Context, key string) (awcEntry, bool) {
+	c.mu.Lock()
+	e, ok := c.mem[key]
+	c.mu.Unlock()
+	if ok && time.Since(e.FetchedAt) < c.ttl {
+		return e, true
+	}
+	if c.mc != nil {
+		if d, err := c.mc.GetWXCache(ctx, key); err == nil && (!ok || d.FetchedAt.After(e.FetchedAt)) {
+			e = awcEntry{Body: d.Body, Status: d.Status, FetchedAt: d.FetchedAt}
+			ok = true
+			c.mu.Lock()
+			c.mem[key] = e
+			c.mu.Unlock()
+		}
+	}
+	return e, ok
+}
+
+func (c *awcCache) store(ctx context.Context, key string, e awcEntry) {
+	c.mu.Lock()
+	c.mem[key] = e
+	// پاکسازی ساده: ورودی‌هایی که دیگر حتی در خطا هم سرو نمی‌شوند
+	for k, v := range c.mem {
+		if time.Since(v.FetchedAt) > c.maxStale {
+			delete(c.mem, k)
+		}
+	}
+	c.mu.Unlock()
+	if c.mc != nil {
+		if err := c.mc.PutWXCache(ctx, mdb.WXCacheDoc{Key: key, Body: e.Body, Status: e.Status, FetchedAt: e.FetchedAt}); err != nil {
+			log.Printf(`{"lvl":"warn","msg":"wx cache put","key":%q,"err":%q}`, key, err.Error())
+		}
+	}
+}
+
+// revalidate refreshes one key in the background; it joins any upstream
+// request already in flight for the key.
+func (c *awcCache) revalidate(key, resource, icao string, hours int) {
+	go func() {
+		if _, err := c.load(context.Background(), key, resource, icao, hours); err != nil {
+			log.Printf(`{"lvl":"warn","msg":"wx revalidate failed","key":%q,"err":%q}`, key, err.Error())
+		}
+	}()
+}
+
+func setCacheHeaders(w http.ResponseWriter, m cacheMeta) {
+	w.Header().Set("X-Cache", m.State)
+	w.Header().Set("Age", strconv.Itoa(int(m.Age.Seconds())))
+}
@@ -0,0 +1,163 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"SepTaf/internal/wx/derived"
+	"SepTaf/internal/wx/metar"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DerivedDTO struct {
+	ICAO string `json:"icao"`
+	// ObsTime/RawOb are from the latest METAR used
+	ObsTime   time.Time      `json:"obs_time"`
+	RawOb     string         `json:"raw_ob"`
+	ElevFrom  string         `json:"elevation_source"` // airport | station
+	Derived   derived.Values `json:"derived"`
+	DecodeErr string         `json:"decode_error,omitempty"`
+}
+
+type DerivedResponse struct {
+	Items []DerivedDTO `json:"items"`
+}
+
+// WX derived godoc
+// @Summary      Derived performance values
+// @Description  From the latest METAR and the airport elevation (airports.elevation_ft, else the AWC station elevation): pressure altitude, density altitude (virtual temperature), ISA deviation, relative humidity, convective cloud-base estimate (spread × 400 ft) and QFE.
+// @Tags         Weather
+// @Produce      json
+// @Param        icao     query   string  false  "ICAO code (e.g., OIII)"
+// @Param        ids      query   string  false  "Comma-separated ICAO list"
+// @Param        country  query   string  false  "ISO country; resolved to stations via airports"
+// @Param        fir_code query   string  false  "FIR code (e.g., OIIX)"
+// @Param        bbox     query   string  false  "minLon,minLat,maxLon,maxLat"
+// @Param        type     query   string  false  "Airport types for area queries (default large_airport,medium_airport)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  DerivedResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      502  {object}  HTTPError
+// @Router       /wx/derived [get]
+func GetWXDerived(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	stations, err := stationsForRequest(r)
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+
+	body, code, meta, err := fetchAWCStations(r.Context(), "metar", stations, 2)
+	setCacheHeaders(w, meta)
+	resp := DerivedResponse{Items: []DerivedDTO{}}
+	if code == http.StatusNoContent {
+		_ = json.NewEncoder(w).Encode(resp)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+	var items []MetarDTO
+	if e := json.Unmarshal(body, &items); e != nil {
+		http.Error(w, `{"error":"invalid upstream json"}`, http.StatusBadGateway)
+		return
+	}
+
+	// فقط آخرین METAR هر ایستگاه
+	latest := map[string]MetarDTO{}
+	var order []string
+	for _, it := range items {
+		cur, ok := latest[it.ICAOId]
+		if !ok {
+			order = append(order, it.ICAOId)
+		}
+		if !ok || it.ObsTime > cur.ObsTime {
+			latest[it.ICAOId] = it
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	elev, err := airportElevations(ctx, order)
+	cancel()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	for _, id := range order {
+		it := latest[id]
+		obs := time.Unix(it.ObsTime, 0).UTC()
+		d := DerivedDTO{ICAO: id, ObsTime: obs, RawOb: it.RawOb, ElevFrom: "airport"}
+		ft, ok := elev[id]
+		if !ok {
+			ft = int(math.Round(float64(it.Elev) * derived.FtPerM))
+			d.ElevFrom = "station"
+		}
+		rep, err := metar.Parse(it.RawOb, obs)
+		if err != nil {
+			d.DecodeErr = err.Error()
+			d.Derived = derived.Values{ElevationFt: ft}
+		} else {
+			d.Derived = derived.Compute(rep, ft)
+		}
+		resp.Items = append(resp.Items, d)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// airportElevations maps station codes to airports.elevation_ft, matching
+// icao_code, gps_code or ident.
+func airportElevations(ctx context.Context, codes []string) (map[string]int, error) {
+	out := map[string]int{}
+	if len(codes) == 0 {
+		return out, nil
+	}
+	cur, err := depMC.DB.Collection("airports").Find(ctx,
+		bson.M{
+			"$or":          []bson.M{{"icao_code": bson.M{"$in": codes}}, {"gps_code": bson.M{"$in": codes}}, {"ident": bson.M{"$in": codes}}},
+			"elevation_ft": bson.M{"$ne": nil},
+		},
+		options.Find().SetProjection(bson.M{"_id": 0, "icao_code": 1, "gps_code": 1, "ident": 1, "elevation_ft": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var docs []AirportDTO
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	want := map[string]bool{}
+	for _, c := range codes {
+		want[c] = true
+	}
+	// ident، بعد gps_code و در آخر icao_code (که بر بقیه اولویت دارد)
+	for _, field := range []func(AirportDTO) string{
+		func(a AirportDTO) string { return a.Ident },
+		func(a AirportDTO) string { return a.GPSCode },
+		func(a AirportDTO) string { return a.IcaoCode },
+	} {
+		for _, a := range docs {
+			if c := strings.ToUpper(field(a)); want[c] && a.ElevationFt != nil {
+				out[c] = *a.ElevationFt
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,179 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MetarHistoryResponse struct {
+	Items []mdb.MetarHistoryDoc `json:"items"`
+	Meta  PageMeta              `json:"meta"`
+}
+
+type TafHistoryResponse struct {
+	Items []mdb.TafHistoryDoc `json:"items"`
+	Meta  PageMeta            `json:"meta"`
+}
+
+// parseRange reads from/to (RFC3339 or epoch) with a default lookback.
+func parseRange(r *http.Request, lookback time.Duration) (time.Time, time.Time, bool) {
+	from, err1 := parseTimeParam(r.URL.Query().Get("from"))
+	to, err2 := parseTimeParam(r.URL.Query().Get("to"))
+	if err1 != nil || err2 != nil {
+		return from, to, false
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-lookback)
+	}
+	return from, to, !from.After(to)
+}
+
+// METAR history godoc
+// @Summary      METAR history
+// @Description  Archived METAR/SPECI for a station between from and to (oldest first)
+// @Tags         Weather
+// @Produce      json
+// @Param        icao   query   string  true   "ICAO code (e.g., OIII)"
+// @Param        from   query   string  false  "RFC3339 or epoch seconds (default to-24h)"
+// @Param        to     query   string  false  "RFC3339 or epoch seconds (default now)"
+// @Param        page   query   int     false  "page (>=1)"      default(1)
+// @Param        limit  query   int     false  "items per page"  default(100)  minimum(1)  maximum(1000)
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  MetarHistoryResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /wx/metar/history [get]
+func GetMETARHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+
+	icao := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("icao")))
+	if !icaoRe.MatchString(icao) {
+		http.Error(w, `{"error":"invalid ICAO"}`, http.StatusBadRequest)
+		return
+	}
+	from, to, ok := parseRange(r, 24*time.Hour)
+	if !ok {
+		http.Error(w, `{"error":"invalid from/to"}`, http.StatusBadRequest)
+		return
+	}
+
+	filter := bson.M{"icao": icao, "obs_time": bson.M{"$gte": from, "$lte": to}}
+	page := getPage(r)
+	limit := getLimit(r, 100, 1000)
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0}).
+		SetSort(bson.D{{Key: "obs_time", Value: 1}}).
+		SetSkip(int64(page-1) * limit).
+		SetLimit(limit)
+
+	cur, err := depMC.MetarHistoryCol().Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cur.Close(ctx)
+
+	items := []mdb.MetarHistoryDoc{}
+	if err := cur.All(ctx, &items); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total, _ := depMC.MetarHistoryCol().CountDocuments(ctx, filter)
+
+	_ = json.NewEncoder(w).Encode(MetarHistoryResponse{
+		Items: items,
+		Meta:  PageMeta{Page: page, Limit: int(limit), Total: total},
+	})
+}
+
+// TAF history godoc
+// @Summary      TAF history
+// @Description  Archived TAFs for a station issued between from and to (oldest first)
+// @Tags         Weather
+// @Produce      json
+// @Param        icao   query   string  true   "ICAO code (e.g., OIII)"
+// @Param        from   query   string  false  "RFC3339 or epoch seconds (default to-72h)"
+// @Param        to     query   string  false  "RFC3339 or epoch seconds (default now)"
+// @Param        page   query   int     false  "page (>=1)"      default(1)
+// @Param        limit  query   int     false  "items per page"  default(50)  minimum(1)  maximum(500)
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  TafHistoryResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /wx/taf/history [get]
+func GetTAFHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+
+	icao := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("icao")))
+	if !icaoRe.MatchString(icao) {
+		http.Error(w, `{"error":"invalid ICAO"}`, http.StatusBadRequest)
+		return
+	}
+	from, to, ok := parseRange(r, 72*time.Hour)
+	if !ok {
+		http.Error(w, `{"error":"invalid from/to"}`, http.StatusBadRequest)
+		return
+	}
+
+	filter := bson.M{"icao": icao, "issue_time": bson.M{"$gte": from, "$lte": to}}
+	page := getPage(r)
+	limit := getLimit(r, 50, 500)
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0}).
+		SetSort(bson.D{{Key: "issue_time", Value: 1}}).
+		SetSkip(int64(page-1) * limit).
+		SetLimit(limit)
+
+	cur, err := depMC.TafHistoryCol().Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cur.Close(ctx)
+
+	items := []mdb.TafHistoryDoc{}
+	if err := cur.All(ctx, &items); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total, _ := depMC.TafHistoryCol().CountDocuments(ctx, filter)
+
+	_ = json.NewEncoder(w).Encode(TafHistoryResponse{
+		Items: items,
+		Meta:  PageMeta{Page: page, Limit: int(limit), Total: total},
+	})
+}
@@ -0,0 +1,114 @@
+package httpx
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/wx/iwxxm"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+// wantsIWXXM: ?format=iwxxm یا Accept: application/xml
+func wantsIWXXM(r *http.Request) bool {
+	if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("format")), "iwxxm") {
+		return true
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(mt, "application/xml") || strings.EqualFold(mt, "text/xml") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIWXXM writes a single report as-is and several as a WMO bulletin.
+// Reports that cannot be decoded are left out and counted in X-IWXXM-Skipped.
+func writeIWXXM(w http.ResponseWriter, kind string, docs [][]byte, skipped int) {
+	w.Header().Set("Content-Type", iwxxm.ContentType)
+	w.Header().Set("X-IWXXM-Skipped", strconv.Itoa(skipped))
+	switch len(docs) {
+	case 0:
+		w.WriteHeader(http.StatusNoContent)
+	case 1:
+		_, _ = w.Write(docs[0])
+	default:
+		b, err := iwxxm.Bulletin(fmt.Sprintf("%s_%s", kind, time.Now().UTC().Format("20060102150405")), docs)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(b)
+	}
+}
+
+func metarsIWXXM(items []MetarDTO) ([][]byte, int) {
+	var docs [][]byte
+	skipped := 0
+	for _, it := range items {
+		obs := it.ReportTime
+		if it.ObsTime > 0 {
+			obs = time.Unix(it.ObsTime, 0).UTC()
+		}
+		rep, err := metar.Parse(it.RawOb, obs)
+		if err == nil {
+			if rep.Time.IsZero() {
+				rep.Time = obs
+			}
+			var b []byte
+			if b, err = iwxxm.METAR(rep, it.ReportTime); err == nil {
+				docs = append(docs, b)
+				continue
+			}
+		}
+		skipped++
+		log.Printf(`{"lvl":"warn","msg":"iwxxm metar skipped","icao":%q,"err":%q}`, it.ICAOId, err.Error())
+	}
+	return docs, skipped
+}
+
+func tafsIWXXM(items []TafDTO) ([][]byte, int) {
+	var docs [][]byte
+	skipped := 0
+	for _, it := range items {
+		ref := it.IssueTime
+		if ref.IsZero() {
+			ref = time.Now().UTC()
+		}
+		t, err := taf.Parse(it.RawTAF, ref)
+		if err == nil {
+			var b []byte
+			if b, err = iwxxm.TAF(t); err == nil {
+				docs = append(docs, b)
+				continue
+			}
+		}
+		skipped++
+		log.Printf(`{"lvl":"warn","msg":"iwxxm taf skipped","icao":%q,"err":%q}`, it.ICAOId, err.Error())
+	}
+	return docs, skipped
+}
+
+// currentTafs keeps the latest issued TAF per station, in upstream order.
+func currentTafs(items []TafDTO) []TafDTO {
+	idx := map[string]int{}
+	var out []TafDTO
+	for _, it := range items {
+		i, ok := idx[it.ICAOId]
+		if !ok {
+			idx[it.ICAOId] = len(out)
+			out = append(out, it)
+			continue
+		}
+		if it.IssueTime.After(out[i].IssueTime) {
+			out[i] = it
+		}
+	}
+	return out
+}
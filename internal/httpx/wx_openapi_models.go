@@ -2,6 +2,11 @@ package httpx
 
 import (
 	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+	"SepTaf/internal/wx/units"
 )
 
 // ---------- METAR ----------
@@ -38,6 +43,16 @@ type MetarDTO struct {
 	MinT        *float64     `json:"minT,omitempty"`
 }
 
+// MetarDecodedDTO is returned by /wx/metar?decode=true: the AWC record plus
+// our own decoding of rawOb.
+type MetarDecodedDTO struct {
+	MetarDTO
+	Decoded        *metar.Report `json:"decoded,omitempty"`
+	FltCatComputed string        `json:"fltCatComputed,omitempty"` // VFR/MVFR/IFR/LIFR from our thresholds
+	Converted      *units.Metar  `json:"converted,omitempty"`      // with units=
+	DecodeError    string        `json:"decodeError,omitempty"`
+}
+
 // ---------- TAF ----------
 
 // NOTE: wdir در fcsts می‌تواند عدد یا "VRB" باشد.
@@ -91,3 +106,41 @@ type TafDTO struct {
 	Name          string        `json:"name"`
 	FCSTS         []TafForecast `json:"fcsts"`
 }
+
+// TafDecodedDTO is returned by /wx/taf/decoded. Change groups come from our
+// own parsing of rawTAF instead of AWC's fcsts array.
+type TafDecodedDTO struct {
+	ICAOId      string     `json:"icaoId"`
+	Name        string     `json:"name,omitempty"`
+	Lat         float64    `json:"lat"`
+	Lon         float64    `json:"lon"`
+	RawTAF      string     `json:"rawTAF"`
+	Decoded     *taf.TAF   `json:"decoded,omitempty"`
+	Converted   *units.Taf `json:"converted,omitempty"` // with units=
+	DecodeError string     `json:"decodeError,omitempty"`
+}
+
+// TafTimelineDTO is returned by /wx/taf/timeline.
+type TafTimelineDTO struct {
+	ICAOId    string     `json:"icaoId"`
+	RawTAF    string     `json:"rawTAF"`
+	IssueTime time.Time  `json:"issueTime"`
+	ValidFrom time.Time  `json:"validFrom"`
+	ValidTo   time.Time  `json:"validTo"`
+	Slots     []taf.Slot `json:"slots"`
+	// with units=: the same slots converted
+	Converted []units.Slot `json:"converted,omitempty"`
+}
+
+// PirepDTO is a stored pilot report plus its distance from the query
+// airport (radius search) or from the route (corridor search).
+type PirepDTO struct {
+	mdb.PirepDoc
+	DistanceNM   float64  `json:"distance_nm"`
+	AlongTrackNM *float64 `json:"along_track_nm,omitempty"`
+}
+
+type PirepResponse struct {
+	Items []PirepDTO `json:"items"`
+	Meta  PageMeta   `json:"meta"`
+}
@@ -0,0 +1,126 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"SepTaf/internal/wx/fltcat"
+	"SepTaf/internal/wx/metar"
+)
+
+type GeoJSONFeature struct {
+	Type       string         `json:"type"` // "Feature"
+	Geometry   any            `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"` // "FeatureCollection"
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// WX overview godoc
+// @Summary      Flight-category overview map
+// @Description  Latest METAR per station in an area as a GeoJSON FeatureCollection, coloured by locally computed VFR/MVFR/IFR/LIFR
+// @Tags         Weather
+// @Produce      json
+// @Param        country  query   string  false  "ISO country (e.g., IR)"
+// @Param        fir_code query   string  false  "FIR code (e.g., OIIX)"
+// @Param        bbox     query   string  false  "minLon,minLat,maxLon,maxLat"
+// @Param        ids      query   string  false  "Comma-separated ICAO list"
+// @Param        type     query   string  false  "Airport types for area queries (default large_airport,medium_airport)"
+// @Param        rules    query   string  false  "FAA (default) or e.g. LIFR<500/1,IFR<1000/3,MVFR<=3000/5"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  GeoJSONFeatureCollection
+// @Failure      400  {object}  HTTPError
+// @Failure      502  {object}  HTTPError
+// @Router       /wx/overview [get]
+func GetWXOverview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/geo+json")
+
+	rules, err := fltCatRules(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	stations, err := stationsForRequest(r)
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+
+	body, _, meta, err := fetchAWCStations(r.Context(), "metar", stations, 2)
+	setCacheHeaders(w, meta)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+	var items []MetarDTO
+	if e := json.Unmarshal(body, &items); e != nil {
+		http.Error(w, `{"error":"invalid upstream json"}`, http.StatusBadGateway)
+		return
+	}
+
+	// فقط آخرین گزارش هر ایستگاه
+	latest := map[string]MetarDTO{}
+	order := []string{}
+	for _, it := range items {
+		prev, ok := latest[it.ICAOId]
+		if !ok {
+			order = append(order, it.ICAOId)
+		}
+		if !ok || it.ObsTime > prev.ObsTime {
+			latest[it.ICAOId] = it
+		}
+	}
+
+	fc := GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []GeoJSONFeature{}}
+	for _, id := range order {
+		it := latest[id]
+		if it.Lat == 0 && it.Lon == 0 {
+			continue
+		}
+		props := map[string]any{
+			"icao":      it.ICAOId,
+			"name":      it.Name,
+			"obsTime":   time.Unix(it.ObsTime, 0).UTC(),
+			"rawOb":     it.RawOb,
+			"fltCat":    fltcat.UNKN,
+			"awcFltCat": it.FltCat,
+		}
+		if rep, err := metar.Parse(it.RawOb, time.Unix(it.ObsTime, 0)); err == nil {
+			cat := rules.FromConditions(rep.Conditions)
+			props["fltCat"] = cat
+			if c := rep.Ceiling(); c != nil {
+				props["ceilingFt"] = *c
+			}
+			if rep.Visibility != nil {
+				props["visibilitySM"] = round2(rep.Visibility.Meters / metar.MetersPerSM)
+			}
+		}
+		props["color"] = fltcat.Color(props["fltCat"].(string))
+
+		fc.Features = append(fc.Features, GeoJSONFeature{
+			Type:       "Feature",
+			Geometry:   GeoJSONPoint{Type: "Point", Coordinates: [2]float64{it.Lon, it.Lat}},
+			Properties: props,
+		})
+	}
+
+	_ = json.NewEncoder(w).Encode(fc)
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
@@ -4,16 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
-)
 
-const awcBase = "https://aviationweather.gov/api/data"
+	"SepTaf/internal/geo"
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/wx/fltcat"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/pirep"
+	"SepTaf/internal/wx/taf"
+	"SepTaf/internal/wx/units"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
 
 // validate ICAO (چهار کاراکتر A-Z/0-9؛ معمولاً حروف)
 var icaoRe = regexp.MustCompile(`^[A-Z0-9]{4}$`)
@@ -38,34 +46,34 @@ func fetchAWC(ctx context.Context, resource, icao string, hours int) ([]byte, in
 	}
 	q.Set("mostRecent", "true") // optional
 
-	u := fmt.Sprintf("%s/%s?%s", awcBase, resource, q.Encode())
-	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
-	req.Header.Set("User-Agent", "SepTaf-WX/1.0 (contact: you@example.com)")
-	req.Header.Set("Accept", "application/json")
-
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := awcUp.Get(ctx, "/api/data/"+resource, q, http.Header{"Accept": {"application/json"}})
 	if err != nil {
 		return nil, 0, err
 	}
-	defer resp.Body.Close()
-
-	b, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return b, resp.StatusCode, fmt.Errorf("awc %s http %d", resource, resp.StatusCode)
+	if resp.Status != http.StatusOK {
+		return resp.Body, resp.Status, fmt.Errorf("awc %s http %d", resource, resp.Status)
 	}
-	return b, resp.StatusCode, nil
+	return resp.Body, resp.Status, nil
 }
 
 // ----------------- Handlers -----------------
 
 // FIR LIST godoc
 // @Summary      Get METAR
-// @Description  Returns METAR JSON from AWC for a given ICAO (no storage)
+// @Description  Returns METAR JSON from AWC for a given ICAO (cached; see X-Cache/Age headers). format=iwxxm or Accept: application/xml returns IWXXM 3.0 (several reports in a collect:MeteorologicalBulletin).
 // @Tags         Weather
-// @Produce      json
-// @Param        icao   query   string  true   "ICAO code (e.g., OIII, KJFK)"
+// @Produce      json,xml
+// @Param        icao   query   string  false  "ICAO code (e.g., OIII, KJFK)"
+// @Param        ids      query   string  false  "Comma-separated ICAO list (e.g., OIII,OIIE)"
+// @Param        country  query   string  false  "ISO country; resolved to stations via airports"
+// @Param        fir_code query   string  false  "FIR code (e.g., OIIX); stations inside the stored FIR geometry"
+// @Param        bbox     query   string  false  "minLon,minLat,maxLon,maxLat"
+// @Param        type     query   string  false  "Airport types for area queries (default large_airport,medium_airport)"
 // @Param        hours  query   int     false  "Lookback hours (default 2)"
+// @Param        decode query   bool    false  "Decode rawOb server-side (returns httpx.MetarDecodedDTO items)"
+// @Param        rules  query   string  false  "Flight-category rules for decode=true: FAA (default) or e.g. LIFR<500/1,IFR<1000/3,MVFR<=3000/5"
+// @Param        units  query   string  false  "Adds a converted block (implies decode=true): metric | imperial | aviation-icao | aviation-faa"
+// @Param        format query   string  false  "iwxxm: IWXXM 3.0 XML (same as Accept: application/xml)"
 /*Headers Params*/
 // @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
 // @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
@@ -82,15 +90,17 @@ func fetchAWC(ctx context.Context, resource, icao string, hours int) ([]byte, in
 // @Failure      502  {object}  httpx.HTTPError
 // @Router       /wx/metar [get]
 func GetMETAR(w http.ResponseWriter, r *http.Request) {
-	icao := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("icao")))
-	if !icaoRe.MatchString(icao) {
-		http.Error(w, `{"error":"invalid ICAO"}`, http.StatusBadRequest)
+	stations, err := stationsForRequest(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeStationError(w, err)
 		return
 	}
 	hours := parseHours(r.URL.Query().Get("hours"), 2)
 
-	body, code, err := fetchAWC(r.Context(), "metar", icao, hours)
+	body, code, meta, err := fetchAWCStations(r.Context(), "metar", stations, hours)
 	w.Header().Set("Content-Type", "application/json")
+	setCacheHeaders(w, meta)
 
 	if err != nil {
 		if len(body) > 0 {
@@ -111,16 +121,100 @@ func GetMETAR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if wantsIWXXM(r) {
+		var items []MetarDTO
+		if e := json.Unmarshal(body, &items); e != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"unexpected upstream schema: %s"}`, e.Error()), http.StatusBadGateway)
+			return
+		}
+		docs, skipped := metarsIWXXM(items)
+		writeIWXXM(w, "METAR", docs, skipped)
+		return
+	}
+
+	sys, e := unitsParam(r)
+	if e != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, e.Error()), http.StatusBadRequest)
+		return
+	}
+	if decode, _ := strconv.ParseBool(r.URL.Query().Get("decode")); decode || sys != nil {
+		rules, e := fltCatRules(r)
+		if e != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, e.Error()), http.StatusBadRequest)
+			return
+		}
+		var items []MetarDTO
+		if e := json.Unmarshal(body, &items); e != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"unexpected upstream schema: %s"}`, e.Error()), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(decodeMetars(items, rules, sys))
+		return
+	}
+
 	w.WriteHeader(code)
 	_, _ = w.Write(body)
 }
 
+func decodeMetars(items []MetarDTO, rules fltcat.Rules, sys *units.System) []MetarDecodedDTO {
+	out := make([]MetarDecodedDTO, 0, len(items))
+	for _, it := range items {
+		ref := it.ReportTime
+		if it.ObsTime > 0 {
+			ref = time.Unix(it.ObsTime, 0).UTC()
+		}
+		d := MetarDecodedDTO{MetarDTO: it}
+		rep, err := metar.Parse(it.RawOb, ref)
+		if err != nil {
+			d.DecodeError = err.Error()
+		} else {
+			d.Decoded = rep
+			d.FltCatComputed = rules.FromConditions(rep.Conditions)
+			if sys != nil {
+				elev := float64(it.Elev)
+				c := sys.Metar(rep, &elev)
+				d.Converted = &c
+			}
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// unitsParam reads ?units=; nil when absent.
+func unitsParam(r *http.Request) (*units.System, error) {
+	v := strings.TrimSpace(r.URL.Query().Get("units"))
+	if v == "" {
+		return nil, nil
+	}
+	s, err := units.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// fltCatRules returns ?rules= if given, else the configured FLTCAT_RULES.
+func fltCatRules(r *http.Request) (fltcat.Rules, error) {
+	if v := strings.TrimSpace(r.URL.Query().Get("rules")); v != "" {
+		return fltcat.ParseRules(v)
+	}
+	return fltcat.ParseRules(depCfg.FltCatRules)
+}
+
 // @Summary      Get TAF
-// @Description  Returns TAF JSON from AWC for a given ICAO (no storage)
+// @Description  Returns TAF JSON from AWC for a given ICAO (cached; see X-Cache/Age headers). format=iwxxm or Accept: application/xml returns the current TAF per station as IWXXM 3.0.
 // @Tags         Weather
-// @Produce      json
-// @Param        icao   query   string  true   "ICAO code (e.g., OIII, KJFK)"
+// @Produce      json,xml
+// @Param        icao   query   string  false  "ICAO code (e.g., OIII, KJFK)"
+// @Param        ids      query   string  false  "Comma-separated ICAO list (e.g., OIII,OIIE)"
+// @Param        country  query   string  false  "ISO country; resolved to stations via airports"
+// @Param        fir_code query   string  false  "FIR code (e.g., OIIX); stations inside the stored FIR geometry"
+// @Param        bbox     query   string  false  "minLon,minLat,maxLon,maxLat"
+// @Param        type     query   string  false  "Airport types for area queries (default large_airport,medium_airport)"
 // @Param        hours  query   int     false  "Lookback hours (default 24)"
+// @Param        format query   string  false  "iwxxm: IWXXM 3.0 XML (same as Accept: application/xml)"
 /*Headers Params*/
 // @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
 // @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
@@ -137,15 +231,17 @@ func GetMETAR(w http.ResponseWriter, r *http.Request) {
 // @Failure      502   {object}  httpx.HTTPError
 // @Router       /wx/taf [get]
 func GetTAF(w http.ResponseWriter, r *http.Request) {
-	icao := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("icao")))
-	if !icaoRe.MatchString(icao) {
-		http.Error(w, `{"error":"invalid ICAO"}`, http.StatusBadRequest)
+	stations, err := stationsForRequest(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeStationError(w, err)
 		return
 	}
 	hours := parseHours(r.URL.Query().Get("hours"), 24)
 
-	body, code, err := fetchAWC(r.Context(), "taf", icao, hours)
+	body, code, meta, err := fetchAWCStations(r.Context(), "taf", stations, hours)
 	w.Header().Set("Content-Type", "application/json")
+	setCacheHeaders(w, meta)
 
 	if err != nil {
 		if len(body) > 0 {
@@ -165,6 +261,385 @@ func GetTAF(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if wantsIWXXM(r) {
+		var items []TafDTO
+		if e := json.Unmarshal(body, &items); e != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"unexpected upstream schema: %s"}`, e.Error()), http.StatusBadGateway)
+			return
+		}
+		docs, skipped := tafsIWXXM(currentTafs(items))
+		writeIWXXM(w, "TAF", docs, skipped)
+		return
+	}
+
 	w.WriteHeader(code)
 	_, _ = w.Write(body)
 }
+
+// @Summary      Get decoded TAF
+// @Description  Fetches TAF from AWC and decodes rawTAF into typed FM/BECMG/TEMPO/PROB groups
+// @Tags         Weather
+// @Produce      json
+// @Param        icao   query   string  false  "ICAO code (e.g., OIII, KJFK)"
+// @Param        ids      query   string  false  "Comma-separated ICAO list (e.g., OIII,OIIE)"
+// @Param        country  query   string  false  "ISO country; resolved to stations via airports"
+// @Param        fir_code query   string  false  "FIR code (e.g., OIIX); stations inside the stored FIR geometry"
+// @Param        bbox     query   string  false  "minLon,minLat,maxLon,maxLat"
+// @Param        type     query   string  false  "Airport types for area queries (default large_airport,medium_airport)"
+// @Param        hours  query   int     false  "Lookback hours (default 24)"
+// @Param        units  query   string  false  "Adds a converted block: metric | imperial | aviation-icao | aviation-faa"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200    {array}   httpx.TafDecodedDTO
+// @Failure      400    {object}  httpx.HTTPError
+// @Failure      502    {object}  httpx.HTTPError
+// @Router       /wx/taf/decoded [get]
+func GetTAFDecoded(w http.ResponseWriter, r *http.Request) {
+	stations, err := stationsForRequest(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeStationError(w, err)
+		return
+	}
+	hours := parseHours(r.URL.Query().Get("hours"), 24)
+	sys, err := unitsParam(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	body, _, meta, err := fetchAWCStations(r.Context(), "taf", stations, hours)
+	w.Header().Set("Content-Type", "application/json")
+	setCacheHeaders(w, meta)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+
+	var items []TafDTO
+	if e := json.Unmarshal(body, &items); e != nil {
+		http.Error(w,
+			fmt.Sprintf(`{"error":"invalid upstream json","upstream":%q}`, string(body)),
+			http.StatusBadGateway)
+		return
+	}
+
+	out := decodeTafs(items)
+	if sys != nil {
+		for i := range out {
+			if out[i].Decoded != nil {
+				c := sys.TAF(out[i].Decoded)
+				out[i].Converted = &c
+			}
+		}
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// @Summary      TAF timeline
+// @Description  Resolves base/FM/BECMG/TEMPO/PROB groups of the latest TAF into hourly prevailing and worst-case conditions
+// @Tags         Weather
+// @Produce      json
+// @Param        icao   query   string  true   "ICAO code (e.g., OIII, KJFK)"
+// @Param        from   query   string  false  "Window start (RFC3339, default TAF validity start)"
+// @Param        to     query   string  false  "Window end (RFC3339, default TAF validity end)"
+// @Param        units  query   string  false  "Adds converted slots: metric | imperial | aviation-icao | aviation-faa"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200    {object}  httpx.TafTimelineDTO
+// @Failure      400    {object}  httpx.HTTPError
+// @Failure      404    {object}  httpx.HTTPError
+// @Failure      502    {object}  httpx.HTTPError
+// @Router       /wx/taf/timeline [get]
+func GetTAFTimeline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	icao := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("icao")))
+	if !icaoRe.MatchString(icao) {
+		http.Error(w, `{"error":"invalid ICAO"}`, http.StatusBadRequest)
+		return
+	}
+	from, err := parseTimeParam(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid from"}`, http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeParam(r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid to"}`, http.StatusBadRequest)
+		return
+	}
+	sys, err := unitsParam(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	body, _, meta, err := fetchAWCCached(r.Context(), "taf", icao, 24)
+	setCacheHeaders(w, meta)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+	var items []TafDTO
+	if e := json.Unmarshal(body, &items); e != nil {
+		http.Error(w,
+			fmt.Sprintf(`{"error":"invalid upstream json","upstream":%q}`, string(body)),
+			http.StatusBadGateway)
+		return
+	}
+
+	// latest decodable TAF wins
+	var latest *taf.TAF
+	var raw string
+	for _, d := range decodeTafs(items) {
+		if d.Decoded == nil || d.Decoded.Nil {
+			continue
+		}
+		if latest == nil || d.Decoded.IssueTime.After(latest.IssueTime) {
+			latest, raw = d.Decoded, d.RawTAF
+		}
+	}
+	if latest == nil {
+		http.Error(w, `{"error":"no TAF available"}`, http.StatusNotFound)
+		return
+	}
+
+	resp := TafTimelineDTO{
+		ICAOId:    icao,
+		RawTAF:    raw,
+		IssueTime: latest.IssueTime,
+		ValidFrom: latest.ValidFrom,
+		ValidTo:   latest.ValidTo,
+		Slots:     latest.Timeline(from, to, time.Hour),
+	}
+	if sys != nil {
+		resp.Converted = sys.Slots(resp.Slots)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// parseTimeParam accepts RFC3339 or epoch seconds; empty means zero time.
+func parseTimeParam(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+func decodeTafs(items []TafDTO) []TafDecodedDTO {
+	out := make([]TafDecodedDTO, 0, len(items))
+	for _, it := range items {
+		d := TafDecodedDTO{ICAOId: it.ICAOId, Name: it.Name, Lat: it.Lat, Lon: it.Lon, RawTAF: it.RawTAF}
+		ref := it.IssueTime
+		if ref.IsZero() {
+			ref = time.Now().UTC()
+		}
+		t, err := taf.Parse(it.RawTAF, ref)
+		if err != nil {
+			d.DecodeError = err.Error()
+		} else {
+			d.Decoded = t
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// @Summary      Get PIREPs near an airport or along a route
+// @Description  Stored PIREP/AIREP reports (decoded) within radius_nm of an airport, or within corridor_nm of the great circle between two airports
+// @Tags         Weather
+// @Produce      json
+// @Param        icao         query   string  false  "Airport for a radius search (ICAO/GPS code)"
+// @Param        radius_nm    query   number  false  "Radius around icao in NM (default 100, max 500)"
+// @Param        from         query   string  false  "Route departure airport (with to)"
+// @Param        to           query   string  false  "Route destination airport (with from)"
+// @Param        corridor_nm  query   number  false  "Half-width of the route corridor in NM (default 50, max 200)"
+// @Param        hours        query   int     false  "Lookback hours (default 3)"
+// @Param        fl_min       query   int     false  "Lowest flight level (hundreds of ft)"
+// @Param        fl_max       query   int     false  "Highest flight level (hundreds of ft)"
+// @Param        turb         query   string  false  "Minimum turbulence: LGT | MOD | SEV | EXTM"
+// @Param        icing        query   string  false  "Minimum icing: TRC | LGT | MOD | SEV"
+// @Param        page         query   int     false  "page (>=1)"      default(1)
+// @Param        limit        query   int     false  "items per page"  default(50)  minimum(1)  maximum(500)
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200    {object}  httpx.PirepResponse
+// @Failure      400    {object}  httpx.HTTPError
+// @Failure      500    {object}  httpx.HTTPError
+// @Router       /wx/pirep [get]
+func GetPIREP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	hours := parseHours(q.Get("hours"), 3)
+	filter := bson.M{"obs_time": bson.M{"$gte": time.Now().UTC().Add(-time.Duration(hours) * time.Hour)}}
+
+	// distance is filled per item once the search shape is known
+	var distance func(p geo.Point) (float64, *float64)
+
+	icao := strings.ToUpper(strings.TrimSpace(q.Get("icao")))
+	from := strings.ToUpper(strings.TrimSpace(q.Get("from")))
+	to := strings.ToUpper(strings.TrimSpace(q.Get("to")))
+	switch {
+	case from != "" && to != "":
+		a, err := airportPoint(ctx, from)
+		if err != nil {
+			writeStationError(w, err)
+			return
+		}
+		b, err := airportPoint(ctx, to)
+		if err != nil {
+			writeStationError(w, err)
+			return
+		}
+		width, err := floatParam(q.Get("corridor_nm"), 50, 200)
+		if err != nil {
+			http.Error(w, `{"error":"invalid corridor_nm"}`, http.StatusBadRequest)
+			return
+		}
+		filter["location"] = bson.M{"$geoWithin": bson.M{"$geometry": bson.M{
+			"type":        "Polygon",
+			"coordinates": [][][]float64{geo.Corridor(a, b, width)},
+		}}}
+		distance = func(p geo.Point) (float64, *float64) {
+			d, along := geo.DistanceToSegmentNM(a, b, p)
+			along = round2(along)
+			return d, &along
+		}
+	case icao != "":
+		c, err := airportPoint(ctx, icao)
+		if err != nil {
+			writeStationError(w, err)
+			return
+		}
+		radius, err := floatParam(q.Get("radius_nm"), 100, 500)
+		if err != nil {
+			http.Error(w, `{"error":"invalid radius_nm"}`, http.StatusBadRequest)
+			return
+		}
+		filter["location"] = bson.M{"$geoWithin": bson.M{
+			"$centerSphere": []any{[]float64{c.Lon, c.Lat}, radius / geo.EarthRadiusNM},
+		}}
+		distance = func(p geo.Point) (float64, *float64) { return geo.DistanceNM(c, p), nil }
+	default:
+		http.Error(w, `{"error":"icao or from/to is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	fl := bson.M{}
+	if v := strings.TrimSpace(q.Get("fl_min")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, `{"error":"invalid fl_min"}`, http.StatusBadRequest)
+			return
+		}
+		fl["$gte"] = n
+	}
+	if v := strings.TrimSpace(q.Get("fl_max")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, `{"error":"invalid fl_max"}`, http.StatusBadRequest)
+			return
+		}
+		fl["$lte"] = n
+	}
+	if len(fl) > 0 {
+		filter["flight_level"] = fl
+	}
+	for param, field := range map[string]string{"turb": "turb_rank", "icing": "icing_rank"} {
+		v := strings.ToUpper(strings.TrimSpace(q.Get(param)))
+		if v == "" {
+			continue
+		}
+		rank := pirep.IntensityRank(v)
+		if rank < 0 {
+			http.Error(w, fmt.Sprintf(`{"error":"invalid %s"}`, param), http.StatusBadRequest)
+			return
+		}
+		filter[field] = bson.M{"$gte": rank}
+	}
+
+	page := getPage(r)
+	limit := getLimit(r, 50, 500)
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0}).
+		SetSort(bson.D{{Key: "obs_time", Value: -1}}).
+		SetSkip(int64(page-1) * limit).
+		SetLimit(limit)
+
+	cur, err := depMC.PirepsCol().Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cur.Close(ctx)
+	var docs []mdb.PirepDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total, _ := depMC.PirepsCol().CountDocuments(ctx, filter)
+
+	items := make([]PirepDTO, 0, len(docs))
+	for _, d := range docs {
+		dist, along := distance(geo.Point{Lat: d.Lat, Lon: d.Lon})
+		items = append(items, PirepDTO{PirepDoc: d, DistanceNM: round2(dist), AlongTrackNM: along})
+	}
+	_ = json.NewEncoder(w).Encode(PirepResponse{
+		Items: items,
+		Meta:  PageMeta{Page: page, Limit: int(limit), Total: total},
+	})
+}
+
+// floatParam parses an optional positive number, capped at max.
+func floatParam(v string, def, max float64) (float64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid number %q", v)
+	}
+	return math.Min(f, max), nil
+}
@@ -0,0 +1,138 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SigmetResponse struct {
+	Items []mdb.SigmetDoc `json:"items"`
+	Meta  PageMeta        `json:"meta"`
+}
+
+// parseLatLon reads lat/lon query params; ok=false if either is missing.
+func parseLatLon(r *http.Request) (lat, lon float64, ok bool, err error) {
+	latS, lonS := strings.TrimSpace(r.URL.Query().Get("lat")), strings.TrimSpace(r.URL.Query().Get("lon"))
+	if latS == "" && lonS == "" {
+		return 0, 0, false, nil
+	}
+	lat, err1 := strconv.ParseFloat(latS, 64)
+	lon, err2 := strconv.ParseFloat(lonS, 64)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false, fmt.Errorf("invalid lat/lon")
+	}
+	return lat, lon, true, nil
+}
+
+// SIGMET godoc
+// @Summary      Active SIGMET/AIRMET
+// @Description  Active advisories intersecting a FIR (stored FIR geometry or fir_id) or a point
+// @Tags         Weather
+// @Produce      json
+// @Param        fir     query   string  false  "FIR code (e.g., OIIX)"
+// @Param        lat     query   number  false  "Latitude (with lon)"
+// @Param        lon     query   number  false  "Longitude (with lat)"
+// @Param        hazard  query   string  false  "TS | TURB | ICE | VA | MTW | ..."
+// @Param        kind    query   string  false  "SIGMET | AIRMET"
+// @Param        page    query   int     false  "page (>=1)"      default(1)
+// @Param        limit   query   int     false  "items per page"  default(50)  minimum(1)  maximum(500)
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  SigmetResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /wx/sigmet [get]
+func GetSIGMET(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"valid_from": bson.M{"$lte": now},
+		"valid_to":   bson.M{"$gte": now},
+	}
+
+	fir := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("fir")))
+	lat, lon, hasPoint, err := parseLatLon(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	switch {
+	case fir != "":
+		if !icaoRe.MatchString(fir) {
+			http.Error(w, `{"error":"invalid FIR code"}`, http.StatusBadRequest)
+			return
+		}
+		// without a stored FIR boundary only the fir_id match is used
+		or := []bson.M{{"fir_id": fir}}
+		geom, err := firGeometry(ctx, fir)
+		var br badRequest
+		switch {
+		case err == nil:
+			or = append(or, bson.M{"geometry": bson.M{"$geoIntersects": bson.M{"$geometry": geom}}})
+		case !errors.As(err, &br):
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		filter["$or"] = or
+	case hasPoint:
+		filter["geometry"] = bson.M{"$geoIntersects": bson.M{"$geometry": GeoJSONPoint{Type: "Point", Coordinates: [2]float64{lon, lat}}}}
+	default:
+		http.Error(w, `{"error":"fir or lat/lon is required"}`, http.StatusBadRequest)
+		return
+	}
+	if hz := strings.TrimSpace(r.URL.Query().Get("hazard")); hz != "" {
+		filter["hazard"] = strings.ToUpper(hz)
+	}
+	if k := strings.TrimSpace(r.URL.Query().Get("kind")); k != "" {
+		filter["kind"] = strings.ToUpper(k)
+	}
+
+	page := getPage(r)
+	limit := getLimit(r, 50, 500)
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0}).
+		SetSort(bson.D{{Key: "valid_from", Value: 1}}).
+		SetSkip(int64(page-1) * limit).
+		SetLimit(limit)
+
+	cur, err := depMC.SigmetsCol().Find(ctx, filter, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cur.Close(ctx)
+
+	items := []mdb.SigmetDoc{}
+	if err := cur.All(ctx, &items); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total, _ := depMC.SigmetsCol().CountDocuments(ctx, filter)
+
+	_ = json.NewEncoder(w).Encode(SigmetResponse{
+		Items: items,
+		Meta:  PageMeta{Page: page, Limit: int(limit), Total: total},
+	})
+}
@@ -0,0 +1,278 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/geo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultStationOffsetNM = 5 // بیشتر از این فاصله بین فرودگاه و ایستگاه = mismatch
+
+// StationInfoDTO is one AWC /stationinfo record.
+type StationInfoDTO struct {
+	ICAOId   string   `json:"icaoId"`
+	IATAId   string   `json:"iataId,omitempty"`
+	FAAId    string   `json:"faaId,omitempty"`
+	WMOId    string   `json:"wmoId,omitempty"`
+	Site     string   `json:"site"`
+	Lat      float64  `json:"lat"`
+	Lon      float64  `json:"lon"`
+	Elev     int      `json:"elev"`
+	State    string   `json:"state,omitempty"`
+	Country  string   `json:"country,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	SiteType []string `json:"siteType"` // METAR, TAF, RAOB, ...
+}
+
+// AirportStationDTO joins one of our airports to its AWC station (if any).
+type AirportStationDTO struct {
+	Airport          AirportDTO      `json:"airport"`
+	Station          *StationInfoDTO `json:"station,omitempty"`
+	MatchedOn        string          `json:"matched_on,omitempty"` // icao_code | gps_code | ident
+	HasMETAR         bool            `json:"has_metar"`
+	HasTAF           bool            `json:"has_taf"`
+	SiteTypes        []string        `json:"site_types,omitempty"`
+	OffsetNM         *float64        `json:"offset_nm,omitempty"` // airport ↔ station distance
+	DistanceMismatch bool            `json:"distance_mismatch,omitempty"`
+}
+
+type StationsSummary struct {
+	Airports   int `json:"airports"`
+	WithMETAR  int `json:"with_metar"`
+	WithTAF    int `json:"with_taf"`
+	NoStation  int `json:"no_station"`
+	Mismatches int `json:"mismatches"`
+}
+
+type StationsResponse struct {
+	Items   []AirportStationDTO `json:"items"`
+	Summary StationsSummary     `json:"summary"`
+}
+
+// WX stations godoc
+// @Summary      Weather stations joined to airports
+// @Description  AWC station info for our airports (joined on icao_code/gps_code/ident): METAR/TAF service, site types and position mismatches. Summary counts are for the whole area, before has_metar/has_taf filtering.
+// @Tags         Weather
+// @Produce      json
+// @Param        icao           query   string  false  "Single airport code"
+// @Param        ids            query   string  false  "Comma-separated airport codes"
+// @Param        country        query   string  false  "ISO country (e.g., IR)"
+// @Param        fir_code       query   string  false  "FIR code (e.g., OIIX)"
+// @Param        bbox           query   string  false  "minLon,minLat,maxLon,maxLat"
+// @Param        type           query   string  false  "Airport types for area queries (default large_airport,medium_airport)"
+// @Param        has_metar      query   bool    false  "Only airports with (true) / without (false) METAR"
+// @Param        has_taf        query   bool    false  "Only airports with (true) / without (false) TAF"
+// @Param        max_offset_nm  query   number  false  "Airport↔station distance flagged as mismatch (default 5)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  StationsResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      502  {object}  HTTPError
+// @Router       /wx/stations [get]
+func GetWXStations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	hasMetar, err := optionalBool(q.Get("has_metar"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid has_metar"}`, http.StatusBadRequest)
+		return
+	}
+	hasTaf, err := optionalBool(q.Get("has_taf"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid has_taf"}`, http.StatusBadRequest)
+		return
+	}
+	maxOffset, err := floatParam(q.Get("max_offset_nm"), defaultStationOffsetNM, 1000)
+	if err != nil {
+		http.Error(w, `{"error":"invalid max_offset_nm"}`, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	airports, err := airportsForRequest(ctx, q)
+	cancel()
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+
+	codes := make([]string, 0, len(airports))
+	for _, a := range airports {
+		if c := stationCode(a); c != "" {
+			codes = append(codes, c)
+		}
+	}
+	var infos []StationInfoDTO
+	if len(codes) > 0 {
+		body, code, meta, err := fetchAWCStations(r.Context(), "stationinfo", codes, 0)
+		setCacheHeaders(w, meta)
+		if code == http.StatusNoContent {
+			body, err = nil, nil
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+			return
+		}
+		if len(body) > 0 {
+			if e := json.Unmarshal(body, &infos); e != nil {
+				http.Error(w, `{"error":"invalid upstream json"}`, http.StatusBadGateway)
+				return
+			}
+		}
+	}
+	byID := make(map[string]*StationInfoDTO, len(infos))
+	for i := range infos {
+		byID[strings.ToUpper(infos[i].ICAOId)] = &infos[i]
+	}
+
+	resp := StationsResponse{Items: []AirportStationDTO{}}
+	for _, a := range airports {
+		it := joinStation(a, byID, maxOffset)
+		resp.Summary.Airports++
+		if it.Station == nil {
+			resp.Summary.NoStation++
+		}
+		if it.HasMETAR {
+			resp.Summary.WithMETAR++
+		}
+		if it.HasTAF {
+			resp.Summary.WithTAF++
+		}
+		if it.DistanceMismatch {
+			resp.Summary.Mismatches++
+		}
+		if hasMetar != nil && it.HasMETAR != *hasMetar {
+			continue
+		}
+		if hasTaf != nil && it.HasTAF != *hasTaf {
+			continue
+		}
+		resp.Items = append(resp.Items, it)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// joinStation matches an airport to a station on icao_code, then gps_code,
+// then ident.
+func joinStation(a AirportDTO, byID map[string]*StationInfoDTO, maxOffset float64) AirportStationDTO {
+	it := AirportStationDTO{Airport: a}
+	for _, c := range []struct{ field, code string }{
+		{"icao_code", a.IcaoCode}, {"gps_code", a.GPSCode}, {"ident", a.Ident},
+	} {
+		if st, ok := byID[strings.ToUpper(c.code)]; ok && c.code != "" {
+			it.Station, it.MatchedOn = st, c.field
+			break
+		}
+	}
+	if it.Station == nil {
+		return it
+	}
+	it.SiteTypes = it.Station.SiteType
+	for _, t := range it.Station.SiteType {
+		switch strings.ToUpper(t) {
+		case "METAR":
+			it.HasMETAR = true
+		case "TAF":
+			it.HasTAF = true
+		}
+	}
+	if a.Location != nil {
+		d := round2(geo.DistanceNM(
+			geo.Point{Lat: a.Location.Coordinates[1], Lon: a.Location.Coordinates[0]},
+			geo.Point{Lat: it.Station.Lat, Lon: it.Station.Lon}))
+		it.OffsetNM = &d
+		it.DistanceMismatch = d > maxOffset
+	}
+	return it
+}
+
+// stationCode is the id we ask AWC for: icao_code, else a 4-char gps_code
+// or ident.
+func stationCode(a AirportDTO) string {
+	for _, c := range []string{a.IcaoCode, a.GPSCode, a.Ident} {
+		if c = strings.ToUpper(strings.TrimSpace(c)); icaoRe.MatchString(c) {
+			return c
+		}
+	}
+	return ""
+}
+
+// airportsForRequest returns the airport records selected by icao / ids /
+// country / fir_code / bbox (same selectors as resolveStations).
+func airportsForRequest(ctx context.Context, q url.Values) ([]AirportDTO, error) {
+	var filter bson.M
+	codes := []string{}
+	for _, v := range append(strings.Split(q.Get("icao"), ","), strings.Split(q.Get("ids"), ",")...) {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			codes = append(codes, v)
+		}
+	}
+	if len(codes) > maxAreaStations {
+		return nil, badRequest{fmt.Sprintf("too many ids (max %d)", maxAreaStations)}
+	}
+	if len(codes) > 0 {
+		filter = bson.M{"$or": []bson.M{
+			{"icao_code": bson.M{"$in": codes}},
+			{"gps_code": bson.M{"$in": codes}},
+			{"ident": bson.M{"$in": codes}},
+		}}
+	} else {
+		var err error
+		if filter, err = areaFilter(ctx, q); err != nil {
+			return nil, err
+		}
+		if filter == nil {
+			return nil, badRequest{"one of icao, ids, country, fir_code or bbox is required"}
+		}
+		filter["type"] = bson.M{"$in": airportTypes(q)}
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0}).
+		SetSort(bson.D{{Key: "ident", Value: 1}}).
+		SetLimit(maxAreaStations + 1)
+	cur, err := depMC.DB.Collection("airports").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var out []AirportDTO
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	if len(out) > maxAreaStations {
+		return nil, badRequest{fmt.Sprintf("area resolves to more than %d airports; narrow it with type= or a smaller area", maxAreaStations)}
+	}
+	return out, nil
+}
+
+// optionalBool parses a tri-state query flag: "" → nil.
+func optionalBool(v string) (*bool, error) {
+	if v = strings.TrimSpace(v); v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
@@ -0,0 +1,135 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/wx/verify"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TafLeadAccuracy struct {
+	FromH    int             `json:"from_h"`
+	ToH      int             `json:"to_h"`
+	Accuracy verify.Accuracy `json:"accuracy"`
+}
+
+type TafStationVerification struct {
+	ICAO    string            `json:"icao"`
+	TAFs    int               `json:"tafs"`
+	Overall verify.Accuracy   `json:"overall"`
+	ByLead  []TafLeadAccuracy `json:"by_lead"`
+}
+
+type TafVerificationResponse struct {
+	From     time.Time                `json:"from"`
+	To       time.Time                `json:"to"`
+	Stations []TafStationVerification `json:"stations"`
+}
+
+// TAF verification godoc
+// @Summary      TAF verification scores
+// @Description  Accuracy of archived TAFs against the METARs observed during their validity, per station and per lead time (6 h buckets from issue). Category/ceiling/visibility use FLTCAT_RULES; categoryInTempo also counts observations covered by TEMPO/PROB groups. Wind hits are within 10 kt and 30°. Without icao/ids all verified stations are returned.
+// @Tags         Weather
+// @Produce      json
+// @Param        icao   query   string  false  "ICAO code (e.g., OIII)"
+// @Param        ids    query   string  false  "Comma-separated ICAO codes"
+// @Param        from   query   string  false  "TAF issue time from, RFC3339 or epoch seconds (default to-30d)"
+// @Param        to     query   string  false  "TAF issue time to, RFC3339 or epoch seconds (default now)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  TafVerificationResponse
+// @Failure      400  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /wx/taf/verification [get]
+func GetTAFVerification(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	var codes []string
+	for _, v := range append(strings.Split(q.Get("icao"), ","), strings.Split(q.Get("ids"), ",")...) {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v == "" {
+			continue
+		}
+		if !icaoRe.MatchString(v) {
+			http.Error(w, `{"error":"invalid ICAO"}`, http.StatusBadRequest)
+			return
+		}
+		codes = append(codes, v)
+	}
+	from, to, ok := parseRange(r, 30*24*time.Hour)
+	if !ok {
+		http.Error(w, `{"error":"invalid from/to"}`, http.StatusBadRequest)
+		return
+	}
+
+	filter := bson.M{"issue_time": bson.M{"$gte": from, "$lte": to}}
+	if len(codes) > 0 {
+		filter["icao"] = bson.M{"$in": codes}
+	}
+	cur, err := depMC.TafVerificationCol().Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"_id": 0, "icao": 1, "total": 1, "by_lead": 1}))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cur.Close(ctx)
+	var docs []mdb.TafVerificationDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type agg struct {
+		tafs  int
+		total verify.Score
+		leads map[int]*verify.LeadScore
+	}
+	byStation := map[string]*agg{}
+	for _, d := range docs {
+		a, ok := byStation[d.ICAO]
+		if !ok {
+			a = &agg{leads: map[int]*verify.LeadScore{}}
+			byStation[d.ICAO] = a
+		}
+		a.tafs++
+		a.total.Add(d.Total)
+		for _, ls := range d.ByLead {
+			l, ok := a.leads[ls.FromH]
+			if !ok {
+				l = &verify.LeadScore{FromH: ls.FromH, ToH: ls.ToH}
+				a.leads[ls.FromH] = l
+			}
+			l.Add(ls.Score)
+		}
+	}
+
+	resp := TafVerificationResponse{From: from, To: to, Stations: []TafStationVerification{}}
+	for icao, a := range byStation {
+		st := TafStationVerification{ICAO: icao, TAFs: a.tafs, Overall: a.total.Accuracy(), ByLead: []TafLeadAccuracy{}}
+		for _, l := range a.leads {
+			st.ByLead = append(st.ByLead, TafLeadAccuracy{FromH: l.FromH, ToH: l.ToH, Accuracy: l.Accuracy()})
+		}
+		sort.Slice(st.ByLead, func(i, j int) bool { return st.ByLead[i].FromH < st.ByLead[j].FromH })
+		resp.Stations = append(resp.Stations, st)
+	}
+	sort.Slice(resp.Stations, func(i, j int) bool { return resp.Stations[i].ICAO < resp.Stations[j].ICAO })
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,175 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/watch"
+)
+
+const maxWatchRules = 50 // برای هر client
+
+// CreateWatchRuleRequest is the POST /wx/watch body.
+type CreateWatchRuleRequest struct {
+	watch.Rule
+	WebhookURL string `json:"webhook_url"`
+	Note       string `json:"note,omitempty"`
+}
+
+type WatchRulesResponse struct {
+	Items []mdb.WatchRuleDoc `json:"items"`
+}
+
+func clientIDFrom(r *http.Request) string {
+	id, _ := r.Context().Value(CtxClientID).(string)
+	return id
+}
+
+// List watch rules godoc
+// @Summary      List watch rules
+// @Description  Weather watch rules of the calling client with their last evaluation state.
+// @Tags         Weather
+// @Produce      json
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  WatchRulesResponse
+// @Failure      500  {object}  HTTPError
+// @Router       /wx/watch [get]
+func ListWatchRules(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+
+	items, err := depMC.ListWatchRules(ctx, clientIDFrom(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(WatchRulesResponse{Items: items})
+}
+
+// Create watch rule godoc
+// @Summary      Create a watch rule
+// @Description  Registers a threshold rule evaluated by the background poller against fresh METARs (source=metar) or the next lookahead_hours of the TAF (source=taf). kind: ceiling_below (ft), visibility_below (m), wind_above (kt, gust counts), crosswind_above (kt, needs runway), category (MVFR/IFR/LIFR or worse). When the rule starts or stops matching, a JSON payload is POSTed to webhook_url with X-Event, X-Delivery-Id, X-Date (epoch), X-Key-Version and X-Signature = Base64(HMAC-SHA256(X-Date + "\n" + X-Delivery-Id + "\n" + sha256hex(body), secret_vN)) using the client's active API secret.
+// @Tags         Weather
+// @Accept       json
+// @Produce      json
+// @Param        rule  body  CreateWatchRuleRequest  true  "Rule"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      201  {object}  mdb.WatchRuleDoc
+// @Failure      400  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /wx/watch [post]
+func CreateWatchRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreateWatchRuleRequest
+	dec := json.NewDecoder(io.LimitReader(r.Body, 16<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := req.Rule.Normalize(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	req.WebhookURL = strings.TrimSpace(req.WebhookURL)
+	if err := watch.ValidateURL(ctx, req.WebhookURL, depCfg.WebhookAllowHTTP); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	clientID := clientIDFrom(r)
+	n, err := depMC.CountWatchRules(ctx, clientID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n >= maxWatchRules {
+		http.Error(w, fmt.Sprintf(`{"error":"too many watch rules (max %d)"}`, maxWatchRules), http.StatusBadRequest)
+		return
+	}
+	if err := depMC.EnsureWatchIndexes(ctx); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	doc := mdb.WatchRuleDoc{
+		ID:         watch.NewID(),
+		ClientID:   clientID,
+		Rule:       req.Rule,
+		WebhookURL: req.WebhookURL,
+		Note:       req.Note,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := depMC.InsertWatchRule(ctx, doc); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+// Delete watch rule godoc
+// @Summary      Delete a watch rule
+// @Tags         Weather
+// @Param        id  path  string  true  "Rule id"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      204
+// @Failure      404  {object}  HTTPError
+// @Failure      500  {object}  HTTPError
+// @Router       /wx/watch/{id} [delete]
+func DeleteWatchRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	ok, err := depMC.DeleteWatchRule(ctx, clientIDFrom(r), r.PathValue("id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		http.Error(w, `{"error":"watch rule not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,397 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"SepTaf/internal/geo"
+	"SepTaf/internal/wx/winds"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	windsMaxStationNM = 300 // ایستگاه‌های دورتر در درون‌یابی مسیر استفاده نمی‌شوند
+	windsNeighbours   = 4
+)
+
+// WindsAloftDTO is returned by /wx/windtemp.
+type WindsAloftDTO struct {
+	Level    string          `json:"level"` // low | high
+	Fcst     int             `json:"fcst"`  // forecast hours (6, 12, 24)
+	BasedOn  time.Time       `json:"basedOn"`
+	Valid    time.Time       `json:"valid"`
+	UseFrom  time.Time       `json:"useFrom"`
+	UseTo    time.Time       `json:"useTo"`
+	Levels   []int           `json:"levels"`
+	Stations []winds.Station `json:"stations"`
+}
+
+// WindsRoutePoint is one sample along the route. Wind is nil when no FD
+// station with data at the level is within range.
+type WindsRoutePoint struct {
+	Lat         float64         `json:"lat"`
+	Lon         float64         `json:"lon"`
+	DistanceNM  float64         `json:"distanceNM"`
+	TrackDeg    float64         `json:"trackDeg"`
+	Wind        *winds.Estimate `json:"wind,omitempty"`
+	TailwindKt  *float64        `json:"tailwindKt,omitempty"` // negative = headwind
+	CrosswindKt *float64        `json:"crosswindKt,omitempty"`
+	Stations    []string        `json:"stations,omitempty"`
+}
+
+// WindsRouteDTO is returned by /wx/windtemp/route.
+type WindsRouteDTO struct {
+	From          string            `json:"from"`
+	To            string            `json:"to"`
+	AltitudeFt    int               `json:"altitudeFt"`
+	DistanceNM    float64           `json:"distanceNM"`
+	Valid         time.Time         `json:"valid"`
+	AvgTailwindKt *float64          `json:"avgTailwindKt,omitempty"`
+	Points        []WindsRoutePoint `json:"points"`
+}
+
+var (
+	windsCacheOnce sync.Once
+	windsCache     *awcCache
+)
+
+// fetchWindTemp reads the FD text product. It has the awcCache fetch shape:
+// resource=level, icao=region, hours=forecast hours.
+func fetchWindTemp(ctx context.Context, level, region string, fcst int) ([]byte, int, error) {
+	q := url.Values{}
+	q.Set("region", strings.ToLower(region))
+	q.Set("level", level)
+	q.Set("fcst", fmt.Sprintf("%02d", fcst))
+
+	resp, err := awcUp.Get(ctx, "/api/data/windtemp", q, http.Header{"Accept": {"text/plain"}})
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.Status != http.StatusOK {
+		return resp.Body, resp.Status, fmt.Errorf("awc windtemp http %d", resp.Status)
+	}
+	return resp.Body, resp.Status, nil
+}
+
+func loadWindsBulletin(ctx context.Context, level, region string, fcst int) (*winds.Bulletin, cacheMeta, error) {
+	windsCacheOnce.Do(func() { windsCache = newAWCCache(fetchWindTemp) })
+	b, _, meta, err := windsCache.get(ctx, "windtemp-"+level, region, fcst)
+	if err != nil {
+		return nil, meta, err
+	}
+	bl, err := winds.Parse(string(b), time.Now().UTC())
+	return bl, meta, err
+}
+
+// windsParams reads level / fcst / region with their defaults.
+func windsParams(q url.Values) (level, region string, fcst int, err error) {
+	level = strings.ToLower(strings.TrimSpace(q.Get("level")))
+	if level == "" {
+		level = "low"
+	}
+	if level != "low" && level != "high" {
+		return "", "", 0, badRequest{"level must be low or high"}
+	}
+	fcst = 6
+	if v := strings.TrimSpace(q.Get("fcst")); v != "" {
+		fcst, _ = strconv.Atoi(v)
+	}
+	if fcst != 6 && fcst != 12 && fcst != 24 {
+		return "", "", 0, badRequest{"fcst must be 06, 12 or 24"}
+	}
+	region = strings.TrimSpace(q.Get("region"))
+	if region == "" {
+		region = "all"
+	}
+	return level, region, fcst, nil
+}
+
+// fdID maps an ICAO code to its FD station id (KDEN → DEN, PANC → ANC).
+func fdID(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) == 4 && (code[0] == 'K' || code[0] == 'P') {
+		return code[1:]
+	}
+	return code
+}
+
+// WindsAloft godoc
+// @Summary      Winds and temperatures aloft (FD)
+// @Description  Decoded AWC winds/temps aloft forecast; 9900 is light and variable, temps above FL240 are negative
+// @Tags         Weather
+// @Produce      json
+// @Param        ids     query   string  false  "Comma-separated FD ids or ICAO codes (e.g., DEN,KORD); empty = all"
+// @Param        level   query   string  false  "low (default, to 39000 ft) | high"
+// @Param        fcst    query   int     false  "Forecast hours: 6 (default), 12, 24"
+// @Param        region  query   string  false  "AWC region (default all)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  WindsAloftDTO
+// @Failure      400  {object}  HTTPError
+// @Failure      502  {object}  HTTPError
+// @Router       /wx/windtemp [get]
+func GetWindsAloft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+	level, region, fcst, err := windsParams(q)
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+
+	bl, meta, err := loadWindsBulletin(r.Context(), level, region, fcst)
+	setCacheHeaders(w, meta)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+
+	out := WindsAloftDTO{
+		Level: level, Fcst: fcst,
+		BasedOn: bl.BasedOn, Valid: bl.Valid, UseFrom: bl.UseFrom, UseTo: bl.UseTo,
+		Levels: bl.Levels, Stations: bl.Stations,
+	}
+	if v := strings.TrimSpace(q.Get("ids")); v != "" {
+		out.Stations = []winds.Station{}
+		for _, id := range strings.Split(v, ",") {
+			if st, ok := bl.Station(fdID(id)); ok {
+				out.Stations = append(out.Stations, st)
+			}
+		}
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// WindsRoute godoc
+// @Summary      Winds aloft along a route
+// @Description  Wind/temperature at a flight level sampled along the great circle between two airports, interpolated (inverse distance) from nearby FD stations
+// @Tags         Weather
+// @Produce      json
+// @Param        from     query   string  true   "Departure airport (ICAO/GPS code)"
+// @Param        to       query   string  true   "Destination airport (ICAO/GPS code)"
+// @Param        fl       query   int     true   "Flight level (e.g., 350)"
+// @Param        fcst     query   int     false  "Forecast hours: 6 (default), 12, 24"
+// @Param        step_nm  query   number  false  "Sample spacing in NM (default 50)"
+/*Headers Params*/
+// @Param        X-Client-Id     header  string  true   "Client ID (e.g., client-42)"
+// @Param        X-Key-Version   header  string  true   "Key version (e.g., v1)"
+// @Param        X-Date          header  string  true   "Request time (RFC3339 or epoch seconds)"
+// @Param        X-Nonce         header  string  true   "Random nonce (UUID/base64)"
+// @Param        X-Signature     header  string  true   "Base64(HMAC-SHA256(canonical, secret_vN))"
+// @Security     ClientIDAuth
+// @Security     KeyVersionAuth
+// @Security     DateAuth
+// @Security     NonceAuth
+// @Security     SignatureAuth
+// @Success      200  {object}  WindsRouteDTO
+// @Failure      400  {object}  HTTPError
+// @Failure      502  {object}  HTTPError
+// @Router       /wx/windtemp/route [get]
+func GetWindsRoute(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	from := strings.ToUpper(strings.TrimSpace(q.Get("from")))
+	to := strings.ToUpper(strings.TrimSpace(q.Get("to")))
+	fl, err := strconv.Atoi(strings.TrimSpace(q.Get("fl")))
+	if from == "" || to == "" || err != nil || fl <= 0 || fl > 600 {
+		http.Error(w, `{"error":"from, to and fl (e.g. 350) are required"}`, http.StatusBadRequest)
+		return
+	}
+	alt := fl * 100
+	_, region, fcst, err := windsParams(q)
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+	level := "low"
+	if alt > 39000 {
+		level = "high"
+	}
+	step, err := floatParam(q.Get("step_nm"), 50, 500)
+	if err != nil {
+		http.Error(w, `{"error":"invalid step_nm"}`, http.StatusBadRequest)
+		return
+	}
+
+	a, err := airportPoint(ctx, from)
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+	b, err := airportPoint(ctx, to)
+	if err != nil {
+		writeStationError(w, err)
+		return
+	}
+
+	bl, meta, err := loadWindsBulletin(ctx, level, region, fcst)
+	setCacheHeaders(w, meta)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+	ids := make([]string, 0, len(bl.Stations))
+	for _, st := range bl.Stations {
+		ids = append(ids, st.ID)
+	}
+	pos, err := fdStationPoints(ctx, ids)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// مقدار هر ایستگاه در این ارتفاع فقط یک بار محاسبه می‌شود
+	type stationAt struct {
+		id  string
+		p   geo.Point
+		est winds.Estimate
+	}
+	var usable []stationAt
+	for _, st := range bl.Stations {
+		p, ok := pos[st.ID]
+		if !ok {
+			continue
+		}
+		if est, ok := st.At(alt); ok {
+			usable = append(usable, stationAt{st.ID, p, est})
+		}
+	}
+
+	total := geo.DistanceNM(a, b)
+	n := int(total/step) + 1
+	if n > 200 {
+		n = 200
+	}
+	out := WindsRouteDTO{From: from, To: to, AltitudeFt: alt, DistanceNM: round2(total), Valid: bl.Valid, Points: []WindsRoutePoint{}}
+	var tailSum float64
+	var tailN int
+	for i := 0; i <= n; i++ {
+		f := float64(i) / float64(n)
+		p := geo.Intermediate(a, b, f)
+		track := geo.Bearing(p, b)
+		if i == n {
+			track = geo.Bearing(geo.Intermediate(a, b, 0.999), b)
+		}
+		pt := WindsRoutePoint{Lat: round2(p.Lat), Lon: round2(p.Lon), DistanceNM: round2(total * f), TrackDeg: round2(track)}
+
+		type cand struct {
+			stationAt
+			d float64
+		}
+		var near []cand
+		for _, s := range usable {
+			if d := geo.DistanceNM(p, s.p); d <= windsMaxStationNM {
+				near = append(near, cand{s, d})
+			}
+		}
+		sort.Slice(near, func(i, j int) bool { return near[i].d < near[j].d })
+		if len(near) > windsNeighbours {
+			near = near[:windsNeighbours]
+		}
+		if len(near) > 0 {
+			ests := make([]winds.Estimate, len(near))
+			weights := make([]float64, len(near))
+			for k, c := range near {
+				ests[k] = c.est
+				d := c.d
+				if d < 1 {
+					d = 1
+				}
+				weights[k] = 1 / (d * d)
+				pt.Stations = append(pt.Stations, c.id)
+			}
+			est := winds.Blend(ests, weights)
+			tail, cross := winds.Components(est, track)
+			pt.Wind, pt.TailwindKt, pt.CrosswindKt = &est, &tail, &cross
+			tailSum += tail
+			tailN++
+		}
+		out.Points = append(out.Points, pt)
+	}
+	if tailN > 0 {
+		avg := round2(tailSum / float64(tailN))
+		out.AvgTailwindKt = &avg
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// fdStationPoints resolves FD ids to positions through the airports
+// collection (iata_code, K/P-prefixed icao_code, or ident).
+func fdStationPoints(ctx context.Context, ids []string) (map[string]geo.Point, error) {
+	var icaos []string
+	for _, id := range ids {
+		icaos = append(icaos, "K"+id, "P"+id)
+	}
+	filter := bson.M{
+		"location": bson.M{"$ne": nil},
+		"$or": []bson.M{
+			{"icao_code": bson.M{"$in": icaos}},
+			{"iata_code": bson.M{"$in": ids}},
+			{"ident": bson.M{"$in": ids}},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 0, "icao_code": 1, "iata_code": 1, "ident": 1, "location": 1})
+	cur, err := depMC.DB.Collection("airports").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []AirportDTO
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	want := map[string]bool{}
+	for _, id := range ids {
+		want[id] = true
+	}
+	out := map[string]geo.Point{}
+	// ترجیح: icao_code، بعد iata_code، بعد ident
+	for pass := 0; pass < 3; pass++ {
+		for _, row := range rows {
+			if row.Location == nil {
+				continue
+			}
+			var id string
+			switch pass {
+			case 0:
+				id = fdID(row.IcaoCode)
+				if id == row.IcaoCode {
+					id = ""
+				}
+			case 1:
+				id = row.IATACode
+			case 2:
+				id = row.Ident
+			}
+			if id == "" || !want[id] {
+				continue
+			}
+			if _, done := out[id]; !done {
+				out[id] = geo.Point{Lat: row.Location.Coordinates[1], Lon: row.Location.Coordinates[0]}
+			}
+		}
+	}
+	return out, nil
+}
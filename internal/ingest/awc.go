@@ -0,0 +1,46 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"SepTaf/internal/config"
+	"SepTaf/internal/upstream"
+)
+
+// loadAWCFeed reads one AWC data API resource (metar, taf, isigmet, pirep
+// ...) as JSON through the AWC provider, so record/replay applies to the
+// ingest jobs too. 204 (no data) leaves out untouched. With
+// AWC_FEED_FIXTURE_DIR set, a hand-written <name>.json is read from that
+// directory instead, whatever the query.
+func loadAWCFeed(ctx context.Context, cfg config.Config, name string, q url.Values, out any) error {
+	if cfg.AWCFeedFixtureDir != "" {
+		b, err := os.ReadFile(filepath.Join(cfg.AWCFeedFixtureDir, name+".json"))
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(b, out)
+	}
+	if q == nil {
+		q = url.Values{}
+	}
+	q.Set("format", "json")
+	resp, err := upstream.AWC(cfg).Get(ctx, "/api/data/"+name, q, http.Header{"Accept": {"application/json"}})
+	if err != nil {
+		return err
+	}
+	// AWC برای "داده‌ای نیست" گاهی 204 برمی‌گرداند
+	if resp.Status == http.StatusNoContent {
+		return nil
+	}
+	if resp.Status != http.StatusOK {
+		return fmt.Errorf("awc %s http %d: %s", name, resp.Status, strings.TrimSpace(string(resp.Body)))
+	}
+	return json.Unmarshal(resp.Body, out)
+}
@@ -0,0 +1,176 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"SepTaf/internal/config"
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/wx/fltcat"
+	"SepTaf/internal/wx/gts"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+// فایلی که هنوز در حال نوشتن است را دور بعد می‌خوانیم
+const gtsSettle = 10 * time.Second
+
+type gtsStats struct {
+	Bulletins int
+	Metars    int
+	Tafs      int
+	Skipped   int
+}
+
+// IngestGTSDir loads every WMO bulletin file in cfg.GTSDir into
+// metar_history / taf_history (source "gts") and moves it to done/, or to
+// failed/ when it cannot be read. Files starting with "." are ignored.
+func IngestGTSDir(ctx context.Context, cfg config.Config, mc *mdb.Client) error {
+	if cfg.GTSDir == "" {
+		return nil
+	}
+	rules, err := fltcat.ParseRules(cfg.FltCatRules)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(cfg.GTSDir)
+	if err != nil {
+		return err
+	}
+	if err := mc.EnsureWXHistoryIndexes(ctx, time.Duration(cfg.WXHistoryDays)*24*time.Hour); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || now.Sub(info.ModTime()) < gtsSettle {
+			continue
+		}
+		path := filepath.Join(cfg.GTSDir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf(`{"lvl":"error","msg":"gts file unreadable","file":%q,"err":%q}`, e.Name(), err.Error())
+			moveGTSFile(cfg.GTSDir, e.Name(), "failed")
+			continue
+		}
+
+		// YYGGgg هدر با زمان فایل تکمیل می‌شود تا آرشیوهای قدیمی هم درست بنشینند
+		mdocs, tdocs, st := parseGTSFile(data, info.ModTime(), rules, now)
+		if err := fillGTSCoords(ctx, mc, mdocs, tdocs); err != nil {
+			return err
+		}
+		if err := mc.BulkUpsertMetarHistory(ctx, mdocs); err != nil {
+			return err
+		}
+		if err := mc.BulkUpsertTafHistory(ctx, tdocs); err != nil {
+			return err
+		}
+		log.Printf(`{"lvl":"info","msg":"gts file loaded","file":%q,"bulletins":%d,"metars":%d,"tafs":%d,"skipped":%d}`,
+			e.Name(), st.Bulletins, st.Metars, st.Tafs, st.Skipped)
+		moveGTSFile(cfg.GTSDir, e.Name(), "done")
+	}
+	return nil
+}
+
+func parseGTSFile(data []byte, ref time.Time, rules fltcat.Rules, now time.Time) ([]mdb.MetarHistoryDoc, []mdb.TafHistoryDoc, gtsStats) {
+	var (
+		mdocs []mdb.MetarHistoryDoc
+		tdocs []mdb.TafHistoryDoc
+		st    gtsStats
+	)
+	for _, b := range gts.Split(data, ref) {
+		if b.Kind == "" {
+			continue
+		}
+		st.Bulletins++
+		for _, raw := range b.Reports {
+			switch b.Kind {
+			case "METAR", "SPECI":
+				rep, err := metar.Parse(raw, b.Time)
+				if err != nil || rep.Nil || rep.Time.IsZero() {
+					st.Skipped++
+					continue
+				}
+				mdocs = append(mdocs, mdb.MetarHistoryDoc{
+					ICAO:       rep.Station,
+					ObsTime:    rep.Time,
+					ReportType: rep.Type,
+					Raw:        rep.Raw,
+					FltCat:     rules.FromConditions(rep.Conditions),
+					Source:     "gts",
+					StoredAt:   now,
+				})
+				st.Metars++
+			case "TAF":
+				t, err := taf.Parse(raw, b.Time)
+				if err != nil || t.Nil || t.IssueTime.IsZero() || t.ValidTo.IsZero() {
+					st.Skipped++
+					continue
+				}
+				tdocs = append(tdocs, mdb.TafHistoryDoc{
+					ICAO:      t.Station,
+					IssueTime: t.IssueTime,
+					ValidFrom: t.ValidFrom,
+					ValidTo:   t.ValidTo,
+					Raw:       t.Raw,
+					Source:    "gts",
+					StoredAt:  now,
+				})
+				st.Tafs++
+			}
+		}
+	}
+	return mdocs, tdocs, st
+}
+
+// fillGTSCoords sets lat/lon from airports, as AWC does for its reports.
+func fillGTSCoords(ctx context.Context, mc *mdb.Client, mdocs []mdb.MetarHistoryDoc, tdocs []mdb.TafHistoryDoc) error {
+	seen := map[string]bool{}
+	var codes []string
+	for _, d := range mdocs {
+		if !seen[d.ICAO] {
+			seen[d.ICAO] = true
+			codes = append(codes, d.ICAO)
+		}
+	}
+	for _, d := range tdocs {
+		if !seen[d.ICAO] {
+			seen[d.ICAO] = true
+			codes = append(codes, d.ICAO)
+		}
+	}
+	coords, err := mc.StationCoords(ctx, codes)
+	if err != nil {
+		return err
+	}
+	for i := range mdocs {
+		if c, ok := coords[mdocs[i].ICAO]; ok {
+			mdocs[i].Lon, mdocs[i].Lat = c[0], c[1]
+		}
+	}
+	for i := range tdocs {
+		if c, ok := coords[tdocs[i].ICAO]; ok {
+			tdocs[i].Lon, tdocs[i].Lat = c[0], c[1]
+		}
+	}
+	return nil
+}
+
+func moveGTSFile(dir, name, sub string) {
+	dst := filepath.Join(dir, sub)
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		log.Printf(`{"lvl":"error","msg":"gts move failed","file":%q,"err":%q}`, name, err.Error())
+		return
+	}
+	if err := os.Rename(filepath.Join(dir, name), filepath.Join(dst, name)); err != nil {
+		log.Printf(`{"lvl":"error","msg":"gts move failed","file":%q,"err":%q}`, name, err.Error())
+	}
+}
@@ -50,6 +50,21 @@ func RunAll(ctx context.Context, cfg config.Config, mc *mdb.Client) error {
 	if err := ParseRegionsStreamAndUpsert(ctx, rgFile, mc); err != nil {
 		return err
 	}
+	// === Runways ===
+	if cfg.URLRunways != "" {
+		rwFile, err := downloadToTemp(cfg.URLRunways)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(rwFile)
+
+		if err := mc.EnsureRunwayIndexes(ctx); err != nil {
+			return err
+		}
+		if err := ParseRunwaysStreamAndUpsert(ctx, rwFile, mc); err != nil {
+			return err
+		}
+	}
 	//// === FIRs ===  👇 بخش جدید
 	if cfg.URLFIRs != "" {
 		firFile, err := downloadToTemp(cfg.URLFIRs)
@@ -0,0 +1,94 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"SepTaf/internal/config"
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/wx/pirep"
+)
+
+// AWC /pirep
+type awcPirep struct {
+	ICAOId    string   `json:"icaoId"`
+	PirepType string   `json:"pirepType"`
+	ObsTime   int64    `json:"obsTime"`
+	AcType    string   `json:"acType"`
+	Lat       *float64 `json:"lat"`
+	Lon       *float64 `json:"lon"`
+	FltLvl    any      `json:"fltLvl"` // عدد یا "DURD"/"DURC"
+	RawOb     string   `json:"rawOb"`
+}
+
+// IngestPIREPs pulls recent PIREPs/AIREPs, decodes them and stores the ones
+// with a usable position.
+func IngestPIREPs(ctx context.Context, cfg config.Config, mc *mdb.Client) error {
+	if err := mc.EnsurePirepIndexes(ctx, time.Duration(cfg.PIREPKeepHours)*time.Hour); err != nil {
+		return err
+	}
+	q := url.Values{}
+	q.Set("age", fmt.Sprintf("%d", cfg.PIREPAgeHours))
+	var items []awcPirep
+	if err := loadAWCFeed(ctx, cfg, "pirep", q, &items); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	docs := make([]mdb.PirepDoc, 0, len(items))
+	for _, it := range items {
+		if d, ok := pirepDoc(it, now); ok {
+			docs = append(docs, d)
+		}
+	}
+	return mc.BulkUpsertPireps(ctx, docs)
+}
+
+func pirepDoc(it awcPirep, now time.Time) (mdb.PirepDoc, bool) {
+	obs := time.Unix(it.ObsTime, 0).UTC()
+	d := mdb.PirepDoc{
+		ICAO:         strings.ToUpper(it.ICAOId),
+		Kind:         pirep.KindPIREP,
+		ObsTime:      obs,
+		AircraftType: it.AcType,
+		Raw:          it.RawOb,
+		StoredAt:     now,
+	}
+	if rep, err := pirep.Parse(it.RawOb, obs); err == nil {
+		d.Decoded = rep
+		d.Kind = rep.Kind
+		d.FlightLevel = rep.FlightLevel
+		if d.AircraftType == "" {
+			d.AircraftType = rep.AircraftType
+		}
+		if it.Lat == nil && rep.Lat != nil {
+			it.Lat, it.Lon = rep.Lat, rep.Lon
+		}
+		d.TurbMax = rep.MaxTurbulence()
+		d.IcingMax = rep.MaxIcing()
+	}
+	if d.FlightLevel == nil {
+		if f, ok := it.FltLvl.(float64); ok {
+			n := int(f)
+			d.FlightLevel = &n
+		}
+	}
+	d.TurbRank = pirep.IntensityRank(d.TurbMax)
+	d.IcingRank = pirep.IntensityRank(d.IcingMax)
+
+	// بدون موقعیت در جستجوی مکانی بی‌فایده است
+	if it.Lat == nil || it.Lon == nil || (*it.Lat == 0 && *it.Lon == 0) {
+		return d, false
+	}
+	d.Lat, d.Lon = *it.Lat, *it.Lon
+	d.Location = map[string]any{"type": "Point", "coordinates": []float64{d.Lon, d.Lat}}
+
+	h := sha1.Sum([]byte(it.RawOb))
+	d.Key = strings.Join([]string{d.ICAO, obs.Format(time.RFC3339), d.AircraftType, hex.EncodeToString(h[:6])}, "|")
+	return d, true
+}
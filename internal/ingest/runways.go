@@ -0,0 +1,99 @@
+package ingest
+
+import (
+	"context"
+	"encoding/csv"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	mdb "SepTaf/internal/mongo"
+)
+
+// ParseRunwaysStreamAndUpsert loads OurAirports runways.csv.
+func ParseRunwaysStreamAndUpsert(ctx context.Context, path string, mc *mdb.Client) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return err
+	}
+	idx := make(map[string]int, len(header))
+	for i, h := range header {
+		idx[h] = i
+	}
+
+	batch := make([]mdb.RunwayDoc, 0, 1000)
+	rows := 0
+	last := time.Now()
+
+	for {
+		row, err := r.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return err
+		}
+		rows++
+
+		get := func(k string) string {
+			if p, ok := idx[k]; ok && p < len(row) {
+				return row[p]
+			}
+			return ""
+		}
+		atoi := func(k string) *int {
+			if n, e := strconv.Atoi(get(k)); e == nil {
+				return &n
+			}
+			return nil
+		}
+		atof := func(k string) *float64 {
+			if v, e := strconv.ParseFloat(get(k), 64); e == nil {
+				return &v
+			}
+			return nil
+		}
+
+		batch = append(batch, mdb.RunwayDoc{
+			IDCSV:        atoi("id"),
+			AirportIdent: get("airport_ident"),
+			LengthFt:     atoi("length_ft"),
+			WidthFt:      atoi("width_ft"),
+			Surface:      get("surface"),
+			Lighted:      get("lighted") == "1",
+			Closed:       get("closed") == "1",
+			LeIdent:      get("le_ident"),
+			LeHeadingT:   atof("le_heading_degT"),
+			HeIdent:      get("he_ident"),
+			HeHeadingT:   atof("he_heading_degT"),
+		})
+		if len(batch) >= 1000 {
+			if err := mc.BulkUpsertRunways(ctx, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+
+		if time.Since(last) > 5*time.Second {
+			log.Printf(`{"msg":"runways-progress","rows":%d}`, rows)
+			last = time.Now()
+		}
+	}
+	if len(batch) > 0 {
+		if err := mc.BulkUpsertRunways(ctx, batch); err != nil {
+			return err
+		}
+	}
+	log.Printf(`{"msg":"runways-upsert-done","rows":%d}`, rows)
+	return nil
+}
@@ -0,0 +1,152 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"SepTaf/internal/config"
+	mdb "SepTaf/internal/mongo"
+)
+
+type awcCoord struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// AWC /isigmet (international SIGMETs)
+type awcISigmet struct {
+	ICAOId        string     `json:"icaoId"`
+	FIRId         string     `json:"firId"`
+	FIRName       string     `json:"firName"`
+	SeriesID      string     `json:"seriesId"`
+	Hazard        string     `json:"hazard"`
+	Qualifier     string     `json:"qualifier"`
+	Base          *float64   `json:"base"`
+	Top           *float64   `json:"top"`
+	ValidTimeFrom int64      `json:"validTimeFrom"`
+	ValidTimeTo   int64      `json:"validTimeTo"`
+	RawSigmet     string     `json:"rawSigmet"`
+	Coords        []awcCoord `json:"coords"`
+}
+
+// AWC /airsigmet (US SIGMET/AIRMET/convective)
+type awcAirSigmet struct {
+	ICAOId        string     `json:"icaoId"`
+	AirSigmetType string     `json:"airSigmetType"`
+	Alphachar     string     `json:"alphaChar"`
+	Hazard        string     `json:"hazard"`
+	Severity      any        `json:"severity"`
+	AltitudeLow1  *float64   `json:"altitudeLow1"`
+	AltitudeHi1   *float64   `json:"altitudeHi1"`
+	ValidTimeFrom int64      `json:"validTimeFrom"`
+	ValidTimeTo   int64      `json:"validTimeTo"`
+	RawAirSigmet  string     `json:"rawAirSigmet"`
+	Coords        []awcCoord `json:"coords"`
+}
+
+// IngestSIGMETs pulls international and US advisories and upserts the
+// active ones.
+func IngestSIGMETs(ctx context.Context, cfg config.Config, mc *mdb.Client) error {
+	if err := mc.EnsureSigmetIndexes(ctx); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	var docs []mdb.SigmetDoc
+
+	var intl []awcISigmet
+	if err := loadAWCFeed(ctx, cfg, "isigmet", nil, &intl); err != nil {
+		return err
+	}
+	for _, s := range intl {
+		d := mdb.SigmetDoc{
+			Kind:      "SIGMET",
+			Source:    "isigmet",
+			ICAOId:    s.ICAOId,
+			FIRId:     strings.ToUpper(s.FIRId),
+			FIRName:   s.FIRName,
+			SeriesID:  s.SeriesID,
+			Hazard:    s.Hazard,
+			Qualifier: s.Qualifier,
+			BaseFt:    intPtr(s.Base),
+			TopFt:     intPtr(s.Top),
+			ValidFrom: time.Unix(s.ValidTimeFrom, 0).UTC(),
+			ValidTo:   time.Unix(s.ValidTimeTo, 0).UTC(),
+			Raw:       s.RawSigmet,
+			Geometry:  polygonFromCoords(s.Coords),
+			UpdatedAt: now,
+		}
+		docs = appendActive(docs, d, now)
+	}
+
+	var us []awcAirSigmet
+	if err := loadAWCFeed(ctx, cfg, "airsigmet", nil, &us); err != nil {
+		return err
+	}
+	for _, s := range us {
+		kind := strings.ToUpper(s.AirSigmetType)
+		if kind == "" {
+			kind = "SIGMET"
+		}
+		d := mdb.SigmetDoc{
+			Kind:      kind,
+			Source:    "airsigmet",
+			ICAOId:    s.ICAOId,
+			SeriesID:  s.Alphachar,
+			Hazard:    s.Hazard,
+			BaseFt:    intPtr(s.AltitudeLow1),
+			TopFt:     intPtr(s.AltitudeHi1),
+			ValidFrom: time.Unix(s.ValidTimeFrom, 0).UTC(),
+			ValidTo:   time.Unix(s.ValidTimeTo, 0).UTC(),
+			Raw:       s.RawAirSigmet,
+			Geometry:  polygonFromCoords(s.Coords),
+			UpdatedAt: now,
+		}
+		if s.Severity != nil {
+			d.Severity = fmt.Sprint(s.Severity)
+		}
+		docs = appendActive(docs, d, now)
+	}
+
+	return mc.BulkUpsertSigmets(ctx, docs)
+}
+
+func appendActive(docs []mdb.SigmetDoc, d mdb.SigmetDoc, now time.Time) []mdb.SigmetDoc {
+	if d.ValidTo.Before(now) || d.Geometry == nil {
+		return docs
+	}
+	d.Key = strings.Join([]string{d.Source, d.ICAOId, d.FIRId, d.SeriesID, d.Hazard, d.ValidFrom.Format(time.RFC3339)}, "|")
+	return append(docs, d)
+}
+
+// polygonFromCoords builds a closed GeoJSON polygon; nil if there are not
+// enough distinct points to form one.
+func polygonFromCoords(cs []awcCoord) map[string]any {
+	ring := make([][]float64, 0, len(cs)+1)
+	for _, c := range cs {
+		p := []float64{c.Lon, c.Lat}
+		if n := len(ring); n > 0 && ring[n-1][0] == p[0] && ring[n-1][1] == p[1] {
+			continue
+		}
+		ring = append(ring, p)
+	}
+	if len(ring) < 3 {
+		return nil
+	}
+	if f, l := ring[0], ring[len(ring)-1]; f[0] != l[0] || f[1] != l[1] {
+		ring = append(ring, []float64{f[0], f[1]})
+	}
+	if len(ring) < 4 {
+		return nil
+	}
+	return map[string]any{"type": "Polygon", "coordinates": [][][]float64{ring}}
+}
+
+func intPtr(f *float64) *int {
+	if f == nil {
+		return nil
+	}
+	n := int(*f)
+	return &n
+}
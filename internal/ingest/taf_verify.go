@@ -0,0 +1,161 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"SepTaf/internal/config"
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/wx/fltcat"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+	"SepTaf/internal/wx/verify"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TAFهایی که اعتبارشان در این بازه تمام شده بررسی می‌شوند؛ اجرای جاافتاده
+// در دور بعد جبران می‌شود
+const tafVerifyLookback = 48 * time.Hour
+
+// VerifyTAFs scores every archived TAF whose validity ended recently (and
+// that has not been verified yet) against the archived METARs. The archive
+// may come from WX_STATIONS or GTS files; without archived TAFs and METARs
+// in the window it returns without touching the database.
+func VerifyTAFs(ctx context.Context, cfg config.Config, mc *mdb.Client) error {
+	rules, err := fltcat.ParseRules(cfg.FltCatRules)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	cur, err := mc.TafHistoryCol().Find(ctx,
+		bson.M{"valid_to": bson.M{"$gt": now.Add(-tafVerifyLookback), "$lte": now}},
+		options.Find().SetSort(bson.D{{Key: "icao", Value: 1}, {Key: "issue_time", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	var tafs []mdb.TafHistoryDoc
+	if err := cur.All(ctx, &tafs); err != nil {
+		return err
+	}
+	if len(tafs) == 0 {
+		return nil
+	}
+	// اعتبار TAF حداکثر ۳۰ ساعت است
+	n, err := mc.MetarHistoryCol().CountDocuments(ctx,
+		bson.M{"obs_time": bson.M{"$gt": now.Add(-tafVerifyLookback - 36*time.Hour)}},
+		options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	if err := mc.EnsureTafVerificationIndexes(ctx, time.Duration(cfg.WXHistoryDays)*24*time.Hour); err != nil {
+		return err
+	}
+	done, err := verifiedTAFs(ctx, mc, now.Add(-tafVerifyLookback-36*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	var docs []mdb.TafVerificationDoc
+	for _, th := range tafs {
+		if done[th.ICAO+"|"+th.IssueTime.Format(time.RFC3339)] {
+			continue
+		}
+		t, err := taf.Parse(th.Raw, th.IssueTime)
+		if err != nil || t.Nil || t.Cancelled || t.ValidTo.IsZero() {
+			continue
+		}
+		until, err := supersededAt(ctx, mc, th)
+		if err != nil {
+			return err
+		}
+		obs, err := observations(ctx, mc, th.ICAO, t.ValidFrom, until)
+		if err != nil {
+			return err
+		}
+		res := verify.Verify(t, obs, until, rules)
+		if res.Total.Samples == 0 {
+			// هنوز METAR آرشیو نشده؛ دور بعد دوباره
+			continue
+		}
+		docs = append(docs, mdb.TafVerificationDoc{
+			ICAO:       th.ICAO,
+			IssueTime:  th.IssueTime,
+			ValidFrom:  t.ValidFrom,
+			ValidTo:    t.ValidTo,
+			Until:      until,
+			Rules:      rules.Name,
+			Total:      res.Total,
+			ByLead:     res.ByLead,
+			VerifiedAt: now,
+		})
+	}
+	if err := mc.BulkUpsertTafVerification(ctx, docs); err != nil {
+		return err
+	}
+	log.Printf(`{"lvl":"info","msg":"taf verification","candidates":%d,"verified":%d}`, len(tafs), len(docs))
+	return nil
+}
+
+func verifiedTAFs(ctx context.Context, mc *mdb.Client, since time.Time) (map[string]bool, error) {
+	cur, err := mc.TafVerificationCol().Find(ctx,
+		bson.M{"issue_time": bson.M{"$gte": since}},
+		options.Find().SetProjection(bson.M{"icao": 1, "issue_time": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var rows []mdb.TafVerificationDoc
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		out[r.ICAO+"|"+r.IssueTime.Format(time.RFC3339)] = true
+	}
+	return out, nil
+}
+
+// supersededAt is the issue time of the station's next TAF (an amendment or
+// the next routine issue) if that comes before the end of validity.
+func supersededAt(ctx context.Context, mc *mdb.Client, th mdb.TafHistoryDoc) (time.Time, error) {
+	var next mdb.TafHistoryDoc
+	err := mc.TafHistoryCol().FindOne(ctx,
+		bson.M{"icao": th.ICAO, "issue_time": bson.M{"$gt": th.IssueTime, "$lt": th.ValidTo}},
+		options.FindOne().SetSort(bson.D{{Key: "issue_time", Value: 1}})).Decode(&next)
+	if err == mongo.ErrNoDocuments {
+		return th.ValidTo, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return next.IssueTime, nil
+}
+
+func observations(ctx context.Context, mc *mdb.Client, icao string, from, to time.Time) ([]*metar.Report, error) {
+	cur, err := mc.MetarHistoryCol().Find(ctx,
+		bson.M{"icao": icao, "obs_time": bson.M{"$gte": from, "$lt": to}},
+		options.Find().SetSort(bson.D{{Key: "obs_time", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var rows []mdb.MetarHistoryDoc
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	out := make([]*metar.Report, 0, len(rows))
+	for _, m := range rows {
+		rep, err := metar.Parse(m.Raw, m.ObsTime)
+		if err != nil {
+			continue
+		}
+		rep.Time = m.ObsTime
+		out = append(out, rep)
+	}
+	return out, nil
+}
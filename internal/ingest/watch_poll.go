@@ -0,0 +1,164 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"SepTaf/internal/config"
+	mdb "SepTaf/internal/mongo"
+	"SepTaf/internal/watch"
+	"SepTaf/internal/wx/fltcat"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// PollWatchRules evaluates every watch rule against fresh METARs/TAFs and
+// sends a webhook when a rule starts or stops matching. A failed delivery
+// leaves the stored state unchanged so the next poll tries again.
+func PollWatchRules(ctx context.Context, cfg config.Config, mc *mdb.Client) error {
+	rules, err := mc.ListWatchRules(ctx, "")
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	cat, err := fltcat.ParseRules(cfg.FltCatRules)
+	if err != nil {
+		return err
+	}
+
+	var stations []string
+	seen := map[string]bool{}
+	for _, r := range rules {
+		if !seen[r.ICAO] {
+			seen[r.ICAO] = true
+			stations = append(stations, r.ICAO)
+		}
+	}
+	now := time.Now().UTC()
+	metars, tafs, err := latestReports(ctx, cfg, stations, now)
+	if err != nil {
+		return err
+	}
+
+	clients := map[string]*mdb.APIClient{}
+	sent, failed := 0, 0
+	for _, r := range rules {
+		var res watch.Result
+		var evalErr error
+		if r.Source == watch.SourceTAF {
+			res, evalErr = r.EvaluateTAF(tafs[r.ICAO], now, cat)
+		} else {
+			res, evalErr = r.EvaluateMETAR(metars[r.ICAO], cat)
+		}
+		set := bson.M{"last_evaluated": now}
+		if evalErr != nil {
+			set["last_error"] = evalErr.Error()
+			_ = mc.SetWatchState(ctx, r.ID, set)
+			continue
+		}
+		set["last_result"] = res
+
+		first := r.LastEvaluated.IsZero()
+		// اولین ارزیابی فقط وقتی webhook می‌فرستد که قانون همین الان برقرار باشد
+		if res.Match == r.Matching || (first && !res.Match) {
+			set["matching"] = res.Match
+			if err := mc.SetWatchState(ctx, r.ID, set); err != nil {
+				return err
+			}
+			continue
+		}
+
+		cl, ok := clients[r.ClientID]
+		if !ok {
+			cl, _ = mc.GetAPIClient(ctx, r.ClientID) // nil => disabled/removed
+			clients[r.ClientID] = cl
+		}
+		ver, secret, ok := "", "", false
+		if cl != nil {
+			ver, secret, ok = cl.FindSecret("")
+		}
+		if !ok {
+			set["last_error"] = "client disabled or has no active secret"
+			_ = mc.SetWatchState(ctx, r.ID, set)
+			continue
+		}
+
+		p := watch.Payload{
+			Event:       watch.EventClear,
+			DeliveryID:  watch.NewID(),
+			RuleID:      r.ID,
+			Description: r.Describe(),
+			Rule:        r.Rule,
+			Result:      res,
+			SentAt:      now,
+		}
+		if res.Match {
+			p.Event = watch.EventMatch
+		}
+		if err := watch.Deliver(ctx, r.WebhookURL, ver, secret, p); err != nil {
+			failed++
+			set["last_error"] = err.Error()
+			log.Printf(`{"lvl":"warn","msg":"watch webhook failed","rule":%q,"err":%q}`, r.ID, err.Error())
+			_ = mc.SetWatchState(ctx, r.ID, set)
+			continue
+		}
+		sent++
+		set["matching"] = res.Match
+		set["last_change"] = now
+		set["last_delivery"] = now
+		set["last_error"] = ""
+		if err := mc.SetWatchState(ctx, r.ID, set); err != nil {
+			return err
+		}
+	}
+	log.Printf(`{"lvl":"info","msg":"watch poll","rules":%d,"sent":%d,"failed":%d}`, len(rules), sent, failed)
+	return nil
+}
+
+// latestReports fetches the newest METAR and current TAF per station.
+func latestReports(ctx context.Context, cfg config.Config, stations []string, now time.Time) (map[string]*metar.Report, map[string]*taf.TAF, error) {
+	metars := map[string]*metar.Report{}
+	tafs := map[string]*taf.TAF{}
+	for start := 0; start < len(stations); start += 50 {
+		end := start + 50
+		if end > len(stations) {
+			end = len(stations)
+		}
+		ids := strings.Join(stations[start:end], ",")
+
+		var ms []awcMetarLight
+		if err := fetchAWCJSON(ctx, cfg, "metar", ids, 2, &ms); err != nil {
+			return nil, nil, err
+		}
+		for _, m := range ms {
+			obs := time.Unix(m.ObsTime, 0).UTC()
+			if cur, ok := metars[m.ICAOId]; ok && !obs.After(cur.Time) {
+				continue
+			}
+			rep, err := metar.Parse(m.RawOb, obs)
+			if err != nil {
+				continue
+			}
+			rep.Time = obs
+			metars[m.ICAOId] = rep
+		}
+
+		var ts []awcTafLight
+		if err := fetchAWCJSON(ctx, cfg, "taf", ids, 0, &ts); err != nil {
+			return nil, nil, err
+		}
+		for _, t := range ts {
+			if cur, ok := tafs[t.ICAOId]; ok && !t.IssueTime.After(cur.IssueTime) {
+				continue
+			}
+			dec, err := taf.Parse(t.RawTAF, t.IssueTime.UTC())
+			if err != nil || !dec.ValidTo.After(now) {
+				continue
+			}
+			tafs[t.ICAOId] = dec
+		}
+	}
+	return metars, tafs, nil
+}
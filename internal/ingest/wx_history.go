@@ -0,0 +1,123 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"SepTaf/internal/config"
+	mdb "SepTaf/internal/mongo"
+)
+
+// فقط فیلدهایی از AWC که برای آرشیو لازم داریم
+type awcMetarLight struct {
+	ICAOId    string  `json:"icaoId"`
+	ObsTime   int64   `json:"obsTime"`
+	MetarType string  `json:"metarType"`
+	RawOb     string  `json:"rawOb"`
+	FltCat    string  `json:"fltCat"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+}
+
+type awcTafLight struct {
+	ICAOId        string    `json:"icaoId"`
+	IssueTime     time.Time `json:"issueTime"`
+	ValidTimeFrom int64     `json:"validTimeFrom"`
+	ValidTimeTo   int64     `json:"validTimeTo"`
+	RawTAF        string    `json:"rawTAF"`
+	Lat           float64   `json:"lat"`
+	Lon           float64   `json:"lon"`
+}
+
+// ParseStationList splits a comma/space separated ICAO list (WX_STATIONS).
+func ParseStationList(s string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == ';' }) {
+		f = strings.ToUpper(strings.TrimSpace(f))
+		if len(f) != 4 || seen[f] {
+			continue
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// CollectWXHistory fetches METAR/TAF for cfg.WXStations and archives them
+// into metar_history / taf_history.
+func CollectWXHistory(ctx context.Context, cfg config.Config, mc *mdb.Client) error {
+	stations := ParseStationList(cfg.WXStations)
+	if len(stations) == 0 {
+		return nil
+	}
+	if err := mc.EnsureWXHistoryIndexes(ctx, time.Duration(cfg.WXHistoryDays)*24*time.Hour); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for start := 0; start < len(stations); start += 50 {
+		end := start + 50
+		if end > len(stations) {
+			end = len(stations)
+		}
+		ids := strings.Join(stations[start:end], ",")
+
+		var metars []awcMetarLight
+		if err := fetchAWCJSON(ctx, cfg, "metar", ids, 3, &metars); err != nil {
+			return err
+		}
+		mdocs := make([]mdb.MetarHistoryDoc, 0, len(metars))
+		for _, m := range metars {
+			mdocs = append(mdocs, mdb.MetarHistoryDoc{
+				ICAO:       m.ICAOId,
+				ObsTime:    time.Unix(m.ObsTime, 0).UTC(),
+				ReportType: m.MetarType,
+				Raw:        m.RawOb,
+				FltCat:     m.FltCat,
+				Lat:        m.Lat,
+				Lon:        m.Lon,
+				Source:     "awc",
+				StoredAt:   now,
+			})
+		}
+		if err := mc.BulkUpsertMetarHistory(ctx, mdocs); err != nil {
+			return err
+		}
+
+		var tafs []awcTafLight
+		if err := fetchAWCJSON(ctx, cfg, "taf", ids, 0, &tafs); err != nil {
+			return err
+		}
+		tdocs := make([]mdb.TafHistoryDoc, 0, len(tafs))
+		for _, t := range tafs {
+			tdocs = append(tdocs, mdb.TafHistoryDoc{
+				ICAO:      t.ICAOId,
+				IssueTime: t.IssueTime.UTC(),
+				ValidFrom: time.Unix(t.ValidTimeFrom, 0).UTC(),
+				ValidTo:   time.Unix(t.ValidTimeTo, 0).UTC(),
+				Raw:       t.RawTAF,
+				Lat:       t.Lat,
+				Lon:       t.Lon,
+				Source:    "awc",
+				StoredAt:  now,
+			})
+		}
+		if err := mc.BulkUpsertTafHistory(ctx, tdocs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fetchAWCJSON(ctx context.Context, cfg config.Config, resource, ids string, hours int, out any) error {
+	q := url.Values{}
+	q.Set("ids", ids)
+	if hours > 0 {
+		q.Set("hours", fmt.Sprintf("%d", hours))
+	}
+	return loadAWCFeed(ctx, cfg, resource, q, out)
+}
@@ -67,3 +67,44 @@ func (c *Client) BulkUpsertAirports(ctx context.Context, docs []AirportDoc) erro
 	_, err := col.BulkWrite(ctx, writes, opts)
 	return err
 }
+
+// StationCoords maps station codes to [lon, lat] from airports.location,
+// matching icao_code, then gps_code, then ident.
+func (c *Client) StationCoords(ctx context.Context, codes []string) (map[string][2]float64, error) {
+	out := map[string][2]float64{}
+	if len(codes) == 0 {
+		return out, nil
+	}
+	cur, err := c.DB.Collection("airports").Find(ctx,
+		bson.M{"$or": []bson.M{{"icao_code": bson.M{"$in": codes}}, {"gps_code": bson.M{"$in": codes}}, {"ident": bson.M{"$in": codes}}}},
+		options.Find().SetProjection(bson.M{"_id": 0, "icao_code": 1, "gps_code": 1, "ident": 1, "location": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var docs []struct {
+		Ident    string `bson:"ident"`
+		GPSCode  string `bson:"gps_code"`
+		IcaoCode string `bson:"icao_code"`
+		Location struct {
+			Coordinates []float64 `bson:"coordinates"`
+		} `bson:"location"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	want := map[string]bool{}
+	for _, c := range codes {
+		want[c] = true
+	}
+	// کم‌اولویت‌ترها اول، تا icao_code در آخر بازنویسی کند
+	for pass := 0; pass < 3; pass++ {
+		for _, d := range docs {
+			code := [3]string{d.Ident, d.GPSCode, d.IcaoCode}[pass]
+			if want[code] && len(d.Location.Coordinates) == 2 {
+				out[code] = [2]float64{d.Location.Coordinates[0], d.Location.Coordinates[1]}
+			}
+		}
+	}
+	return out, nil
+}
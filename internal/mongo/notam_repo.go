@@ -0,0 +1,258 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"SepTaf/internal/notam"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NOTAM lifecycle status
+const (
+	NotamActive       = "active"
+	NotamReplaced     = "replaced"     // superseded by a NOTAMR
+	NotamCancelled    = "cancelled"    // superseded by a NOTAMC
+	NotamCancellation = "cancellation" // a NOTAMC itself; never part of the current set
+)
+
+// ===== NOTAMs =====
+// کلید: location + notam_id (سری/شماره/سال)
+type NotamDoc struct {
+	Location       string     `bson:"location"                  json:"location"` // icaoLocation / item A
+	NotamID        string     `bson:"notam_id"                  json:"notam_id"` // A1234/25
+	Series         string     `bson:"series,omitempty"          json:"series,omitempty"`
+	Number         int        `bson:"number,omitempty"          json:"number,omitempty"`
+	Year           int        `bson:"year,omitempty"            json:"year,omitempty"`
+	Type           string     `bson:"type"                      json:"type"`          // N | R | C
+	Ref            string     `bson:"ref,omitempty"             json:"ref,omitempty"` // replaced/cancelled NOTAM
+	Status         string     `bson:"status,omitempty"          json:"status"`
+	SupersededBy   string     `bson:"superseded_by,omitempty"   json:"superseded_by,omitempty"`
+	SupersededAt   *time.Time `bson:"superseded_at,omitempty"   json:"superseded_at,omitempty"`
+	FAAID          string     `bson:"faa_id,omitempty"          json:"faa_id,omitempty"`
+	Classification string     `bson:"classification,omitempty"  json:"classification,omitempty"`
+	AffectedFIR    string     `bson:"affected_fir,omitempty"    json:"affected_fir,omitempty"`
+	Issued         *time.Time `bson:"issued,omitempty"          json:"issued,omitempty"`
+	EffectiveStart *time.Time `bson:"effective_start,omitempty" json:"effective_start,omitempty"`
+	EffectiveEnd   *time.Time `bson:"effective_end,omitempty"   json:"effective_end,omitempty"` // nil: PERM or unknown
+	Permanent      bool       `bson:"permanent"                 json:"permanent"`
+	Estimated      bool       `bson:"estimated"                 json:"estimated"`          // EST end: in force until replaced/cancelled
+	Schedule       string     `bson:"schedule,omitempty"        json:"schedule,omitempty"` // item D
+	Text           string     `bson:"text"                      json:"text"`
+	// area from item E or the Q-line circle (2dsphere) and the Q-line FL band
+	Geometry       *notam.Geometry `bson:"geometry,omitempty"        json:"geometry,omitempty"`
+	GeometrySource string          `bson:"geometry_source,omitempty" json:"geometry_source,omitempty"`
+	LowerFL        *int            `bson:"lower_fl,omitempty"        json:"lower_fl,omitempty"`
+	UpperFL        *int            `bson:"upper_fl,omitempty"        json:"upper_fl,omitempty"`
+	Decoded        *notam.NOTAM    `bson:"decoded,omitempty"         json:"decoded,omitempty"`
+	FirstSeen      time.Time       `bson:"first_seen,omitempty"      json:"first_seen"`
+	LastSeen       time.Time       `bson:"last_seen"                 json:"last_seen"`
+	// item D could not be read, so only B/C were applied (response only)
+	ScheduleUnparsed bool `bson:"-" json:"schedule_unparsed,omitempty"`
+}
+
+func (c *Client) NotamsCol() *mongo.Collection { return c.DB.Collection("notams") }
+
+func (c *Client) EnsureNotamIndexes(ctx context.Context) error {
+	_, err := c.NotamsCol().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "location", Value: 1}, {Key: "notam_id", Value: 1}},
+			Options: options.Index().SetName("uniq_location_notam").SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "location", Value: 1}, {Key: "effective_end", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "affected_fir", Value: 1}}},
+		{Keys: bson.D{{Key: "location", Value: 1}, {Key: "ref", Value: 1}}},
+		{Keys: bson.D{{Key: "geometry", Value: "2dsphere"}}},
+	})
+	return err
+}
+
+// BulkUpsertNotams stores NOTAMs and applies NOTAMR/NOTAMC supersession,
+// in both arrival orders: a replacement seen before its predecessor still
+// marks the predecessor once that is stored.
+func (c *Client) BulkUpsertNotams(ctx context.Context, docs []NotamDoc) error {
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	kept := make([]NotamDoc, 0, len(docs)) // kept[i] is writes[i]
+	for _, d := range docs {
+		if d.Location == "" || d.NotamID == "" {
+			continue
+		}
+		kept = append(kept, d)
+		status := NotamActive
+		if d.Type == "C" {
+			status = NotamCancellation
+		}
+		first := d.LastSeen
+		// وضعیت فقط در insert؛ بعدا فقط با supersede عوض می‌شود
+		d.Status, d.SupersededBy, d.SupersededAt, d.FirstSeen = "", "", nil, time.Time{}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"location": d.Location, "notam_id": d.NotamID}).
+			SetUpdate(bson.M{
+				"$set":         d,
+				"$setOnInsert": bson.M{"status": status, "first_seen": first},
+			}).
+			SetUpsert(true))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	res, err := c.NotamsCol().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	var bwe mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bwe) {
+		return err
+	}
+	if res != nil {
+		log.Printf(`{"msg":"notams-bulkwrite","matched":%d,"upserted":%d,"failed":%d}`, res.MatchedCount, res.UpsertedCount, len(bwe.WriteErrors))
+	}
+	// unordered: the writes that did not fail are stored and still get linked
+	if len(bwe.WriteErrors) > 0 {
+		failed := make(map[int]bool, len(bwe.WriteErrors))
+		for _, we := range bwe.WriteErrors {
+			failed[we.Index] = true
+		}
+		stored := kept[:0:0]
+		for i, d := range kept {
+			if !failed[i] {
+				stored = append(stored, d)
+			}
+		}
+		kept = stored
+	}
+	if lerr := c.linkNotams(ctx, kept); lerr != nil {
+		return errors.Join(err, lerr)
+	}
+	return err
+}
+
+func (c *Client) linkNotams(ctx context.Context, docs []NotamDoc) error {
+	// successors in this batch, plus stored successors of NOTAMs in this batch
+	succ := []NotamDoc{}
+	var or []bson.M
+	for _, d := range docs {
+		if d.Ref != "" && (d.Type == "R" || d.Type == "C") {
+			succ = append(succ, d)
+		}
+		if d.Type != "C" {
+			or = append(or, bson.M{"location": d.Location, "ref": d.NotamID})
+		}
+	}
+	if len(or) > 0 {
+		cur, err := c.NotamsCol().Find(ctx, bson.M{"$or": or, "type": bson.M{"$in": []string{"R", "C"}}},
+			options.Find().SetProjection(bson.M{"location": 1, "notam_id": 1, "type": 1, "ref": 1, "issued": 1, "last_seen": 1}))
+		if err != nil {
+			return err
+		}
+		var stored []NotamDoc
+		if err := cur.All(ctx, &stored); err != nil {
+			return err
+		}
+		succ = append(succ, stored...)
+	}
+	if len(succ) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(succ))
+	for _, s := range succ {
+		status := NotamReplaced
+		if s.Type == "C" {
+			status = NotamCancelled
+		}
+		at := s.Issued
+		if at == nil {
+			at = &s.LastSeen
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"location": s.Location, "notam_id": s.Ref}).
+			SetUpdate(bson.M{"$set": bson.M{"status": status, "superseded_by": s.NotamID, "superseded_at": at}}))
+	}
+	_, err := c.NotamsCol().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// CurrentNotams returns the NOTAMs valid at `at`: issued by then, not yet
+// replaced or cancelled at that time, not a NOTAMC, started and not yet
+// ended (an EST end does not end it). Item-D schedules are evaluated by the
+// caller.
+func (c *Client) CurrentNotams(ctx context.Context, filter bson.M, at time.Time) ([]NotamDoc, error) {
+	f := bson.M{
+		"type": bson.M{"$ne": "C"},
+		"$and": []bson.M{
+			// برای at گذشته: NOTAMی که بعدا جایگزین شده هنوز معتبر بوده
+			{"$or": []bson.M{{"status": NotamActive}, {"superseded_at": bson.M{"$gt": at}}}},
+			{"$or": []bson.M{{"issued": nil}, {"issued": bson.M{"$lte": at}}}},
+			{"$or": []bson.M{{"effective_start": nil}, {"effective_start": bson.M{"$lte": at}}}},
+			{"$or": []bson.M{{"effective_end": nil}, {"effective_end": bson.M{"$gt": at}}, {"estimated": true}}},
+		},
+	}
+	if len(filter) > 0 {
+		f = bson.M{"$and": []bson.M{f, filter}}
+	}
+	cur, err := c.NotamsCol().Find(ctx, f, options.Find().
+		SetProjection(bson.M{"_id": 0}).
+		SetSort(bson.D{{Key: "location", Value: 1}, {Key: "effective_start", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	out := []NotamDoc{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NotamLineage follows ref back to the original NOTAM and superseded_by
+// forward to the latest one; the result is oldest first.
+func (c *Client) NotamLineage(ctx context.Context, location, id string) ([]NotamDoc, error) {
+	const maxSteps = 50
+	get := func(f bson.M) (*NotamDoc, error) {
+		var d NotamDoc
+		err := c.NotamsCol().FindOne(ctx, f, options.FindOne().SetProjection(bson.M{"_id": 0})).Decode(&d)
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return &d, err
+	}
+	start, err := get(bson.M{"location": location, "notam_id": id})
+	if err != nil || start == nil {
+		return nil, err
+	}
+
+	var back []NotamDoc
+	seen := map[string]bool{start.NotamID: true}
+	for cur := start; cur.Ref != "" && len(back) < maxSteps; {
+		prev, err := get(bson.M{"location": location, "notam_id": cur.Ref})
+		if err != nil {
+			return nil, err
+		}
+		if prev == nil || seen[prev.NotamID] {
+			break
+		}
+		seen[prev.NotamID] = true
+		back = append(back, *prev)
+		cur = prev
+	}
+	out := make([]NotamDoc, 0, len(back)+1)
+	for i := len(back) - 1; i >= 0; i-- {
+		out = append(out, back[i])
+	}
+	out = append(out, *start)
+
+	for cur := start; cur.SupersededBy != "" && len(out) < 2*maxSteps; {
+		next, err := get(bson.M{"location": location, "notam_id": cur.SupersededBy})
+		if err != nil {
+			return nil, err
+		}
+		if next == nil || seen[next.NotamID] {
+			break
+		}
+		seen[next.NotamID] = true
+		out = append(out, *next)
+		cur = next
+	}
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"SepTaf/internal/wx/pirep"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PirepDoc is a decoded pilot report with its position as a GeoJSON point.
+type PirepDoc struct {
+	Key          string        `bson:"key"                     json:"key"` // icao|obs_time|aircraft|raw-hash
+	ICAO         string        `bson:"icao,omitempty"          json:"icao,omitempty"`
+	Kind         string        `bson:"kind"                    json:"kind"` // PIREP | UUA | AIREP
+	ObsTime      time.Time     `bson:"obs_time"                json:"obs_time"`
+	AircraftType string        `bson:"aircraft_type,omitempty" json:"aircraft_type,omitempty"`
+	FlightLevel  *int          `bson:"flight_level,omitempty"  json:"flight_level,omitempty"`
+	Lat          float64       `bson:"lat"                     json:"lat"`
+	Lon          float64       `bson:"lon"                     json:"lon"`
+	Location     any           `bson:"location"                json:"location"` // GeoJSON Point
+	TurbMax      string        `bson:"turb_max,omitempty"      json:"turb_max,omitempty"`
+	TurbRank     int           `bson:"turb_rank"               json:"-"` // pirep.IntensityRank، برای فیلتر
+	IcingMax     string        `bson:"icing_max,omitempty"     json:"icing_max,omitempty"`
+	IcingRank    int           `bson:"icing_rank"              json:"-"`
+	Raw          string        `bson:"raw"                     json:"raw"`
+	Decoded      *pirep.Report `bson:"decoded,omitempty"       json:"decoded,omitempty"`
+	StoredAt     time.Time     `bson:"stored_at"               json:"stored_at"`
+}
+
+func (c *Client) PirepsCol() *mongo.Collection { return c.DB.Collection("pireps") }
+
+func (c *Client) EnsurePirepIndexes(ctx context.Context, retention time.Duration) error {
+	_, err := c.PirepsCol().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetName("uniq_key").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "location", Value: "2dsphere"}, {Key: "obs_time", Value: -1}},
+			Options: options.Index().SetName("geo_location_time"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return ensureTTLIndex(ctx, c.PirepsCol(), "ttl_obs_time", "obs_time", retention)
+}
+
+func (c *Client) BulkUpsertPireps(ctx context.Context, docs []PirepDoc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	for _, d := range docs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"key": d.Key}).
+			SetUpdate(bson.M{"$setOnInsert": d}).
+			SetUpsert(true))
+	}
+	res, err := c.PirepsCol().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return err
+	}
+	log.Printf(`{"msg":"pirep-bulkwrite","matched":%d,"upserted":%d}`, res.MatchedCount, res.UpsertedCount)
+	return nil
+}
@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RunwayDoc is one row of OurAirports runways.csv. le_* is the low end,
+// he_* the high end; headings are true.
+type RunwayDoc struct {
+	IDCSV        *int     `bson:"id_csv,omitempty"`
+	AirportIdent string   `bson:"airport_ident"` // = airports.ident
+	LengthFt     *int     `bson:"length_ft,omitempty"`
+	WidthFt      *int     `bson:"width_ft,omitempty"`
+	Surface      string   `bson:"surface,omitempty"`
+	Lighted      bool     `bson:"lighted"`
+	Closed       bool     `bson:"closed"`
+	LeIdent      string   `bson:"le_ident,omitempty"`
+	LeHeadingT   *float64 `bson:"le_heading_deg_t,omitempty"`
+	HeIdent      string   `bson:"he_ident,omitempty"`
+	HeHeadingT   *float64 `bson:"he_heading_deg_t,omitempty"`
+}
+
+func (c *Client) RunwaysCol() *mongo.Collection { return c.DB.Collection("runways") }
+
+func (c *Client) EnsureRunwayIndexes(ctx context.Context) error {
+	_, err := c.RunwaysCol().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id_csv", Value: 1}}},
+		{Keys: bson.D{{Key: "airport_ident", Value: 1}}},
+	})
+	return err
+}
+
+func (c *Client) BulkUpsertRunways(ctx context.Context, docs []RunwayDoc) error {
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	for _, d := range docs {
+		if d.IDCSV == nil || d.AirportIdent == "" {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"id_csv": *d.IDCSV}).
+			SetUpdate(bson.M{"$set": d}).
+			SetUpsert(true))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	res, err := c.RunwaysCol().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return err
+	}
+	log.Printf(`{"msg":"runways-bulkwrite","matched":%d,"upserted":%d}`, res.MatchedCount, res.UpsertedCount)
+	return nil
+}
+
+// RunwaysByAirport groups the runways of the given airport idents.
+func (c *Client) RunwaysByAirport(ctx context.Context, idents []string) (map[string][]RunwayDoc, error) {
+	out := map[string][]RunwayDoc{}
+	if len(idents) == 0 {
+		return out, nil
+	}
+	cur, err := c.RunwaysCol().Find(ctx, bson.M{"airport_ident": bson.M{"$in": idents}},
+		options.Find().SetProjection(bson.M{"_id": 0}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var docs []RunwayDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		out[d.AirportIdent] = append(out[d.AirportIdent], d)
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SigmetDoc is an active SIGMET/AIRMET advisory with its polygon.
+type SigmetDoc struct {
+	Key       string    `bson:"key"                  json:"key"`  // source|icao|series|valid_from
+	Kind      string    `bson:"kind"                 json:"kind"` // SIGMET | AIRMET | OUTLOOK ...
+	Source    string    `bson:"source"               json:"source"`
+	ICAOId    string    `bson:"icao_id,omitempty"    json:"icao_id,omitempty"` // issuing MWO
+	FIRId     string    `bson:"fir_id,omitempty"     json:"fir_id,omitempty"`
+	FIRName   string    `bson:"fir_name,omitempty"   json:"fir_name,omitempty"`
+	SeriesID  string    `bson:"series_id,omitempty"  json:"series_id,omitempty"`
+	Hazard    string    `bson:"hazard,omitempty"     json:"hazard,omitempty"` // TS, TURB, ICE, VA, ...
+	Qualifier string    `bson:"qualifier,omitempty"  json:"qualifier,omitempty"`
+	Severity  string    `bson:"severity,omitempty"   json:"severity,omitempty"`
+	BaseFt    *int      `bson:"base_ft,omitempty"    json:"base_ft,omitempty"`
+	TopFt     *int      `bson:"top_ft,omitempty"     json:"top_ft,omitempty"`
+	ValidFrom time.Time `bson:"valid_from"           json:"valid_from"`
+	ValidTo   time.Time `bson:"valid_to"             json:"valid_to"`
+	Raw       string    `bson:"raw,omitempty"        json:"raw,omitempty"`
+	Geometry  any       `bson:"geometry,omitempty"   json:"geometry,omitempty"` // GeoJSON Polygon
+	UpdatedAt time.Time `bson:"updated_at"           json:"updated_at"`
+}
+
+func (c *Client) SigmetsCol() *mongo.Collection { return c.DB.Collection("sigmets") }
+
+func (c *Client) EnsureSigmetIndexes(ctx context.Context) error {
+	_, err := c.SigmetsCol().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetName("uniq_key").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "geometry", Value: "2dsphere"}},
+			Options: options.Index().SetName("geo_geometry"),
+		},
+		{Keys: bson.D{{Key: "fir_id", Value: 1}, {Key: "valid_to", Value: 1}}},
+		{
+			// منقضی‌ها خودکار حذف می‌شوند؛ فقط advisoryهای فعال می‌مانند
+			Keys:    bson.D{{Key: "valid_to", Value: 1}},
+			Options: options.Index().SetName("ttl_valid_to").SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// BulkUpsertSigmets upserts by key. Invalid polygons are rejected by the
+// 2dsphere index; those are logged and skipped instead of failing the batch.
+func (c *Client) BulkUpsertSigmets(ctx context.Context, docs []SigmetDoc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	for _, d := range docs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"key": d.Key}).
+			SetUpdate(bson.M{"$set": d}).
+			SetUpsert(true))
+	}
+	res, err := c.SigmetsCol().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		var bwe mongo.BulkWriteException
+		if !errors.As(err, &bwe) || bwe.WriteConcernError != nil || len(bwe.WriteErrors) == 0 {
+			return err
+		}
+		log.Printf(`{"lvl":"warn","msg":"sigmet-bulkwrite skipped","count":%d,"first":%q}`, len(bwe.WriteErrors), bwe.WriteErrors[0].Message)
+	}
+	if res != nil {
+		log.Printf(`{"msg":"sigmet-bulkwrite","matched":%d,"upserted":%d}`, res.MatchedCount, res.UpsertedCount)
+	}
+	return nil
+}
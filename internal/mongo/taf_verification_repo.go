@@ -0,0 +1,60 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"SepTaf/internal/wx/verify"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ===== TAF verification =====
+// یک سند برای هر TAF بعد از پایان اعتبارش
+type TafVerificationDoc struct {
+	ICAO       string             `bson:"icao"        json:"icao"`
+	IssueTime  time.Time          `bson:"issue_time"  json:"issue_time"`
+	ValidFrom  time.Time          `bson:"valid_from"  json:"valid_from"`
+	ValidTo    time.Time          `bson:"valid_to"    json:"valid_to"`
+	Until      time.Time          `bson:"until"       json:"until"` // valid_to, or when the next TAF superseded it
+	Rules      string             `bson:"rules"       json:"rules"` // flight category rules used
+	Total      verify.Score       `bson:"total"       json:"total"`
+	ByLead     []verify.LeadScore `bson:"by_lead"     json:"by_lead"`
+	VerifiedAt time.Time          `bson:"verified_at" json:"verified_at"`
+}
+
+func (c *Client) TafVerificationCol() *mongo.Collection { return c.DB.Collection("taf_verification") }
+
+func (c *Client) EnsureTafVerificationIndexes(ctx context.Context, retention time.Duration) error {
+	_, err := c.TafVerificationCol().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "icao", Value: 1}, {Key: "issue_time", Value: 1}},
+			Options: options.Index().SetName("uniq_icao_issue").SetUnique(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return ensureTTLIndex(ctx, c.TafVerificationCol(), "ttl_issue_time", "issue_time", retention)
+}
+
+func (c *Client) BulkUpsertTafVerification(ctx context.Context, docs []TafVerificationDoc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	for _, d := range docs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"icao": d.ICAO, "issue_time": d.IssueTime}).
+			SetUpdate(bson.M{"$set": d}).
+			SetUpsert(true))
+	}
+	res, err := c.TafVerificationCol().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return err
+	}
+	log.Printf(`{"msg":"taf-verification-bulkwrite","matched":%d,"upserted":%d}`, res.MatchedCount, res.UpsertedCount)
+	return nil
+}
@@ -0,0 +1,55 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureTTLIndex creates a TTL index on field, or brings an existing one
+// to the configured retention. Recreating it under the same name with
+// another expireAfterSeconds fails with IndexOptionsConflict, so a changed
+// retention is applied with collMod instead.
+func ensureTTLIndex(ctx context.Context, col *mongo.Collection, name, field string, retention time.Duration) error {
+	secs := int32(retention.Seconds())
+	cur, err := col.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	var idx []struct {
+		Name               string `bson:"name"`
+		ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+	}
+	if err := cur.All(ctx, &idx); err != nil {
+		return err
+	}
+	for _, ix := range idx {
+		if ix.Name != name {
+			continue
+		}
+		if ix.ExpireAfterSeconds == nil {
+			// ایندکس معمولی با همین نام؛ collMod فقط روی TTL کار می‌کند
+			if _, err := col.Indexes().DropOne(ctx, name); err != nil {
+				return err
+			}
+			break
+		}
+		if *ix.ExpireAfterSeconds == int64(secs) {
+			return nil
+		}
+		log.Printf(`{"msg":"ttl-index-update","collection":%q,"index":%q,"from":%d,"to":%d}`, col.Name(), name, *ix.ExpireAfterSeconds, secs)
+		return col.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: col.Name()},
+			{Key: "index", Value: bson.D{{Key: "name", Value: name}, {Key: "expireAfterSeconds", Value: secs}}},
+		}).Err()
+	}
+	_, err = col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetName(name).SetExpireAfterSeconds(secs),
+	})
+	return err
+}
@@ -0,0 +1,83 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"SepTaf/internal/watch"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ===== Watch rules =====
+// قانون هشدار هر APIClient؛ وضعیت آخرین ارزیابی هم همین‌جا نگه داشته می‌شود
+type WatchRuleDoc struct {
+	ID         string `bson:"id"          json:"id"`
+	ClientID   string `bson:"client_id"   json:"client_id"`
+	watch.Rule `bson:",inline"`
+	WebhookURL string `bson:"webhook_url"    json:"webhook_url"`
+	Note       string `bson:"note,omitempty" json:"note,omitempty"`
+	// state
+	Matching      bool          `bson:"matching"                  json:"matching"`
+	LastResult    *watch.Result `bson:"last_result,omitempty"     json:"last_result,omitempty"`
+	LastEvaluated time.Time     `bson:"last_evaluated,omitempty"  json:"last_evaluated,omitempty"`
+	LastChange    time.Time     `bson:"last_change,omitempty"     json:"last_change,omitempty"`
+	LastDelivery  time.Time     `bson:"last_delivery,omitempty"   json:"last_delivery,omitempty"`
+	LastError     string        `bson:"last_error,omitempty"      json:"last_error,omitempty"`
+	CreatedAt     time.Time     `bson:"created_at"                json:"created_at"`
+}
+
+func (c *Client) WatchRulesCol() *mongo.Collection { return c.DB.Collection("watch_rules") }
+
+func (c *Client) EnsureWatchIndexes(ctx context.Context) error {
+	_, err := c.WatchRulesCol().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("uniq_id").SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "client_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	return err
+}
+
+func (c *Client) ListWatchRules(ctx context.Context, clientID string) ([]WatchRuleDoc, error) {
+	filter := bson.M{}
+	if clientID != "" {
+		filter["client_id"] = clientID
+	}
+	cur, err := c.WatchRulesCol().Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"_id": 0}).SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	out := []WatchRuleDoc{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) CountWatchRules(ctx context.Context, clientID string) (int64, error) {
+	return c.WatchRulesCol().CountDocuments(ctx, bson.M{"client_id": clientID})
+}
+
+func (c *Client) InsertWatchRule(ctx context.Context, d WatchRuleDoc) error {
+	_, err := c.WatchRulesCol().InsertOne(ctx, d)
+	return err
+}
+
+// DeleteWatchRule removes a rule owned by clientID; false if there was none.
+func (c *Client) DeleteWatchRule(ctx context.Context, clientID, id string) (bool, error) {
+	res, err := c.WatchRulesCol().DeleteOne(ctx, bson.M{"client_id": clientID, "id": id})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// SetWatchState stores the outcome of one poll for a rule.
+func (c *Client) SetWatchState(ctx context.Context, id string, set bson.M) error {
+	_, err := c.WatchRulesCol().UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": set})
+	return err
+}
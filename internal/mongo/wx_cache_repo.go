@@ -0,0 +1,38 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WXCacheDoc is one cached upstream weather response (shared tier).
+type WXCacheDoc struct {
+	Key       string    `bson:"_id"`
+	Body      []byte    `bson:"body"`
+	Status    int       `bson:"status"`
+	FetchedAt time.Time `bson:"fetched_at"`
+}
+
+func (c *Client) wxCacheCol() *mongo.Collection { return c.DB.Collection("wx_cache") }
+
+// retention: بعد از این مدت Mongo خودش سند را پاک می‌کند
+func (c *Client) EnsureWXCacheIndexes(ctx context.Context, retention time.Duration) error {
+	return ensureTTLIndex(ctx, c.wxCacheCol(), "ttl_fetched_at", "fetched_at", retention)
+}
+
+func (c *Client) GetWXCache(ctx context.Context, key string) (*WXCacheDoc, error) {
+	var out WXCacheDoc
+	if err := c.wxCacheCol().FindOne(ctx, bson.M{"_id": key}).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) PutWXCache(ctx context.Context, d WXCacheDoc) error {
+	_, err := c.wxCacheCol().ReplaceOne(ctx, bson.M{"_id": d.Key}, d, options.Replace().SetUpsert(true))
+	return err
+}
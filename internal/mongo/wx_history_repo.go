@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ===== METAR history =====
+type MetarHistoryDoc struct {
+	ICAO       string    `bson:"icao"                  json:"icao"`
+	ObsTime    time.Time `bson:"obs_time"              json:"obs_time"`
+	ReportType string    `bson:"report_type,omitempty" json:"report_type,omitempty"` // METAR | SPECI
+	Raw        string    `bson:"raw"                   json:"raw"`
+	FltCat     string    `bson:"flt_cat,omitempty"     json:"flt_cat,omitempty"`
+	Lat        float64   `bson:"lat,omitempty"         json:"lat,omitempty"`
+	Lon        float64   `bson:"lon,omitempty"         json:"lon,omitempty"`
+	Source     string    `bson:"source,omitempty"      json:"source,omitempty"` // "awc" | "gts"
+	StoredAt   time.Time `bson:"stored_at"             json:"stored_at"`
+}
+
+// ===== TAF history =====
+type TafHistoryDoc struct {
+	ICAO      string    `bson:"icao"             json:"icao"`
+	IssueTime time.Time `bson:"issue_time"       json:"issue_time"`
+	ValidFrom time.Time `bson:"valid_from"       json:"valid_from"`
+	ValidTo   time.Time `bson:"valid_to"         json:"valid_to"`
+	Raw       string    `bson:"raw"              json:"raw"`
+	Lat       float64   `bson:"lat,omitempty"    json:"lat,omitempty"`
+	Lon       float64   `bson:"lon,omitempty"    json:"lon,omitempty"`
+	Source    string    `bson:"source,omitempty" json:"source,omitempty"`
+	StoredAt  time.Time `bson:"stored_at"        json:"stored_at"`
+}
+
+func (c *Client) MetarHistoryCol() *mongo.Collection { return c.DB.Collection("metar_history") }
+func (c *Client) TafHistoryCol() *mongo.Collection   { return c.DB.Collection("taf_history") }
+
+// retention: TTL روی زمان مشاهده/صدور، نه زمان ذخیره
+func (c *Client) EnsureWXHistoryIndexes(ctx context.Context, retention time.Duration) error {
+	_, err := c.MetarHistoryCol().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "icao", Value: 1}, {Key: "obs_time", Value: 1}, {Key: "report_type", Value: 1}},
+		Options: options.Index().SetName("uniq_icao_obs").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	if err := ensureTTLIndex(ctx, c.MetarHistoryCol(), "ttl_obs_time", "obs_time", retention); err != nil {
+		return err
+	}
+	_, err = c.TafHistoryCol().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "icao", Value: 1}, {Key: "issue_time", Value: 1}},
+			Options: options.Index().SetName("uniq_icao_issue").SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "icao", Value: 1}, {Key: "valid_from", Value: 1}, {Key: "valid_to", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	return ensureTTLIndex(ctx, c.TafHistoryCol(), "ttl_issue_time", "issue_time", retention)
+}
+
+func (c *Client) BulkUpsertMetarHistory(ctx context.Context, docs []MetarHistoryDoc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	for _, d := range docs {
+		if d.ICAO == "" || d.ObsTime.IsZero() {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"icao": d.ICAO, "obs_time": d.ObsTime, "report_type": d.ReportType}).
+			SetUpdate(bson.M{"$set": d}).
+			SetUpsert(true))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	res, err := c.MetarHistoryCol().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return err
+	}
+	log.Printf(`{"msg":"metar-history-bulkwrite","matched":%d,"upserted":%d}`, res.MatchedCount, res.UpsertedCount)
+	return nil
+}
+
+func (c *Client) BulkUpsertTafHistory(ctx context.Context, docs []TafHistoryDoc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	for _, d := range docs {
+		if d.ICAO == "" || d.IssueTime.IsZero() {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"icao": d.ICAO, "issue_time": d.IssueTime}).
+			SetUpdate(bson.M{"$set": d}).
+			SetUpsert(true))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	res, err := c.TafHistoryCol().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return err
+	}
+	log.Printf(`{"msg":"taf-history-bulkwrite","matched":%d,"upserted":%d}`, res.MatchedCount, res.UpsertedCount)
+	return nil
+}
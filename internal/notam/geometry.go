@@ -0,0 +1,201 @@
+package notam
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+
+	"SepTaf/internal/geo"
+)
+
+// Geometry is a GeoJSON Polygon ([lon,lat] rings), usable with 2dsphere.
+type Geometry struct {
+	Type        string        `json:"type"        bson:"type"`
+	Coordinates [][][]float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// geometry sources
+const (
+	GeomItemE = "item_e" // polygon or circle described in item E
+	GeomQLine = "q_line" // Q-line centre and radius
+)
+
+const circleVertices = 36
+
+var (
+	// 354500N0512000E, 3545N05120E, 354500.50N0512000.25E
+	eCoordRe  = regexp.MustCompile(`\b(\d{2})(\d{2})(\d{2}(?:\.\d+)?)?([NS])\s?(\d{3})(\d{2})(\d{2}(?:\.\d+)?)?([EW])\b`)
+	eRadiusRe = regexp.MustCompile(`\bRADIUS\s+(?:OF\s+)?(\d+(?:\.\d+)?)\s*(NM|KM|M)\b`)
+	// a list of obstacles also has coordinates; an area is WI/AREA/... or points joined by "-"
+	eAreaRe = regexp.MustCompile(`\b(?:WI|AREA|BOUNDED|POLYGON)\b|[NSEW]\s*-\s*\d`)
+)
+
+// Geometry builds the NOTAM's area: an item-E polygon (three or more
+// coordinates), an item-E circle (RADIUS ... and one centre), else the
+// Q-line circle. It returns nil when none of these is available or valid.
+func (n *NOTAM) Geometry() (*Geometry, string) {
+	if g := itemEGeometry(n.Text); g != nil {
+		return g, GeomItemE
+	}
+	if q := n.Q; q != nil && q.Lat != nil && q.Lon != nil && q.RadiusNM != nil {
+		r := float64(*q.RadiusNM)
+		if r < 1 {
+			r = 1 // 000 = کمتر از یک مایل
+		}
+		return Circle(geo.Point{Lat: *q.Lat, Lon: *q.Lon}, r), GeomQLine
+	}
+	return nil, ""
+}
+
+func itemEGeometry(text string) *Geometry {
+	ms := eCoordRe.FindAllStringSubmatch(text, -1)
+	pts := make([]geo.Point, 0, len(ms))
+	for _, m := range ms {
+		lat := dms(m[1], m[2], m[3], m[4] == "S")
+		lon := dms(m[5], m[6], m[7], m[8] == "W")
+		if math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+			return nil
+		}
+		pts = append(pts, geo.Point{Lat: lat, Lon: lon})
+	}
+
+	if r := eRadiusRe.FindStringSubmatch(text); r != nil && len(pts) == 1 {
+		v, _ := strconv.ParseFloat(r[1], 64)
+		switch r[2] {
+		case "KM":
+			v = v * 1000 / geo.MetersPerNM
+		case "M":
+			v = v / geo.MetersPerNM
+		}
+		if v <= 0 || v > 999 {
+			return nil
+		}
+		return Circle(pts[0], v)
+	}
+	if len(pts) < 3 || !eAreaRe.MatchString(text) {
+		return nil
+	}
+
+	ring := make([][]float64, 0, len(pts)+1)
+	for _, p := range pts {
+		c := []float64{p.Lon, p.Lat}
+		if k := len(ring); k > 0 && ring[k-1][0] == c[0] && ring[k-1][1] == c[1] {
+			continue
+		}
+		ring = append(ring, c)
+	}
+	if first, last := ring[0], ring[len(ring)-1]; first[0] != last[0] || first[1] != last[1] {
+		ring = append(ring, first)
+	}
+	// 2dsphere حلقه‌ی خودمتقاطع یا تباه‌شده را رد می‌کند
+	if len(ring) < 4 || degenerate(ring) || selfIntersects(ring) {
+		return nil
+	}
+	return &Geometry{Type: "Polygon", Coordinates: [][][]float64{ring}}
+}
+
+// Circle approximates a circle of radiusNM around c by a polygon.
+func Circle(c geo.Point, radiusNM float64) *Geometry {
+	ring := make([][]float64, 0, circleVertices+1)
+	for i := 0; i < circleVertices; i++ {
+		p := geo.Destination(c, float64(i)*360/circleVertices, radiusNM)
+		ring = append(ring, []float64{round6(p.Lon), round6(p.Lat)})
+	}
+	ring = append(ring, ring[0])
+	return &Geometry{Type: "Polygon", Coordinates: [][][]float64{ring}}
+}
+
+func dms(d, m, s string, neg bool) float64 {
+	deg, _ := strconv.Atoi(d)
+	min, _ := strconv.Atoi(m)
+	sec, _ := strconv.ParseFloat(s, 64)
+	if min > 59 || sec >= 60 {
+		return math.Inf(1)
+	}
+	v := float64(deg) + float64(min)/60 + sec/3600
+	if neg {
+		v = -v
+	}
+	return round6(v)
+}
+
+func round6(v float64) float64 { return math.Round(v*1e6) / 1e6 }
+
+// ringTol is the planar tolerance in degrees (about 1 m) for a point
+// lying on a line; item-E coordinates are rounded to 1e-6°.
+const ringTol = 1e-5
+
+// selfIntersects checks non-adjacent edges of a closed ring for crossings
+// or contact (planar; NOTAM polygons are small enough).
+func selfIntersects(ring [][]float64) bool {
+	n := len(ring) - 1
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			if i == 0 && j == n-1 {
+				continue // first and last edge share the closing vertex
+			}
+			if segmentsMeet(ring[i], ring[i+1], ring[j], ring[j+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// degenerate reports the rings 2dsphere rejects although no two edges
+// cross: a repeated vertex, fewer than three distinct vertices, zero area
+// (all points on one line) or an edge doubling back along the previous one.
+func degenerate(ring [][]float64) bool {
+	n := len(ring) - 1
+	seen := make(map[[2]float64]bool, n)
+	for _, p := range ring[:n] {
+		k := [2]float64{p[0], p[1]}
+		if seen[k] {
+			return true
+		}
+		seen[k] = true
+	}
+	if n < 3 {
+		return true
+	}
+	// zero area: the ring is thinner than ringTol everywhere
+	var area, perimeter float64
+	for i := 0; i < n; i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+		perimeter += math.Hypot(ring[i+1][0]-ring[i][0], ring[i+1][1]-ring[i][1])
+	}
+	if math.Abs(area) < ringTol*perimeter {
+		return true
+	}
+	for i := 0; i < n; i++ {
+		p, q, r := ring[(i+n-1)%n], ring[i], ring[i+1]
+		dot := (p[0]-q[0])*(r[0]-q[0]) + (p[1]-q[1])*(r[1]-q[1])
+		if math.Abs(side(q, p, r)) < ringTol && dot > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// side is the signed distance of p from the line through a and b.
+func side(a, b, p []float64) float64 {
+	return ((b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])) / math.Hypot(b[0]-a[0], b[1]-a[1])
+}
+
+// segmentsMeet reports whether ab and cd cross or touch.
+func segmentsMeet(a, b, c, d []float64) bool {
+	d1, d2 := side(c, d, a), side(c, d, b)
+	d3, d4 := side(a, b, c), side(a, b, d)
+	if ((d1 > ringTol && d2 < -ringTol) || (d1 < -ringTol && d2 > ringTol)) &&
+		((d3 > ringTol && d4 < -ringTol) || (d3 < -ringTol && d4 > ringTol)) {
+		return true
+	}
+	return onSegment(c, d, a, d1) || onSegment(c, d, b, d2) || onSegment(a, b, c, d3) || onSegment(a, b, d, d4)
+}
+
+// onSegment reports whether p, at distance dist from the line ab, lies on ab.
+func onSegment(a, b, p []float64, dist float64) bool {
+	return math.Abs(dist) < ringTol &&
+		p[0] >= math.Min(a[0], b[0])-ringTol && p[0] <= math.Max(a[0], b[0])+ringTol &&
+		p[1] >= math.Min(a[1], b[1])-ringTol && p[1] <= math.Max(a[1], b[1])+ringTol
+}
@@ -0,0 +1,170 @@
+package notam
+
+import (
+	"math"
+	"testing"
+
+	"SepTaf/internal/geo"
+	"SepTaf/internal/testutil"
+)
+
+const qLine = "Q) OIIX/QRDCA/IV/BO/W/000/100/3541N05119E010 "
+
+func TestGeometry(t *testing.T) {
+	tests := []struct {
+		name   string
+		e      string
+		source string
+		ring   [][]float64 // exact item-E polygon; nil to skip
+		center *geo.Point  // circle centre; radius checked against radius
+		radius float64
+	}{
+		{
+			name:   "item-E polygon, closed",
+			e:      "DANGER AREA ACT WI 354500N0512000E - 354500N0513000E - 353500N0513000E - 353500N0512000E - 354500N0512000E SFC-FL100",
+			source: GeomItemE,
+			ring:   [][]float64{{51.333333, 35.75}, {51.5, 35.75}, {51.5, 35.583333}, {51.333333, 35.583333}, {51.333333, 35.75}},
+		},
+		{
+			name:   "item-E polygon, short coordinates closed for 2dsphere",
+			e:      "AREA 3545N05120E 3545N05130E 3535N05125E",
+			source: GeomItemE,
+			ring:   [][]float64{{51.333333, 35.75}, {51.5, 35.75}, {51.416667, 35.583333}, {51.333333, 35.75}},
+		},
+		{
+			name:   "item-E circle in km",
+			e:      "UAV FLYING RADIUS 9.26KM CENTRE 354000N0511500E",
+			source: GeomItemE,
+			center: &geo.Point{Lat: 35.666667, Lon: 51.25},
+			radius: 5,
+		},
+		{
+			name:   "obstacle list falls back to the Q-line",
+			e:      "OBST CRANES: 354000N0511500E 354100N0511600E 354200N0511700E HGT 150M",
+			source: GeomQLine,
+			center: &geo.Point{Lat: 35.6833, Lon: 51.3167},
+			radius: 10,
+		},
+		{
+			name:   "self-intersecting polygon falls back to the Q-line",
+			e:      "WI 3545N05120E - 3535N05130E - 3545N05130E - 3535N05120E",
+			source: GeomQLine,
+			center: &geo.Point{Lat: 35.6833, Lon: 51.3167},
+			radius: 10,
+		},
+		{
+			name:   "repeated vertex falls back to the Q-line",
+			e:      "WI 3545N05120E - 3545N05130E - 3540N05125E - 3545N05130E - 3535N05125E",
+			source: GeomQLine,
+			center: &geo.Point{Lat: 35.6833, Lon: 51.3167},
+			radius: 10,
+		},
+		{
+			name:   "collinear points fall back to the Q-line",
+			e:      "WI 3545N05120E - 3540N05125E - 3535N05130E",
+			source: GeomQLine,
+			center: &geo.Point{Lat: 35.6833, Lon: 51.3167},
+			radius: 10,
+		},
+		{
+			name:   "invalid minutes fall back to the Q-line",
+			e:      "WI 3575N05120E - 3545N05130E - 3535N05125E",
+			source: GeomQLine,
+			center: &geo.Point{Lat: 35.6833, Lon: 51.3167},
+			radius: 10,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := Parse("A0100/25 NOTAMN " + qLine + "A) OIIX B) 2503140000 C) 2503150000 E) " + tc.e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, src := n.Geometry()
+			if g == nil || src != tc.source || g.Type != "Polygon" || len(g.Coordinates) != 1 {
+				t.Fatalf("Geometry = %+v, %q; want a %s polygon", g, src, tc.source)
+			}
+			if tc.ring != nil {
+				testutil.Eq(t, "ring", g.Coordinates[0], tc.ring)
+			}
+			if tc.center != nil {
+				checkCircle(t, g, *tc.center, tc.radius)
+			}
+		})
+	}
+}
+
+func TestGeometryNone(t *testing.T) {
+	// no item-E area and no Q-line radius
+	n, err := Parse("A0101/25 NOTAMN Q) OIIX/QMRLC/IV/NBO/A/000/999/3541N05119E A) OIII E) RWY CLSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, src := n.Geometry(); g != nil || src != "" {
+		t.Errorf("Geometry = %+v, %q; want none", g, src)
+	}
+
+	// radius 000 is drawn as 1 NM
+	n, err = Parse("A0102/25 NOTAMN Q) OIIX/QMRLC/IV/NBO/A/000/999/3541N05119E000 A) OIII E) RWY CLSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, src := n.Geometry()
+	if g == nil || src != GeomQLine {
+		t.Fatalf("Geometry = %+v, %q; want the Q-line circle", g, src)
+	}
+	checkCircle(t, g, geo.Point{Lat: 35.6833, Lon: 51.3167}, 1)
+}
+
+func TestCircle(t *testing.T) {
+	c := geo.Point{Lat: 35.69, Lon: 51.31}
+	g := Circle(c, 25)
+	ring := g.Coordinates[0]
+	testutil.Eq(t, "vertices", len(ring), circleVertices+1)
+	testutil.Eq(t, "closed", ring[0], ring[len(ring)-1])
+	// first vertex is due north
+	testutil.Eq(t, "north lon", ring[0][0], 51.31)
+	checkCircle(t, g, c, 25)
+	if selfIntersects(ring) {
+		t.Error("circle ring self-intersects")
+	}
+}
+
+func TestSelfIntersects(t *testing.T) {
+	square := [][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}
+	bowtie := [][]float64{{0, 0}, {1, 1}, {1, 0}, {0, 1}, {0, 0}}
+	// a vertex on a non-adjacent edge
+	touching := [][]float64{{0, 0}, {2, 0}, {2, 2}, {1, 0}, {0, 2}, {0, 0}}
+	testutil.Eq(t, "square", selfIntersects(square), false)
+	testutil.Eq(t, "bowtie", selfIntersects(bowtie), true)
+	testutil.Eq(t, "touching", selfIntersects(touching), true)
+}
+
+func TestDegenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		ring [][]float64
+		want bool
+	}{
+		{"square", [][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}, false},
+		{"straight vertex inside an edge", [][]float64{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {0, 0}}, false},
+		{"repeated vertex", [][]float64{{0, 0}, {1, 0}, {1, 1}, {1, 0}, {0, 1}, {0, 0}}, true},
+		{"two distinct vertices", [][]float64{{0, 0}, {1, 0}, {0, 0}}, true},
+		{"collinear", [][]float64{{0, 0}, {1, 1}, {2, 2}, {0, 0}}, true},
+		{"edge doubles back", [][]float64{{0, 0}, {2, 0}, {1, 0}, {1, 1}, {0, 0}}, true},
+		{"closing edge doubles back", [][]float64{{1, 0}, {1, 1}, {0, 0}, {2, 0}, {1, 0}}, true},
+	}
+	for _, tc := range tests {
+		testutil.Eq(t, tc.name, degenerate(tc.ring), tc.want)
+	}
+}
+
+// checkCircle asserts every vertex lies radiusNM from c.
+func checkCircle(t *testing.T, g *Geometry, c geo.Point, radiusNM float64) {
+	t.Helper()
+	for _, p := range g.Coordinates[0] {
+		if d := geo.DistanceNM(c, geo.Point{Lat: p[1], Lon: p[0]}); math.Abs(d-radiusNM) > 0.01 {
+			t.Fatalf("vertex %v is %.3f NM from %v, want %v", p, d, c, radiusNM)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package notam decodes ICAO-format NOTAMs (ICAO Annex 15 / Doc 8126):
+// the series/number/type line, the Q-line and items A to G, and evaluates
+// when a NOTAM is in force (items B/C and the item-D schedule).
+package notam
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	headerRe = regexp.MustCompile(`\b([A-Z])(\d{4})/(\d{2})\s+NOTAM([NRC])(?:\s+([A-Z]\d{4}/\d{2}))?`)
+	itemRe   = regexp.MustCompile(`(?:^|\s)([QABCDEFG])\)\s*`)
+	coordRe  = regexp.MustCompile(`^(\d{2})(\d{2})([NS])(\d{3})(\d{2})([EW])(\d{3})?$`)
+	dtgRe    = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})\s*(EST)?$`)
+	limitRe  = regexp.MustCompile(`^(SFC|GND|UNL|FL\s?\d{2,3}|\d+\s?(FT|M)\b)`)
+)
+
+// ErrNoNOTAM is returned when the text has neither a NOTAM header nor a Q-line.
+var ErrNoNOTAM = errors.New("not an ICAO NOTAM")
+
+// QLine is item Q: FIR/QCODE/TRAFFIC/PURPOSE/SCOPE/LOWER/UPPER/COORD+RADIUS.
+type QLine struct {
+	FIR           string   `json:"fir"`
+	Code          string   `json:"code"`    // e.g. QMRLC
+	Subject       string   `json:"subject"` // 2nd+3rd letters, e.g. MR
+	SubjectText   string   `json:"subjectText,omitempty"`
+	Condition     string   `json:"condition"` // 4th+5th letters, e.g. LC
+	ConditionText string   `json:"conditionText,omitempty"`
+	Traffic       string   `json:"traffic,omitempty"` // I | V | IV | K
+	Purpose       string   `json:"purpose,omitempty"` // combination of N B O M K
+	Scope         string   `json:"scope,omitempty"`   // A | E | W | AE | AW | K
+	LowerFL       *int     `json:"lowerFL,omitempty"`
+	UpperFL       *int     `json:"upperFL,omitempty"`
+	Lat           *float64 `json:"lat,omitempty"`
+	Lon           *float64 `json:"lon,omitempty"`
+	RadiusNM      *int     `json:"radiusNM,omitempty"`
+}
+
+// NOTAM is a decoded ICAO NOTAM. From/To are items B/C; To is nil for PERM.
+type NOTAM struct {
+	Raw       string     `json:"raw"`
+	ID        string     `json:"id,omitempty"` // A1234/25
+	Series    string     `json:"series,omitempty"`
+	Number    int        `json:"number,omitempty"`
+	Year      int        `json:"year,omitempty"`
+	Type      string     `json:"type,omitempty"` // N (new) | R (replace) | C (cancel)
+	Ref       string     `json:"ref,omitempty"`  // NOTAM replaced or cancelled
+	Q         *QLine     `json:"q,omitempty"`
+	Locations []string   `json:"locations,omitempty"` // item A
+	From      *time.Time `json:"from,omitempty"`      // item B
+	To        *time.Time `json:"to,omitempty"`        // item C
+	Permanent bool       `json:"permanent,omitempty"` // C) PERM
+	Estimated bool       `json:"estimated,omitempty"` // C) ... EST
+	Schedule  string     `json:"schedule,omitempty"`  // item D, see ParseSchedule
+	Text      string     `json:"text,omitempty"`      // item E
+	Lower     string     `json:"lower,omitempty"`     // item F
+	Upper     string     `json:"upper,omitempty"`     // item G
+	Unparsed  []string   `json:"unparsed,omitempty"`
+}
+
+// Parse decodes an ICAO NOTAM. Items that cannot be read are reported in
+// Unparsed rather than failing the whole NOTAM.
+func Parse(raw string) (*NOTAM, error) {
+	text := strings.TrimSpace(strings.ReplaceAll(raw, "\r", ""))
+	if strings.HasPrefix(text, "(") {
+		// ICAO format wraps the whole NOTAM in parentheses
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "("), ")")
+	}
+	n := &NOTAM{Raw: strings.TrimSpace(raw)}
+
+	if m := headerRe.FindStringSubmatch(text); m != nil {
+		n.Series = m[1]
+		n.Number, _ = strconv.Atoi(m[2])
+		yy, _ := strconv.Atoi(m[3])
+		n.Year = 2000 + yy
+		n.ID = m[1] + m[2] + "/" + m[3]
+		n.Type = m[4]
+		n.Ref = m[5]
+	}
+
+	items := splitItems(text)
+	if n.ID == "" && items["Q"] == "" {
+		return nil, ErrNoNOTAM
+	}
+
+	if v := items["Q"]; v != "" {
+		q, err := parseQ(v)
+		if err != nil {
+			n.Unparsed = append(n.Unparsed, "Q) "+v)
+		}
+		n.Q = q
+	}
+	if v := items["A"]; v != "" {
+		n.Locations = strings.Fields(v)
+	}
+	if v := items["B"]; v != "" {
+		if t, _, ok := parseDTG(v); ok {
+			n.From = &t
+		} else {
+			n.Unparsed = append(n.Unparsed, "B) "+v)
+		}
+	}
+	if v := items["C"]; v != "" {
+		switch t, est, ok := parseDTG(v); {
+		case v == "PERM":
+			n.Permanent = true
+		case ok:
+			n.To, n.Estimated = &t, est
+		default:
+			n.Unparsed = append(n.Unparsed, "C) "+v)
+		}
+	}
+	if v := items["D"]; v != "" {
+		n.Schedule = v
+		if _, err := ParseSchedule(v); err != nil {
+			n.Unparsed = append(n.Unparsed, "D) "+v)
+		}
+	}
+	n.Text = items["E"]
+	n.Lower = items["F"]
+	n.Upper = items["G"]
+	return n, nil
+}
+
+// splitItems cuts the text at "X)" markers. Markers must come in Q..G
+// order, so "A)" inside item E is left alone; F)/G) are only taken when
+// followed by something that looks like a vertical limit.
+func splitItems(text string) map[string]string {
+	const order = "QABCDEFG"
+	out := map[string]string{}
+	locs := itemRe.FindAllStringSubmatchIndex(text, -1)
+	last, cur, start := -1, "", 0
+	for _, l := range locs {
+		letter := text[l[2]:l[3]]
+		pos := strings.Index(order, letter)
+		if pos <= last {
+			continue
+		}
+		if (letter == "F" || letter == "G") && !limitRe.MatchString(text[l[1]:]) {
+			continue
+		}
+		if cur != "" {
+			out[cur] = clean(text[start:l[0]])
+		}
+		last, cur, start = pos, letter, l[1]
+	}
+	if cur != "" {
+		out[cur] = clean(text[start:])
+	}
+	return out
+}
+
+func clean(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+func parseQ(v string) (*QLine, error) {
+	parts := strings.Split(strings.ReplaceAll(v, " ", ""), "/")
+	q := &QLine{}
+	if len(parts) > 0 {
+		q.FIR = parts[0]
+	}
+	if len(parts) > 1 {
+		q.Code = parts[1]
+		if len(q.Code) == 5 && q.Code[0] == 'Q' {
+			q.Subject, q.Condition = q.Code[1:3], q.Code[3:5]
+			q.SubjectText, q.ConditionText = subjects[q.Subject], conditions[q.Condition]
+		}
+	}
+	if len(parts) < 8 {
+		return q, errors.New("short Q-line")
+	}
+	q.Traffic, q.Purpose, q.Scope = parts[2], parts[3], parts[4]
+	if n, err := strconv.Atoi(parts[5]); err == nil {
+		q.LowerFL = &n
+	}
+	if n, err := strconv.Atoi(parts[6]); err == nil {
+		q.UpperFL = &n
+	}
+	m := coordRe.FindStringSubmatch(parts[7])
+	if m == nil {
+		return q, errors.New("bad Q-line coordinates")
+	}
+	lat := dm(m[1], m[2], m[3] == "S")
+	lon := dm(m[4], m[5], m[6] == "W")
+	q.Lat, q.Lon = &lat, &lon
+	if m[7] != "" {
+		r, _ := strconv.Atoi(m[7])
+		q.RadiusNM = &r
+	}
+	return q, nil
+}
+
+func dm(deg, min string, neg bool) float64 {
+	d, _ := strconv.Atoi(deg)
+	mi, _ := strconv.Atoi(min)
+	v := float64(d) + float64(mi)/60
+	if neg {
+		v = -v
+	}
+	return math.Round(v*1e4) / 1e4
+}
+
+// parseDTG reads YYMMDDhhmm with an optional EST suffix (UTC).
+func parseDTG(v string) (time.Time, bool, bool) {
+	m := dtgRe.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return time.Time{}, false, false
+	}
+	n := make([]int, 5)
+	for i := range n {
+		n[i], _ = strconv.Atoi(m[i+1])
+	}
+	t := time.Date(2000+n[0], time.Month(n[1]), n[2], n[3], n[4], 0, 0, time.UTC)
+	if int(t.Month()) != n[1] || t.Day() != n[2] {
+		return time.Time{}, false, false
+	}
+	return t, m[6] == "EST", true
+}
@@ -0,0 +1,136 @@
+package notam
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+)
+
+func utc(y int, m time.Month, d, h, mi int) time.Time {
+	return time.Date(y, m, d, h, mi, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	raw := `(A1234/25 NOTAMR A1200/25
+Q) OIIX/QMRLC/IV/NBO/A/000/999/3541N05119E005
+A) OIII B) 2503140600 C) 2503201800 EST
+D) MON-FRI 0600-1800
+E) RWY 11L/29R CLSD DUE TO WIP. A) IS NOT AN ITEM HERE
+F) SFC G) 1500FT AMSL)`
+	n, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := &NOTAM{
+		Raw: raw, ID: "A1234/25", Series: "A", Number: 1234, Year: 2025, Type: "R", Ref: "A1200/25",
+		Q: &QLine{
+			FIR: "OIIX", Code: "QMRLC", Subject: "MR", SubjectText: "Runway", Condition: "LC", ConditionText: "Closed",
+			Traffic: "IV", Purpose: "NBO", Scope: "A", LowerFL: testutil.Ptr(0), UpperFL: testutil.Ptr(999),
+			Lat: testutil.Ptr(35.6833), Lon: testutil.Ptr(51.3167), RadiusNM: testutil.Ptr(5),
+		},
+		Locations: []string{"OIII"},
+		From:      testutil.Ptr(utc(2025, 3, 14, 6, 0)),
+		To:        testutil.Ptr(utc(2025, 3, 20, 18, 0)),
+		Estimated: true,
+		Schedule:  "MON-FRI 0600-1800",
+		Text:      "RWY 11L/29R CLSD DUE TO WIP. A) IS NOT AN ITEM HERE",
+		Lower:     "SFC",
+		Upper:     "1500FT AMSL",
+	}
+	if !reflect.DeepEqual(n, want) {
+		t.Errorf("Parse:\n got  %+v\n      %+v\n want %+v\n      %+v", n, n.Q, want, want.Q)
+	}
+}
+
+func TestParseVariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		check func(t *testing.T, n *NOTAM)
+	}{
+		{
+			name: "perm, south/west, no radius",
+			raw:  "B0012/24 NOTAMN Q) SAEF/QNVAS/I/BO/E/000/050/3449S05832W A) SAEZ B) 2401010000 C) PERM E) VOR EZE U/S",
+			check: func(t *testing.T, n *NOTAM) {
+				testutil.Eq(t, "perm", n.Permanent, true)
+				testutil.Eq(t, "to", n.To, (*time.Time)(nil))
+				testutil.Eq(t, "lat", *n.Q.Lat, -34.8167)
+				testutil.Eq(t, "lon", *n.Q.Lon, -58.5333)
+				testutil.Eq(t, "radius", n.Q.RadiusNM, (*int)(nil))
+				testutil.Eq(t, "condition text", n.Q.ConditionText, "Unserviceable")
+			},
+		},
+		{
+			name: "cancel, several locations",
+			raw:  "(C0099/25 NOTAMC C0098/25\nQ) OIIX/QMXXX/IV/M/A/000/999/3541N05119E005\nA) OIII OIIE\nB) 2503141200\nE) REF NOTAM CANCELLED)",
+			check: func(t *testing.T, n *NOTAM) {
+				testutil.Eq(t, "type", n.Type, "C")
+				testutil.Eq(t, "ref", n.Ref, "C0098/25")
+				testutil.Eq(t, "locations", n.Locations, []string{"OIII", "OIIE"})
+				testutil.Eq(t, "code text", n.Q.SubjectText+": "+n.Q.ConditionText, "Taxiway: Plain language")
+				testutil.Eq(t, "no to", n.To, (*time.Time)(nil))
+			},
+		},
+		{
+			name: "F) in item E is not a limit",
+			raw:  "A0001/25 NOTAMN Q) OIIX/QOBCE/IV/M/A/000/010/3541N05119E001 A) OIII B) 2503140000 C) 2503150000 E) CRANE AT TWY F) HEIGHT 45M",
+			check: func(t *testing.T, n *NOTAM) {
+				testutil.Eq(t, "text", n.Text, "CRANE AT TWY F) HEIGHT 45M")
+				testutil.Eq(t, "lower", n.Lower, "")
+			},
+		},
+		{
+			name: "bad items kept in unparsed",
+			raw:  "A0002/25 NOTAMN Q) OIIX/QMRLC/IV A) OIII B) 2502300000 C) SOON D) WHENEVER E) X",
+			check: func(t *testing.T, n *NOTAM) {
+				testutil.Eq(t, "unparsed", n.Unparsed, []string{"Q) OIIX/QMRLC/IV", "B) 2502300000", "C) SOON", "D) WHENEVER"})
+				// what could be read is still set
+				testutil.Eq(t, "code", n.Q.Code, "QMRLC")
+				testutil.Eq(t, "text", n.Text, "X")
+			},
+		},
+		{
+			name: "Q-line without header",
+			raw:  "Q) OIIX/QFALC/IV/NBO/A/000/999/3541N05119E005 A) OIII E) AD CLSD",
+			check: func(t *testing.T, n *NOTAM) {
+				testutil.Eq(t, "id", n.ID, "")
+				testutil.Eq(t, "subject", n.Q.SubjectText, "Aerodrome")
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := Parse(tc.raw)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tc.check(t, n)
+		})
+	}
+
+	if _, err := Parse("RWY 11L CLSD"); !errors.Is(err, ErrNoNOTAM) {
+		t.Errorf("Parse(plain text) err = %v, want ErrNoNOTAM", err)
+	}
+}
+
+func TestParseDTG(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		est, ok bool
+	}{
+		{"2503140600", utc(2025, 3, 14, 6, 0), false, true},
+		{"2512312359 EST", utc(2025, 12, 31, 23, 59), true, true},
+		{"2502290000", time.Time{}, false, false}, // no 29 Feb 2025
+		{"25031406", time.Time{}, false, false},
+	}
+	for _, tc := range tests {
+		got, est, ok := parseDTG(tc.in)
+		if !got.Equal(tc.want) || est != tc.est || ok != tc.ok {
+			t.Errorf("parseDTG(%q) = %v, %v, %v; want %v, %v, %v", tc.in, got, est, ok, tc.want, tc.est, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package notam
+
+// subjects and conditions are the common NOTAM Q-code letters (ICAO Doc
+// 8126 / PANS-ABC); unknown codes are kept without text.
+var subjects = map[string]string{
+	// AGA: lighting
+	"LA": "Approach lighting system", "LB": "Aerodrome beacon", "LC": "Runway centre line lights",
+	"LD": "Landing direction indicator lights", "LE": "Runway edge lights", "LF": "Sequenced flashing lights",
+	"LH": "High intensity runway lights", "LI": "Runway end identifier lights", "LK": "CAT II components of ALS",
+	"LL": "Low intensity runway lights", "LM": "Medium intensity runway lights", "LP": "PAPI",
+	"LR": "All landing area lighting facilities", "LS": "Stopway lights", "LT": "Threshold lights",
+	"LV": "VASIS", "LW": "Heliport lighting", "LX": "Taxiway centre line lights", "LY": "Taxiway edge lights",
+	"LZ": "Runway touchdown zone lights",
+	// AGA: movement and landing area
+	"MA": "Movement area", "MB": "Bearing strength", "MC": "Clearway", "MD": "Declared distances",
+	"MG": "Taxiing guidance system", "MH": "Runway arresting gear", "MK": "Parking area", "MM": "Daylight markings",
+	"MN": "Apron", "MO": "Stopbar", "MP": "Aircraft stands", "MR": "Runway", "MS": "Stopway",
+	"MT": "Threshold", "MU": "Runway turning bay", "MW": "Strip/shoulder", "MX": "Taxiway",
+	"MY": "Rapid exit taxiway",
+	// AGA: facilities and services
+	"FA": "Aerodrome", "FB": "Friction measuring device", "FC": "Ceiling measurement equipment",
+	"FD": "Docking system", "FE": "Oxygen", "FF": "Fire fighting and rescue", "FG": "Ground movement control",
+	"FH": "Helicopter alighting area/platform", "FI": "Aircraft de-icing", "FJ": "Oils", "FL": "Landing direction indicator",
+	"FM": "Meteorological service", "FO": "Fog dispersal system", "FP": "Heliport", "FS": "Snow removal equipment",
+	"FT": "Transmissometer", "FU": "Fuel availability", "FW": "Wind direction indicator", "FZ": "Customs/immigration",
+	// COM
+	"CA": "Air/ground facility", "CB": "ADS-B", "CC": "ADS-C", "CD": "CPDLC", "CE": "En route surveillance radar",
+	"CG": "Ground controlled approach system", "CL": "SELCAL", "CM": "Surface movement radar",
+	"CP": "Precision approach radar", "CR": "Surveillance radar element of PAR", "CS": "SSR",
+	"CT": "Terminal area surveillance radar",
+	"IC": "ILS", "ID": "DME associated with ILS", "IG": "Glide path (ILS)", "II": "Inner marker (ILS)",
+	"IL": "Localizer (ILS)", "IM": "Middle marker (ILS)", "IN": "Localizer (not ILS)", "IO": "Outer marker (ILS)",
+	"IS": "ILS Category I", "IT": "ILS Category II", "IU": "ILS Category III", "IW": "MLS", "IX": "Locator, outer (ILS)",
+	"IY": "Locator, middle (ILS)",
+	"GA": "GNSS airfield-specific operations", "GW": "GNSS area-wide operations",
+	"NA": "All radio navigation facilities", "NB": "NDB", "NC": "DECCA", "ND": "DME", "NF": "Fan marker",
+	"NL": "Locator", "NM": "VOR/DME", "NN": "TACAN", "NO": "OMEGA", "NT": "VORTAC", "NV": "VOR",
+	"NX": "Direction finding station",
+	// RAC
+	"AA": "Minimum altitude", "AC": "Class B, C, D or E surface area", "AD": "Air defence identification zone",
+	"AE": "Control area", "AF": "Flight information region", "AH": "Upper control area", "AL": "Minimum usable flight level",
+	"AN": "Area navigation route", "AO": "Oceanic control area", "AP": "Reporting point", "AR": "ATS route",
+	"AT": "Terminal control area", "AU": "Upper flight information region", "AV": "Upper advisory area",
+	"AX": "Significant point", "AZ": "Aerodrome traffic zone",
+	"SA": "ATIS", "SB": "ATS reporting office", "SC": "Area control centre", "SE": "Flight information service",
+	"SF": "Aerodrome flight information service", "SL": "Flow control centre", "SO": "Oceanic area control centre",
+	"SP": "Approach control service", "SS": "Flight service station", "ST": "Aerodrome control tower",
+	"SU": "Upper area control centre", "SV": "VOLMET broadcast", "SY": "Upper advisory service",
+	"PA": "Standard instrument arrival", "PB": "Standard VFR arrival", "PC": "Contingency procedures",
+	"PD": "Standard instrument departure", "PE": "Standard VFR departure", "PF": "Flow control procedure",
+	"PH": "Holding procedure", "PI": "Instrument approach procedure", "PK": "VFR approach procedure",
+	"PL": "Flight plan processing", "PM": "Aerodrome operating minima", "PN": "Noise operating restriction",
+	"PO": "Obstacle clearance altitude and height", "PR": "Radio failure procedure", "PT": "Transition altitude or level",
+	"PU": "Missed approach procedure", "PX": "Minimum holding altitude", "PZ": "ADIZ procedure",
+	// navigation warnings
+	"RA": "Airspace reservation", "RD": "Danger area", "RM": "Military operating area", "RO": "Overflying of",
+	"RP": "Prohibited area", "RR": "Restricted area", "RT": "Temporary restricted area",
+	"WA": "Air display", "WB": "Aerobatics", "WC": "Captive balloon or kite", "WD": "Demolition of explosives",
+	"WE": "Exercises", "WF": "Air refuelling", "WG": "Glider flying", "WH": "Blasting", "WJ": "Banner/target towing",
+	"WL": "Ascent of free balloon", "WM": "Missile, gun or rocket firing", "WP": "Parachute jumping exercise",
+	"WR": "Radioactive materials or toxic chemicals", "WS": "Burning or blowing gas", "WT": "Mass movement of aircraft",
+	"WU": "Unmanned aircraft", "WV": "Formation flight", "WW": "Significant volcanic activity",
+	"WZ": "Model flying",
+	// other
+	"OA": "Aeronautical information service", "OB": "Obstacle", "OE": "Aircraft entry requirements",
+	"OL": "Obstacle lights", "OR": "Rescue coordination centre",
+}
+
+var conditions = map[string]string{
+	// availability
+	"AC": "Withdrawn for maintenance", "AD": "Available for daylight operation", "AF": "Flight checked and found reliable",
+	"AG": "Operating but ground checked only", "AH": "Hours of service are", "AK": "Resumed normal operation",
+	"AL": "Operative (subject to previously published limitations)", "AM": "Military operations only",
+	"AN": "Available for night operation", "AO": "Operational", "AP": "Available, prior permission required",
+	"AR": "Available on request", "AS": "Unserviceable", "AU": "Not available", "AW": "Completely withdrawn",
+	"AX": "Previously promulgated shutdown has been cancelled",
+	// changes
+	"CA": "Activated", "CC": "Completed", "CD": "Deactivated", "CE": "Erected", "CF": "Operating frequency changed",
+	"CG": "Downgraded", "CH": "Changed", "CI": "Identification or radio call sign changed", "CL": "Realigned",
+	"CM": "Displaced", "CN": "Cancelled", "CO": "Operating", "CP": "Operating on reduced power",
+	"CR": "Temporarily replaced by", "CS": "Installed", "CT": "On test, do not use",
+	// hazard conditions
+	"HA": "Braking action is", "HB": "Friction coefficient is", "HC": "Covered by compacted snow",
+	"HD": "Covered by dry snow", "HE": "Covered by water", "HF": "Totally free of snow and ice",
+	"HG": "Grass cutting in progress", "HH": "Hazard due to", "HI": "Covered by ice", "HJ": "Launch planned",
+	"HK": "Bird migration in progress", "HL": "Snow clearance completed", "HM": "Marked by",
+	"HN": "Covered by wet snow or slush", "HO": "Obscured by snow", "HP": "Snow clearance in progress",
+	"HQ": "Operation cancelled", "HR": "Standing water", "HS": "Sanding in progress",
+	"HT": "Approach according to signal area only", "HU": "Launch in progress", "HV": "Work completed",
+	"HW": "Work in progress", "HX": "Concentration of birds", "HY": "Snow banks exist", "HZ": "Covered by frozen ruts and ridges",
+	// limitations
+	"LA": "Operating on auxiliary power supply", "LB": "Reserved for aircraft based therein",
+	"LC": "Closed", "LD": "Unsafe", "LE": "Operating without auxiliary power supply", "LF": "Interference from",
+	"LG": "Operating without identification", "LH": "Unserviceable for aircraft heavier than",
+	"LI": "Closed to IFR operations", "LK": "Operating as a fixed light", "LL": "Usable for length of",
+	"LN": "Closed to all night operations", "LP": "Prohibited to", "LR": "Aircraft restricted to runways and taxiways",
+	"LS": "Subject to interruption", "LT": "Limited to", "LV": "Closed to VFR operations", "LW": "Will take place",
+	"LX": "Operating but caution advised due to",
+	// other
+	"XX": "Plain language",
+}
@@ -0,0 +1,393 @@
+package notam
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed item D: day selectors (DAILY, MON-FRI, JAN 05-10,
+// 05 07 09, ...) each followed by time spans (0600-1400, SR-SS, H24, HJ,
+// HN, SR MINUS30-SS PLUS30), with an optional EXC list of days. All times
+// are UTC; SR/SS are computed for the NOTAM position.
+type Schedule struct {
+	Raw    string
+	rules  []schedRule
+	except []daySel
+	sun    bool // uses SR/SS
+}
+
+type schedRule struct {
+	days  []daySel // all must match; empty = every day
+	times []span   // empty = whole day
+}
+
+type daySel struct {
+	weekdays []time.Weekday // OR
+	dates    []dateRange    // OR
+}
+
+// dateRange is MMDD..MMDD; month 0 means any month (day-of-month only).
+type dateRange struct{ m1, d1, m2, d2 int }
+
+type span struct{ from, to tod }
+
+// tod is a clock time in minutes, or SR/SS plus an offset in minutes.
+type tod struct {
+	min   int
+	event string // "" | SR | SS
+}
+
+var (
+	sunOffRe  = regexp.MustCompile(`\b(SR|SS)\s*(PLUS|MINUS)\s*(\d{1,3})\b`)
+	dashRe    = regexp.MustCompile(`\s*-\s*`)
+	crossRe   = regexp.MustCompile(`\b(JAN|FEB|MAR|APR|MAY|JUN|JUL|AUG|SEP|OCT|NOV|DEC) (\d{1,2})-(JAN|FEB|MAR|APR|MAY|JUN|JUL|AUG|SEP|OCT|NOV|DEC) (\d{1,2})\b`)
+	crossTok  = regexp.MustCompile(`^(JAN|FEB|MAR|APR|MAY|JUN|JUL|AUG|SEP|OCT|NOV|DEC)(\d{1,2})-(JAN|FEB|MAR|APR|MAY|JUN|JUL|AUG|SEP|OCT|NOV|DEC)(\d{1,2})$`)
+	spanRe    = regexp.MustCompile(`^(\d{4}|SR|SS)(?:([PM])(\d{1,3}))?-(\d{4}|SR|SS)(?:([PM])(\d{1,3}))?$`)
+	domRe     = regexp.MustCompile(`^(\d{1,2})(?:-(\d{1,2}))?$`)
+	weekdayRe = regexp.MustCompile(`^(MON|TUE|WED|THU|FRI|SAT|SUN)(?:-(MON|TUE|WED|THU|FRI|SAT|SUN))?$`)
+)
+
+var (
+	weekdays = map[string]time.Weekday{"SUN": time.Sunday, "MON": time.Monday, "TUE": time.Tuesday,
+		"WED": time.Wednesday, "THU": time.Thursday, "FRI": time.Friday, "SAT": time.Saturday}
+	months = map[string]int{"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12}
+)
+
+// ParseSchedule parses item D. An empty text gives nil (no schedule).
+func ParseSchedule(d string) (*Schedule, error) {
+	text := strings.ToUpper(strings.TrimSpace(d))
+	if text == "" {
+		return nil, nil
+	}
+	s := &Schedule{Raw: d}
+	text = strings.NewReplacer(",", " ", ".", " ", "/", " ").Replace(text)
+	text = sunOffRe.ReplaceAllStringFunc(text, func(m string) string {
+		p := sunOffRe.FindStringSubmatch(m)
+		return p[1] + p[2][:1] + p[3] // SR MINUS30 -> SRM30
+	})
+	text = dashRe.ReplaceAllString(text, "-")
+	text = crossRe.ReplaceAllString(text, "$1$2-$3$4")
+
+	var (
+		cur   schedRule
+		month int
+		exc   bool
+	)
+	flush := func() {
+		if len(cur.days) > 0 || len(cur.times) > 0 {
+			s.rules = append(s.rules, cur)
+		}
+		// month stays: "FEB 08 0800-1200 10 1300-1500" is FEB 10
+		cur = schedRule{}
+	}
+	addDay := func(sel daySel) {
+		if exc {
+			s.except = append(s.except, sel)
+			return
+		}
+		if len(cur.times) > 0 {
+			flush()
+		}
+		// روزهای هم‌نوع پشت سر هم OR می‌شوند (MON WED FRI)
+		if n := len(cur.days); n > 0 {
+			last := &cur.days[n-1]
+			if len(sel.weekdays) > 0 && len(last.weekdays) > 0 {
+				last.weekdays = append(last.weekdays, sel.weekdays...)
+				return
+			}
+			if len(sel.dates) > 0 && len(last.dates) > 0 {
+				last.dates = append(last.dates, sel.dates...)
+				return
+			}
+		}
+		cur.days = append(cur.days, sel)
+	}
+	addSpan := func(sp span) {
+		exc = false
+		if sp.from.event != "" || sp.to.event != "" {
+			s.sun = true
+		}
+		cur.times = append(cur.times, sp)
+	}
+
+	for _, tok := range strings.Fields(text) {
+		switch {
+		case tok == "DAILY":
+			if len(cur.times) > 0 {
+				flush()
+			}
+		case tok == "EXC":
+			exc = true
+		case tok == "H24":
+			addSpan(span{tod{min: 0}, tod{min: 24 * 60}})
+		case tok == "HJ":
+			addSpan(span{tod{event: "SR"}, tod{event: "SS"}})
+		case tok == "HN":
+			addSpan(span{tod{event: "SS"}, tod{event: "SR"}})
+		case months[tok] > 0:
+			if exc {
+				month = months[tok]
+				continue
+			}
+			if len(cur.times) > 0 {
+				flush()
+			}
+			month = months[tok]
+		case weekdayRe.MatchString(tok):
+			m := weekdayRe.FindStringSubmatch(tok)
+			month = 0
+			addDay(daySel{weekdays: weekdayRange(m[1], m[2])})
+		case spanRe.MatchString(tok):
+			m := spanRe.FindStringSubmatch(tok)
+			from, err1 := parseTod(m[1], m[2], m[3])
+			to, err2 := parseTod(m[4], m[5], m[6])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("bad time %q", tok)
+			}
+			addSpan(span{from, to})
+		case crossTok.MatchString(tok):
+			m := crossTok.FindStringSubmatch(tok)
+			d1, _ := strconv.Atoi(m[2])
+			d2, _ := strconv.Atoi(m[4])
+			addDay(daySel{dates: []dateRange{{months[m[1]], d1, months[m[3]], d2}}})
+		case domRe.MatchString(tok):
+			m := domRe.FindStringSubmatch(tok)
+			d1, _ := strconv.Atoi(m[1])
+			d2 := d1
+			if m[2] != "" {
+				d2, _ = strconv.Atoi(m[2])
+			}
+			if d1 < 1 || d1 > 31 || d2 < 1 || d2 > 31 {
+				return nil, fmt.Errorf("bad day %q", tok)
+			}
+			addDay(daySel{dates: []dateRange{{month, d1, month, d2}}})
+		case exc:
+			// EXC HOL و مانند آن قابل محاسبه نیست؛ استثنا نادیده گرفته می‌شود
+			// (NOTAM در آن روزها هم فعال حساب می‌شود)
+		default:
+			return nil, fmt.Errorf("unsupported schedule term %q", tok)
+		}
+	}
+	flush()
+	if len(s.rules) == 0 {
+		return nil, errors.New("empty schedule")
+	}
+	return s, nil
+}
+
+func weekdayRange(a, b string) []time.Weekday {
+	from := weekdays[a]
+	if b == "" {
+		return []time.Weekday{from}
+	}
+	to := weekdays[b]
+	var out []time.Weekday
+	for d := from; ; d = (d + 1) % 7 {
+		out = append(out, d)
+		if d == to {
+			return out
+		}
+	}
+}
+
+func parseTod(v, sign, off string) (tod, error) {
+	if v == "SR" || v == "SS" {
+		t := tod{event: v}
+		if off != "" {
+			n, _ := strconv.Atoi(off)
+			if sign == "M" {
+				n = -n
+			}
+			t.min = n
+		}
+		return t, nil
+	}
+	h, _ := strconv.Atoi(v[:2])
+	m, _ := strconv.Atoi(v[2:])
+	if m > 59 || h*60+m > 24*60 {
+		return tod{}, errors.New("bad time")
+	}
+	return tod{min: h*60 + m}, nil
+}
+
+// UsesSun reports whether the schedule needs a position (SR/SS).
+func (s *Schedule) UsesSun() bool { return s != nil && s.sun }
+
+// ActiveBetween reports whether the schedule is in force at any instant
+// of [from, to). Without a position, SR/SS spans count as active.
+func (s *Schedule) ActiveBetween(from, to time.Time, lat, lon *float64) bool {
+	if s == nil {
+		return true
+	}
+	if s.sun && (lat == nil || lon == nil) {
+		return true
+	}
+	from, to = from.UTC(), to.UTC()
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	for i := 0; !day.After(to); i, day = i+1, day.AddDate(0, 0, 1) {
+		if i == 400 {
+			return true // بازه‌ی بیش از یک سال: عملا فعال
+		}
+		if s.excluded(day) {
+			continue
+		}
+		for _, r := range s.rules {
+			if !r.matches(day) {
+				continue
+			}
+			if len(r.times) == 0 {
+				if day.Before(to) && day.Add(24*time.Hour).After(from) {
+					return true
+				}
+				continue
+			}
+			for _, sp := range r.times {
+				start, end, ok := sp.on(day, lat, lon)
+				if ok && start.Before(to) && end.After(from) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (s *Schedule) excluded(day time.Time) bool {
+	for _, e := range s.except {
+		if e.matches(day) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r schedRule) matches(day time.Time) bool {
+	for _, d := range r.days {
+		if !d.matches(day) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d daySel) matches(day time.Time) bool {
+	for _, w := range d.weekdays {
+		if day.Weekday() == w {
+			return true
+		}
+	}
+	md := int(day.Month())*100 + day.Day()
+	for _, r := range d.dates {
+		if r.m1 == 0 {
+			if day.Day() >= r.d1 && day.Day() <= r.d2 {
+				return true
+			}
+			continue
+		}
+		a, b := r.m1*100+r.d1, r.m2*100+r.d2
+		if (a <= b && md >= a && md <= b) || (a > b && (md >= a || md <= b)) {
+			return true
+		}
+	}
+	return false
+}
+
+// on gives the span's instants for day; an end before the start runs into
+// the next day, an empty span (e.g. SR-SS in polar night) gives ok=false.
+func (sp span) on(day time.Time, lat, lon *float64) (time.Time, time.Time, bool) {
+	var rise, set time.Time
+	if sp.from.event != "" || sp.to.event != "" {
+		rise, set = sunTimes(day, *lat, *lon)
+	}
+	at := func(t tod) time.Time {
+		switch t.event {
+		case "SR":
+			return rise.Add(time.Duration(t.min) * time.Minute)
+		case "SS":
+			return set.Add(time.Duration(t.min) * time.Minute)
+		}
+		return day.Add(time.Duration(t.min) * time.Minute)
+	}
+	start, end := at(sp.from), at(sp.to)
+	if end.Before(start) {
+		end = end.Add(24 * time.Hour)
+	}
+	return start, end, end.After(start)
+}
+
+// sunTimes returns sunrise and sunset (UTC) for the given UTC day, from
+// the standard sunrise equation (±1-2 min). In polar day the sun "rises" at
+// 00:00 and sets at 24:00; in polar night both are at 12:00.
+func sunTimes(day time.Time, lat, lon float64) (time.Time, time.Time) {
+	const rad = math.Pi / 180
+	jd := float64(day.Unix())/86400 + 2440587.5 + 0.5 // Julian date at 12:00 UTC
+	n := math.Round(jd - 2451545.0)
+	jStar := n - lon/360
+	m := math.Mod(357.5291+0.98560028*jStar, 360)
+	c := 1.9148*math.Sin(m*rad) + 0.0200*math.Sin(2*m*rad) + 0.0003*math.Sin(3*m*rad)
+	lambda := math.Mod(m+c+180+102.9372, 360)
+	transit := 2451545.0 + jStar + 0.0053*math.Sin(m*rad) - 0.0069*math.Sin(2*lambda*rad)
+	sinDec := math.Sin(lambda*rad) * math.Sin(23.4397*rad)
+	cosDec := math.Cos(math.Asin(sinDec))
+	cosW := (math.Sin(-0.833*rad) - math.Sin(lat*rad)*sinDec) / (math.Cos(lat*rad) * cosDec)
+	switch {
+	case cosW < -1:
+		return day, day.Add(24 * time.Hour)
+	case cosW > 1:
+		noon := day.Add(12 * time.Hour)
+		return noon, noon
+	}
+	w := math.Acos(cosW) / rad
+	toTime := func(j float64) time.Time {
+		return time.Unix(0, int64((j-2440587.5)*86400*1e9)).UTC().Truncate(time.Minute)
+	}
+	return toTime(transit - w/360), toTime(transit + w/360)
+}
+
+// Validity is when a NOTAM applies: items B/C plus the item-D schedule.
+// An EST end stays in force until the NOTAM is replaced or cancelled.
+type Validity struct {
+	From      *time.Time
+	To        *time.Time // nil: PERM or unknown
+	Estimated bool
+	Schedule  *Schedule
+	// ScheduleErr is set when item D is present but unreadable; Schedule is
+	// then nil and only B/C are applied.
+	ScheduleErr error
+	Lat, Lon    *float64 // Q-line position, for SR/SS
+}
+
+// ActiveBetween reports whether the NOTAM is in force at any instant of
+// [from, to).
+func (v Validity) ActiveBetween(from, to time.Time) bool {
+	if v.From != nil && v.From.After(from) {
+		from = *v.From
+	}
+	if v.To != nil && !v.Estimated && v.To.Before(to) {
+		to = *v.To
+	}
+	if !to.After(from) {
+		return false
+	}
+	return v.Schedule.ActiveBetween(from, to, v.Lat, v.Lon)
+}
+
+// ActiveAt reports whether the NOTAM is in force at t.
+func (v Validity) ActiveAt(t time.Time) bool { return v.ActiveBetween(t, t.Add(time.Second)) }
+
+// Validity returns B/C, the parsed item D (nil if absent, ScheduleErr if
+// unreadable) and the Q-line position.
+func (n *NOTAM) Validity() Validity {
+	v := Validity{From: n.From, To: n.To, Estimated: n.Estimated}
+	v.Schedule, v.ScheduleErr = ParseSchedule(n.Schedule)
+	if n.Q != nil {
+		v.Lat, v.Lon = n.Q.Lat, n.Q.Lon
+	}
+	return v
+}
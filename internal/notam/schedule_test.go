@@ -0,0 +1,106 @@
+package notam
+
+import (
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+)
+
+func TestParseScheduleActive(t *testing.T) {
+	oiiiLat, oiiiLon := 35.69, 51.31
+	tests := []struct {
+		d    string
+		at   time.Time
+		want bool
+	}{
+		{"MON-FRI 0600-1800", utc(2025, 3, 14, 12, 0), true}, // Friday
+		{"MON-FRI 0600-1800", utc(2025, 3, 15, 12, 0), false},
+		{"MON-FRI 0600-1800", utc(2025, 3, 14, 18, 30), false},
+		{"MON WED FRI 0800-1000", utc(2025, 3, 12, 9, 0), true},
+		{"MON WED FRI 0800-1000", utc(2025, 3, 13, 9, 0), false},
+		// overnight span runs into the next day
+		{"DAILY 2200-0600", utc(2025, 3, 15, 2, 0), true},
+		{"DAILY 2200-0600", utc(2025, 3, 15, 12, 0), false},
+		{"JAN 05-10 H24", utc(2025, 1, 7, 3, 0), true},
+		{"JAN 05-10 H24", utc(2025, 1, 11, 3, 0), false},
+		{"DEC 20-JAN 05 0800-1600", utc(2025, 1, 2, 9, 0), true},
+		{"DEC 20-JAN 05 0800-1600", utc(2025, 1, 6, 9, 0), false},
+		// the month carries over to the following day groups
+		{"FEB 08 0800-1200 10 1300-1500 12 0800-0900", utc(2025, 2, 8, 10, 0), true},
+		{"FEB 08 0800-1200 10 1300-1500 12 0800-0900", utc(2025, 2, 10, 14, 0), true},
+		{"FEB 08 0800-1200 10 1300-1500 12 0800-0900", utc(2025, 2, 10, 9, 0), false},
+		{"FEB 08 0800-1200 10 1300-1500 12 0800-0900", utc(2025, 2, 12, 8, 30), true},
+		{"FEB 08 0800-1200 10 1300-1500 12 0800-0900", utc(2025, 3, 12, 8, 30), false},
+		{"FEB 08 0800-1200 10 1300-1500 12 0800-0900", utc(2025, 3, 10, 14, 0), false},
+		// a weekday selector ends the month
+		{"FEB 08 1000-1200 MON 1300-1400 15 0800-0900", utc(2025, 3, 15, 8, 30), true},
+		{"MON WED FRI 0800-1000 EXC MAR 14", utc(2025, 3, 14, 9, 0), false},
+		{"MON WED FRI 0800-1000 EXC MAR 14", utc(2025, 3, 21, 9, 0), true},
+		// EXC HOL cannot be evaluated and is ignored
+		{"DAILY 0800-1000 EXC HOL", utc(2025, 3, 14, 9, 0), true},
+		{"HJ", utc(2025, 3, 14, 12, 0), true},
+		{"HJ", utc(2025, 3, 14, 20, 0), false},
+		{"HN", utc(2025, 3, 14, 20, 0), true},
+		{"SR MINUS30-SS PLUS30", utc(2025, 3, 14, 15, 0), true}, // sunset ~14:50Z
+		{"SR-SS", utc(2025, 3, 14, 15, 0), false},
+	}
+	for _, tc := range tests {
+		s, err := ParseSchedule(tc.d)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tc.d, err)
+			continue
+		}
+		if got := s.ActiveBetween(tc.at, tc.at.Add(time.Second), &oiiiLat, &oiiiLon); got != tc.want {
+			t.Errorf("%q at %s = %v, want %v", tc.d, tc.at.Format("Jan 02 15:04"), got, tc.want)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, d := range []string{"WHENEVER", "0800-2500", "MON 32 0800-1000", "EXC HOL"} {
+		if s, err := ParseSchedule(d); err == nil {
+			t.Errorf("ParseSchedule(%q) = %+v, want error", d, s)
+		}
+	}
+	if s, err := ParseSchedule("  "); s != nil || err != nil {
+		t.Errorf("ParseSchedule(blank) = %v, %v; want nil, nil", s, err)
+	}
+}
+
+func TestScheduleWithoutPosition(t *testing.T) {
+	s, err := ParseSchedule("HJ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Eq(t, "uses sun", s.UsesSun(), true)
+	// no position: SR/SS spans count as active
+	testutil.Eq(t, "active", s.ActiveBetween(utc(2025, 3, 14, 23, 0), utc(2025, 3, 14, 23, 1), nil, nil), true)
+}
+
+func TestValidity(t *testing.T) {
+	n, err := Parse("A0010/25 NOTAMN Q) OIIX/QMRLC/IV/NBO/A/000/999/3541N05119E005 A) OIII " +
+		"B) 2503100000 C) 2503200000 EST D) MON-FRI 0600-1800 E) RWY CLSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := n.Validity()
+	testutil.Eq(t, "schedule err", v.ScheduleErr, nil)
+	testutil.Eq(t, "before B", v.ActiveAt(utc(2025, 3, 7, 12, 0)), false)
+	testutil.Eq(t, "in schedule", v.ActiveAt(utc(2025, 3, 14, 12, 0)), true)
+	testutil.Eq(t, "outside schedule", v.ActiveAt(utc(2025, 3, 15, 12, 0)), false)
+	// EST: still in force after C
+	testutil.Eq(t, "after EST end", v.ActiveAt(utc(2025, 3, 24, 12, 0)), true)
+	testutil.Eq(t, "window", v.ActiveBetween(utc(2025, 3, 15, 0, 0), utc(2025, 3, 17, 0, 0)), false)
+
+	n.Estimated = false
+	testutil.Eq(t, "after C", n.Validity().ActiveAt(utc(2025, 3, 24, 12, 0)), false)
+
+	// an unreadable item D is reported, and only B/C apply
+	n.Schedule = "MON-FRI WHEN DIRECTED"
+	v = n.Validity()
+	if v.ScheduleErr == nil || v.Schedule != nil {
+		t.Fatalf("Validity with bad item D = %+v, want ScheduleErr", v)
+	}
+	testutil.Eq(t, "B/C only", v.ActiveAt(utc(2025, 3, 15, 12, 0)), true)
+}
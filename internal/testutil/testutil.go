@@ -0,0 +1,22 @@
+// Package testutil has the small helpers shared by the parser tests.
+package testutil
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Ref is the reference time of the test reports (DDHHMM groups on day 14).
+var Ref = time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+
+// Eq fails the test when got and want differ (reflect.DeepEqual).
+func Eq(t testing.TB, what string, got, want any) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %+v, want %+v", what, got, want)
+	}
+}
+
+// Ptr returns a pointer to v, for optional fields in expected values.
+func Ptr[T any](v T) *T { return &v }
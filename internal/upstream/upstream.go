@@ -0,0 +1,207 @@
+// Package upstream wraps the external data APIs (AWC, FAA) behind one
+// provider type with a shared HTTP client, configurable base URLs and an
+// optional record/replay mode for running against fixtures on disk.
+package upstream
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"SepTaf/internal/config"
+)
+
+const (
+	ModeLive   = "live"
+	ModeRecord = "record" // live + ذخیره‌ی پاسخ روی دیسک
+	ModeReplay = "replay" // فقط از دیسک؛ بدون شبکه
+)
+
+// ErrNoFixture is returned in replay mode when nothing was recorded for a
+// request.
+var ErrNoFixture = errors.New("no recorded upstream response")
+
+// یک client مشترک تا اتصال‌ها بین handlerها و jobها reuse شوند
+var sharedClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        64,
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type Provider struct {
+	Name      string // also the fixture sub-directory
+	BaseURL   string
+	Mode      string
+	Dir       string
+	UserAgent string
+	Timeout   time.Duration
+}
+
+// recording is the on-disk form of one upstream response.
+type recording struct {
+	URL         string    `json:"url"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        string    `json:"body"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+func New(name, baseURL string, cfg config.Config) *Provider {
+	mode := strings.ToLower(strings.TrimSpace(cfg.UpstreamMode))
+	if mode != ModeRecord && mode != ModeReplay {
+		mode = ModeLive
+	}
+	return &Provider{
+		Name:    name,
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Mode:    mode,
+		Dir:     cfg.UpstreamDir,
+		Timeout: 30 * time.Second,
+	}
+}
+
+// AWC is the aviationweather.gov data API (paths start with /api/data).
+func AWC(cfg config.Config) *Provider {
+	p := New("awc", cfg.AWCBaseURL, cfg)
+	p.UserAgent = "SepTaf-WX/1.0 (contact: you@example.com)"
+	return p
+}
+
+// FAA is the FAA external API (NOTAMs under /notamapi/v1).
+func FAA(cfg config.Config) *Provider {
+	p := New("faa", cfg.FAABaseURL, cfg)
+	p.UserAgent = "SepTaf-NOTAM/1.0 (contact: you@example.com)"
+	p.Timeout = 20 * time.Second
+	return p
+}
+
+// Get performs GET BaseURL+path?q. Non-2xx statuses are returned as a
+// Response, not an error; errors are transport failures or, in replay mode,
+// ErrNoFixture. hdr is sent upstream but never recorded.
+func (p *Provider) Get(ctx context.Context, path string, q url.Values, hdr http.Header) (*Response, error) {
+	u := p.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	file := p.fixturePath(path, q)
+
+	if p.Mode == ModeReplay {
+		return readRecording(file)
+	}
+
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range hdr {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if p.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+
+	resp, err := sharedClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	out := &Response{Status: resp.StatusCode, Header: resp.Header, Body: b}
+
+	if p.Mode == ModeRecord {
+		if err := writeRecording(file, u, out); err != nil {
+			log.Printf(`{"lvl":"warn","msg":"upstream record failed","file":%q,"err":%q}`, file, err.Error())
+		}
+	}
+	return out, nil
+}
+
+// fixturePath names the recording after the path plus a hash of the sorted
+// query, e.g. <dir>/awc/api_data_metar-3f2a….json.
+func (p *Provider) fixturePath(path string, q url.Values) string {
+	h := sha1.Sum([]byte(path + "?" + q.Encode()))
+	slug := strings.Trim(strings.ReplaceAll(path, "/", "_"), "_")
+	if slug == "" {
+		slug = "root"
+	}
+	return filepath.Join(p.Dir, p.Name, fmt.Sprintf("%s-%s.json", slug, hex.EncodeToString(h[:8])))
+}
+
+func readRecording(file string) (*Response, error) {
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNoFixture, file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec recording
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, fmt.Errorf("bad recording %s: %w", file, err)
+	}
+	h := http.Header{}
+	if rec.ContentType != "" {
+		h.Set("Content-Type", rec.ContentType)
+	}
+	return &Response{Status: rec.Status, Header: h, Body: []byte(rec.Body)}, nil
+}
+
+func writeRecording(file, u string, r *Response) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(recording{
+		URL:         u,
+		Status:      r.Status,
+		ContentType: r.Header.Get("Content-Type"),
+		Body:        string(r.Body),
+		RecordedAt:  time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// اول فایل موقت، بعد rename تا replay هم‌زمان فایل نیمه‌کاره نبیند
+	f, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
@@ -0,0 +1,232 @@
+// Package watch evaluates per-client weather watch rules ("OIII ceiling
+// below 1000 ft", "crosswind on 29L above 20 kt", "category IFR or worse")
+// against decoded METARs and TAFs.
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/wx/fltcat"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+const (
+	KindCeilingBelow    = "ceiling_below"    // threshold: ft AGL
+	KindVisibilityBelow = "visibility_below" // threshold: metres
+	KindWindAbove       = "wind_above"       // threshold: kt, gust counts
+	KindCrosswindAbove  = "crosswind_above"  // threshold: kt, needs runway
+	KindCategory        = "category"         // category or worse, e.g. IFR matches IFR and LIFR
+
+	SourceMETAR = "metar"
+	SourceTAF   = "taf"
+
+	DefaultLookaheadHours = 6
+	MaxLookaheadHours     = 30
+)
+
+var (
+	icaoRe   = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	runwayRe = regexp.MustCompile(`^(\d{2})[LCR]?$`)
+)
+
+// Rule is what a client registers. For SourceTAF the rule matches when any
+// hour of the next LookaheadHours matches (prevailing conditions, or the
+// worst case including TEMPO/PROB groups when IncludeTempo is set).
+type Rule struct {
+	ICAO           string  `bson:"icao"                      json:"icao"`
+	Kind           string  `bson:"kind"                      json:"kind"`
+	Threshold      float64 `bson:"threshold,omitempty"       json:"threshold,omitempty"`
+	Category       string  `bson:"category,omitempty"        json:"category,omitempty"`
+	Runway         string  `bson:"runway,omitempty"          json:"runway,omitempty"` // e.g. 29L; heading taken from the designator
+	Source         string  `bson:"source"                    json:"source"`           // metar | taf
+	LookaheadHours int     `bson:"lookahead_hours,omitempty" json:"lookahead_hours,omitempty"`
+	IncludeTempo   bool    `bson:"include_tempo,omitempty"   json:"include_tempo,omitempty"`
+}
+
+// Result is one evaluation of a rule.
+type Result struct {
+	Match    bool      `json:"match"`
+	Value    *float64  `json:"value,omitempty"`    // ceiling/visibility/wind of the worst matching point
+	Category string    `json:"category,omitempty"` // for KindCategory
+	At       time.Time `json:"at"`                 // observation time, or forecast hour for TAF rules
+	Raw      string    `json:"raw,omitempty"`
+}
+
+// ErrNoData means the product the rule needs was not available.
+var ErrNoData = errors.New("no report for station")
+
+// Normalize upper-cases codes, fills defaults and validates the rule.
+func (r *Rule) Normalize() error {
+	r.ICAO = strings.ToUpper(strings.TrimSpace(r.ICAO))
+	r.Kind = strings.ToLower(strings.TrimSpace(r.Kind))
+	r.Source = strings.ToLower(strings.TrimSpace(r.Source))
+	r.Category = strings.ToUpper(strings.TrimSpace(r.Category))
+	r.Runway = strings.ToUpper(strings.TrimSpace(r.Runway))
+	if !icaoRe.MatchString(r.ICAO) {
+		return errors.New("invalid icao")
+	}
+	switch r.Source {
+	case "":
+		r.Source = SourceMETAR
+	case SourceMETAR, SourceTAF:
+	default:
+		return fmt.Errorf("invalid source %q", r.Source)
+	}
+	if r.Source == SourceTAF {
+		if r.LookaheadHours == 0 {
+			r.LookaheadHours = DefaultLookaheadHours
+		}
+		if r.LookaheadHours < 1 || r.LookaheadHours > MaxLookaheadHours {
+			return fmt.Errorf("lookahead_hours must be 1..%d", MaxLookaheadHours)
+		}
+	} else {
+		r.LookaheadHours, r.IncludeTempo = 0, false
+	}
+	switch r.Kind {
+	case KindCeilingBelow, KindVisibilityBelow, KindWindAbove:
+		if r.Threshold <= 0 {
+			return errors.New("threshold must be > 0")
+		}
+	case KindCrosswindAbove:
+		if r.Threshold <= 0 {
+			return errors.New("threshold must be > 0")
+		}
+		if _, err := runwayHeading(r.Runway); err != nil {
+			return err
+		}
+	case KindCategory:
+		switch r.Category {
+		case fltcat.MVFR, fltcat.IFR, fltcat.LIFR:
+		default:
+			return errors.New("category must be MVFR, IFR or LIFR")
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", r.Kind)
+	}
+	return nil
+}
+
+// Describe is a short human label, used in webhook payloads.
+func (r Rule) Describe() string {
+	var s string
+	switch r.Kind {
+	case KindCeilingBelow:
+		s = fmt.Sprintf("%s ceiling below %g ft", r.ICAO, r.Threshold)
+	case KindVisibilityBelow:
+		s = fmt.Sprintf("%s visibility below %g m", r.ICAO, r.Threshold)
+	case KindWindAbove:
+		s = fmt.Sprintf("%s wind above %g kt", r.ICAO, r.Threshold)
+	case KindCrosswindAbove:
+		s = fmt.Sprintf("%s RWY %s crosswind above %g kt", r.ICAO, r.Runway, r.Threshold)
+	case KindCategory:
+		s = fmt.Sprintf("%s %s or worse", r.ICAO, r.Category)
+	}
+	if r.Source == SourceTAF {
+		s += fmt.Sprintf(" (TAF, next %dh)", r.LookaheadHours)
+	}
+	return s
+}
+
+// EvaluateMETAR checks the rule against the latest observation.
+func (r Rule) EvaluateMETAR(m *metar.Report, rules fltcat.Rules) (Result, error) {
+	if m == nil || m.Nil {
+		return Result{}, ErrNoData
+	}
+	res := r.check(m.Conditions, rules)
+	res.At, res.Raw = m.Time, m.Raw
+	return res, nil
+}
+
+// EvaluateTAF checks every hour of [now, now+LookaheadHours) and reports the
+// first matching hour, or the last checked one when nothing matches.
+func (r Rule) EvaluateTAF(t *taf.TAF, now time.Time, rules fltcat.Rules) (Result, error) {
+	if t == nil || t.Nil || t.Cancelled {
+		return Result{}, ErrNoData
+	}
+	slots := t.Timeline(now, now.Add(time.Duration(r.LookaheadHours)*time.Hour), time.Hour)
+	if len(slots) == 0 {
+		return Result{}, ErrNoData
+	}
+	var last Result
+	for _, s := range slots {
+		c := s.Prevailing
+		if r.IncludeTempo {
+			c = s.WorstCase
+		}
+		last = r.check(c, rules)
+		last.At, last.Raw = s.From, t.Raw
+		if last.Match {
+			return last, nil
+		}
+	}
+	return last, nil
+}
+
+func (r Rule) check(c metar.Conditions, rules fltcat.Rules) Result {
+	var res Result
+	switch r.Kind {
+	case KindCeilingBelow:
+		if ceil := c.Ceiling(); ceil != nil {
+			res.Value = f64(float64(*ceil))
+			res.Match = float64(*ceil) < r.Threshold
+		}
+	case KindVisibilityBelow:
+		if c.Visibility != nil {
+			res.Value = f64(c.Visibility.Meters)
+			res.Match = c.Visibility.Meters < r.Threshold
+		}
+	case KindWindAbove:
+		if c.Wind != nil {
+			v := math.Max(c.Wind.SpeedKt(), c.Wind.GustKt())
+			res.Value = f64(math.Round(v))
+			res.Match = v > r.Threshold
+		}
+	case KindCrosswindAbove:
+		hdg, _ := runwayHeading(r.Runway)
+		if c.Wind != nil {
+			v := c.Wind.Crosswind(hdg)
+			res.Value = f64(v)
+			res.Match = v > r.Threshold
+		}
+	case KindCategory:
+		res.Category = rules.FromConditions(c)
+		res.Match = rank(res.Category) >= rank(r.Category)
+	}
+	return res
+}
+
+// runwayHeading reads the heading from the designator (29L → 290°).
+// METAR wind is true and the designator magnetic; for a threshold alert the
+// variation is accepted.
+func runwayHeading(rwy string) (float64, error) {
+	m := runwayRe.FindStringSubmatch(rwy)
+	if m == nil {
+		return 0, errors.New("runway must look like 29 or 29L")
+	}
+	n, _ := strconv.Atoi(m[1])
+	if n < 1 || n > 36 {
+		return 0, errors.New("runway must be 01..36")
+	}
+	return float64(n * 10), nil
+}
+
+func rank(cat string) int {
+	switch cat {
+	case fltcat.LIFR:
+		return 3
+	case fltcat.IFR:
+		return 2
+	case fltcat.MVFR:
+		return 1
+	}
+	return 0
+}
+
+func f64(v float64) *float64 { return &v }
@@ -0,0 +1,177 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	EventMatch = "match" // rule started matching
+	EventClear = "clear" // rule stopped matching
+)
+
+// Payload is the webhook body.
+type Payload struct {
+	Event       string    `json:"event"`
+	DeliveryID  string    `json:"delivery_id"`
+	RuleID      string    `json:"rule_id"`
+	Description string    `json:"description"`
+	Rule        Rule      `json:"rule"`
+	Result      Result    `json:"result"`
+	SentAt      time.Time `json:"sent_at"`
+}
+
+// webhookClient only connects to public addresses (checked on the address
+// actually dialled, so DNS rebinding does not help) and never follows
+// redirects; webhook URLs come from API clients.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil, // با proxy، بررسی آدرس روی خود proxy انجام می‌شد
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip, err := netip.ParseAddr(host)
+				if err != nil || !publicAddr(ip) {
+					return fmt.Errorf("webhook target %s is not a public address", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConnsPerHost: 2,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse // 3xx مثل هر پاسخ غیر 2xx خطاست
+	},
+}
+
+// ranges that IsGlobalUnicast/IsPrivate do not cover but are not the public internet
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 به IPv4 داخلی
+}
+
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateURL accepts absolute https URLs (http only when allowHTTP) whose
+// host resolves to public addresses only; loopback, private and link-local
+// targets are refused.
+func ValidateURL(ctx context.Context, raw string, allowHTTP bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil {
+		return errors.New("invalid webhook_url")
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && allowHTTP) {
+		return errors.New("webhook_url must be https")
+	}
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(ip) {
+			return errors.New("webhook_url must point to a public address")
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("webhook_url host %s does not resolve", host)
+	}
+	for _, ip := range addrs {
+		if !publicAddr(ip) {
+			return errors.New("webhook_url must point to a public address")
+		}
+	}
+	return nil
+}
+
+// NewID returns a random hex id for rules and deliveries.
+func NewID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Sign is what the receiver recomputes to check X-Signature:
+// Base64(HMAC-SHA256(X-Date + "\n" + X-Delivery-Id + "\n" + sha256hex(body), secret))
+// with the same client secret (and key version) used to call the API.
+func Sign(secretB64, date, deliveryID string, body []byte) (string, error) {
+	secret, err := base64.StdEncoding.DecodeString(secretB64)
+	if err != nil {
+		return "", fmt.Errorf("bad secret encoding: %w", err)
+	}
+	h := sha256.Sum256(body)
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(date + "\n" + deliveryID + "\n" + hex.EncodeToString(h[:])))
+	return base64.StdEncoding.EncodeToString(m.Sum(nil)), nil
+}
+
+// Deliver POSTs p to target, signed with the client's secret. Any non-2xx
+// answer is an error so the caller keeps the old state and retries.
+func Deliver(ctx context.Context, target, keyVersion, secretB64 string, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	date := strconv.FormatInt(p.SentAt.Unix(), 10)
+	sig, err := Sign(secretB64, date, p.DeliveryID, body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SepTaf-Watch/1.0")
+	req.Header.Set("X-Event", p.Event)
+	req.Header.Set("X-Delivery-Id", p.DeliveryID)
+	req.Header.Set("X-Date", date)
+	req.Header.Set("X-Key-Version", keyVersion)
+	req.Header.Set("X-Signature", sig)
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook http %d", resp.StatusCode)
+	}
+	return nil
+}
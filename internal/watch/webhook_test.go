@@ -0,0 +1,71 @@
+package watch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		allowHTTP bool
+		ok        bool
+	}{
+		{"https://8.8.8.8/hook", false, true},
+		{"https://[2606:4700:4700::1111]/hook", false, true},
+		{"http://8.8.8.8/hook", false, false},
+		{"http://8.8.8.8/hook", true, true},
+		{"ftp://8.8.8.8/hook", true, false},
+		{"/relative", false, false},
+		{"https://user:pw@8.8.8.8/hook", false, false},
+		{"https://127.0.0.1/hook", false, false},
+		{"https://localhost:8443/hook", false, false},
+		{"https://10.1.2.3/hook", false, false},
+		{"https://192.168.1.10/hook", false, false},
+		{"https://169.254.169.254/latest/meta-data", false, false},
+		{"https://100.64.0.1/hook", false, false},
+		{"https://[::1]/hook", false, false},
+		{"https://[fd00::1]/hook", false, false},
+		{"https://[::ffff:127.0.0.1]/hook", false, false},
+		{"https://0.0.0.0/hook", false, false},
+	}
+	for _, tc := range tests {
+		err := ValidateURL(context.Background(), tc.url, tc.allowHTTP)
+		if (err == nil) != tc.ok {
+			t.Errorf("ValidateURL(%q, %v) = %v, want ok=%v", tc.url, tc.allowHTTP, err, tc.ok)
+		}
+	}
+}
+
+func TestDeliverRefusesLoopback(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+
+	secret := "c2VjcmV0" // "secret"
+	err := Deliver(context.Background(), srv.URL, "v1", secret, Payload{Event: EventMatch, DeliveryID: "d1", SentAt: time.Now()})
+	if err == nil || !strings.Contains(err.Error(), "not a public address") {
+		t.Fatalf("Deliver to %s: err = %v, want refusal", srv.URL, err)
+	}
+	if hit {
+		t.Fatal("request reached the loopback server")
+	}
+}
+
+func TestSign(t *testing.T) {
+	a, err := Sign("c2VjcmV0", "1700000000", "d1", []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Sign("c2VjcmV0", "1700000001", "d1", []byte(`{}`))
+	if a == "" || a == b {
+		t.Errorf("Sign: %q vs %q", a, b)
+	}
+	if _, err := Sign("not base64!", "1", "d1", nil); err == nil {
+		t.Error("Sign with bad secret: expected error")
+	}
+}
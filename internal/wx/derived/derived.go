@@ -0,0 +1,121 @@
+// Package derived computes performance values from a METAR and the
+// aerodrome elevation: pressure and density altitude, relative humidity,
+// a cloud-base estimate and QFE.
+package derived
+
+import (
+	"math"
+
+	"SepTaf/internal/wx/metar"
+)
+
+const (
+	StdPressureHPa = 1013.25
+	FtPerM         = 3.28084
+	// spread × 400 ft ≈ base of convective cloud (dry adiabatic vs dewpoint lapse)
+	CloudBaseFtPerC = 400
+)
+
+// Values are the derived numbers; each is nil when an input is missing
+// (e.g. no altimeter group or no dewpoint).
+type Values struct {
+	ElevationFt        int      `json:"elevationFt"`
+	PressureAltitudeFt *int     `json:"pressureAltitudeFt,omitempty"`
+	DensityAltitudeFt  *int     `json:"densityAltitudeFt,omitempty"`
+	ISATempC           *float64 `json:"isaTempC,omitempty"` // ISA temperature at the pressure altitude
+	ISADeviationC      *float64 `json:"isaDeviationC,omitempty"`
+	RelativeHumidity   *float64 `json:"relativeHumidity,omitempty"` // %
+	CloudBaseFtAGL     *int     `json:"cloudBaseFtAgl,omitempty"`   // convective estimate from the spread
+	QFEHPa             *float64 `json:"qfeHpa,omitempty"`
+	QFEInHg            *float64 `json:"qfeInHg,omitempty"`
+	QNHHPa             *float64 `json:"qnhHpa,omitempty"` // as reported, for reference
+}
+
+// Compute derives the values for an aerodrome at elevFt.
+func Compute(rep *metar.Report, elevFt int) Values {
+	v := Values{ElevationFt: elevFt}
+	if rep == nil {
+		return v
+	}
+	elevM := float64(elevFt) / FtPerM
+
+	var qfe float64
+	if rep.Altimeter != nil && rep.Altimeter.HPa > 0 {
+		qnh := rep.Altimeter.HPa
+		v.QNHHPa = f1(qnh)
+		qfe = StationPressure(qnh, elevM)
+		v.QFEHPa = f1(qfe)
+		v.QFEInHg = f2(qfe / metar.HPaPerInHg)
+		pa := PressureAltitudeFt(qfe)
+		v.PressureAltitudeFt = iptr(pa)
+		isa := 15 - 1.98*pa/1000
+		v.ISATempC = f1(isa)
+		if rep.Temperature != nil {
+			v.ISADeviationC = f1(float64(*rep.Temperature) - isa)
+		}
+	}
+	if rep.Temperature != nil && rep.Dewpoint != nil {
+		t, td := float64(*rep.Temperature), float64(*rep.Dewpoint)
+		v.RelativeHumidity = f1(RelativeHumidity(t, td))
+		spread := math.Max(t-td, 0)
+		v.CloudBaseFtAGL = iptr(spread * CloudBaseFtPerC)
+	}
+	if qfe > 0 && rep.Temperature != nil {
+		t := float64(*rep.Temperature)
+		td := math.NaN()
+		if rep.Dewpoint != nil {
+			td = float64(*rep.Dewpoint)
+		}
+		v.DensityAltitudeFt = iptr(DensityAltitudeFt(qfe, t, td))
+	}
+	return v
+}
+
+// StationPressure reduces the altimeter setting (QNH, hPa) to the station
+// elevation (NWS formula).
+func StationPressure(qnhHPa, elevM float64) float64 {
+	return math.Pow(math.Pow(qnhHPa, 0.190263)-8.417286e-5*elevM, 1/0.190263)
+}
+
+// PressureAltitudeFt is the ISA altitude of a station pressure.
+func PressureAltitudeFt(stationHPa float64) float64 {
+	return 145366.45 * (1 - math.Pow(stationHPa/StdPressureHPa, 0.190284))
+}
+
+// DensityAltitudeFt uses the virtual temperature, so humidity is included
+// when tdC is known (pass NaN for a dry estimate).
+func DensityAltitudeFt(stationHPa, tC, tdC float64) float64 {
+	tv := tC + 273.15
+	if !math.IsNaN(tdC) {
+		e := VaporPressure(tdC)
+		tv = tv / (1 - (e/stationHPa)*(1-0.622))
+	}
+	pInHg := stationHPa / metar.HPaPerInHg
+	tR := tv * 9 / 5 // Rankine
+	return 145442.16 * (1 - math.Pow(17.326*pInHg/tR, 0.235))
+}
+
+// VaporPressure is the saturation vapour pressure in hPa (Magnus, over water).
+func VaporPressure(tC float64) float64 {
+	return 6.1078 * math.Pow(10, 7.5*tC/(237.3+tC))
+}
+
+// RelativeHumidity in percent from temperature and dewpoint.
+func RelativeHumidity(tC, tdC float64) float64 {
+	return math.Min(100, 100*VaporPressure(tdC)/VaporPressure(tC))
+}
+
+func iptr(v float64) *int {
+	n := int(math.Round(v))
+	return &n
+}
+
+func f1(v float64) *float64 {
+	r := math.Round(v*10) / 10
+	return &r
+}
+
+func f2(v float64) *float64 {
+	r := math.Round(v*100) / 100
+	return &r
+}
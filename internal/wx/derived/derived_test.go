@@ -0,0 +1,90 @@
+package derived
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+	"SepTaf/internal/wx/metar"
+)
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		raw    string
+		elevFt int
+		want   Values
+	}{
+		{
+			// hot day at Mehrabad: PA ≈ 3962 + (1013.25-1008)×27
+			raw:    "METAR OIII 141200Z 27010KT 9999 FEW040 40/05 Q1008",
+			elevFt: 3962,
+			want: Values{
+				ElevationFt: 3962, PressureAltitudeFt: testutil.Ptr(4104), DensityAltitudeFt: testutil.Ptr(7911),
+				ISATempC: testutil.Ptr(6.9), ISADeviationC: testutil.Ptr(33.1), RelativeHumidity: testutil.Ptr(11.8),
+				CloudBaseFtAGL: testutil.Ptr(14000), QFEHPa: testutil.Ptr(871.7), QFEInHg: testutil.Ptr(25.74), QNHHPa: testutil.Ptr(1008.0),
+			},
+		},
+		{
+			// A-group altimeter: PA ≈ 5434 + (29.92-30.02)×1000
+			raw:    "METAR KDEN 141153Z 16008KT 10SM FEW080 25/M05 A3002",
+			elevFt: 5434,
+			want: Values{
+				ElevationFt: 5434, PressureAltitudeFt: testutil.Ptr(5340), DensityAltitudeFt: testutil.Ptr(7757),
+				ISATempC: testutil.Ptr(4.4), ISADeviationC: testutil.Ptr(20.6), RelativeHumidity: testutil.Ptr(13.3),
+				CloudBaseFtAGL: testutil.Ptr(12000), QFEHPa: testutil.Ptr(832.3), QFEInHg: testutil.Ptr(24.58), QNHHPa: testutil.Ptr(1016.6),
+			},
+		},
+		{
+			// cold day: density altitude below the field elevation
+			raw:    "METAR KDEN 141153Z 16008KT 10SM FEW080 M10/M12 A2992",
+			elevFt: 5434,
+			want: Values{
+				ElevationFt: 5434, PressureAltitudeFt: testutil.Ptr(5433), DensityAltitudeFt: testutil.Ptr(3746),
+				ISATempC: testutil.Ptr(4.2), ISADeviationC: testutil.Ptr(-14.2), RelativeHumidity: testutil.Ptr(85.2),
+				CloudBaseFtAGL: testutil.Ptr(800), QFEHPa: testutil.Ptr(829.4), QFEInHg: testutil.Ptr(24.49), QNHHPa: testutil.Ptr(1013.2),
+			},
+		},
+		{
+			// no altimeter: nothing pressure based
+			raw:    "METAR OIII 141200Z 27010KT 9999 FEW040 20/10",
+			elevFt: 3962,
+			want:   Values{ElevationFt: 3962, RelativeHumidity: testutil.Ptr(52.5), CloudBaseFtAGL: testutil.Ptr(4000)},
+		},
+	}
+	ref := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	for _, tc := range tests {
+		r, err := metar.Parse(tc.raw, ref)
+		if err != nil {
+			t.Fatalf("metar.Parse(%q): %v", tc.raw, err)
+		}
+		testutil.Eq(t, r.Station, Compute(r, tc.elevFt), tc.want)
+	}
+	testutil.Eq(t, "nil report", Compute(nil, 100), Values{ElevationFt: 100})
+}
+
+func TestFormulas(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want float64
+		tol       float64
+	}{
+		{"QFE at sea level", StationPressure(1013.25, 0), 1013.25, 0.01},
+		{"QFE at 1000 m", StationPressure(1013.25, 1000), 898.75, 0.05},
+		{"PA at 1013.25", PressureAltitudeFt(1013.25), 0, 0.01},
+		{"PA at 898.75 hPa", PressureAltitudeFt(898.75), 3281, 3}, // 1000 m
+		{"PA at 500 hPa", PressureAltitudeFt(500), 18289, 10},
+		{"DA dry ISA", DensityAltitudeFt(1013.25, 15, math.NaN()), 0, 20},
+		// humid air is lighter: the same pressure and temperature give a higher DA
+		{"DA humid ISA", DensityAltitudeFt(1013.25, 15, 15), 235, 20},
+		{"RH 20/10", RelativeHumidity(20, 10), 52.5, 0.1},
+		{"RH 0/-10", RelativeHumidity(0, -10), 46.8, 0.1},
+		{"RH saturated", RelativeHumidity(15, 15), 100, 0},
+		{"RH dewpoint above temperature", RelativeHumidity(10, 12), 100, 0},
+	}
+	for _, tc := range tests {
+		if math.Abs(tc.got-tc.want) > tc.tol {
+			t.Errorf("%s = %.2f, want %.2f ± %v", tc.name, tc.got, tc.want, tc.tol)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package fltcat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"SepTaf/internal/wx/metar"
+)
+
+const (
+	VFR  = "VFR"
+	MVFR = "MVFR"
+	IFR  = "IFR"
+	LIFR = "LIFR"
+	UNKN = "UNKN"
+)
+
+// Level is one category threshold. A report falls into the level when its
+// ceiling or visibility is below (or, if Inclusive, at or below) the limit.
+type Level struct {
+	Category  string  `json:"category"`
+	CeilingFt int     `json:"ceilingFt"`
+	VisSM     float64 `json:"visSM"`
+	Inclusive bool    `json:"inclusive,omitempty"`
+}
+
+// Rules is an ordered set of levels, most restrictive first. Anything that
+// matches no level is VFR.
+type Rules struct {
+	Name   string  `json:"name"`
+	Levels []Level `json:"levels"`
+}
+
+// FAA is the US definition (AIM 7-1-7): LIFR <500/<1, IFR <1000/<3,
+// MVFR 1000-3000/3-5.
+var FAA = Rules{
+	Name: "FAA",
+	Levels: []Level{
+		{Category: LIFR, CeilingFt: 500, VisSM: 1},
+		{Category: IFR, CeilingFt: 1000, VisSM: 3},
+		{Category: MVFR, CeilingFt: 3000, VisSM: 5, Inclusive: true},
+	},
+}
+
+// ParseRules reads a rules spec. "FAA" (or empty) selects the preset;
+// otherwise the spec is a comma list of CAT<ceil/vis or CAT<=ceil/vis,
+// e.g. "LIFR<500/1,IFR<800/2,MVFR<=2000/4".
+func ParseRules(spec string) (Rules, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.EqualFold(spec, "FAA") {
+		return FAA, nil
+	}
+	out := Rules{Name: "custom"}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		lv := Level{}
+		var lim string
+		if cat, rest, ok := strings.Cut(part, "<="); ok {
+			lv.Category, lim, lv.Inclusive = cat, rest, true
+		} else if cat, rest, ok := strings.Cut(part, "<"); ok {
+			lv.Category, lim = cat, rest
+		} else {
+			return Rules{}, fmt.Errorf("bad rule %q", part)
+		}
+		switch lv.Category {
+		case LIFR, IFR, MVFR:
+		default:
+			return Rules{}, fmt.Errorf("unknown category %q", lv.Category)
+		}
+		c, v, ok := strings.Cut(lim, "/")
+		if !ok {
+			return Rules{}, fmt.Errorf("bad rule %q", part)
+		}
+		var err error
+		if lv.CeilingFt, err = strconv.Atoi(c); err != nil {
+			return Rules{}, fmt.Errorf("bad ceiling in %q", part)
+		}
+		if lv.VisSM, err = strconv.ParseFloat(v, 64); err != nil {
+			return Rules{}, fmt.Errorf("bad visibility in %q", part)
+		}
+		out.Levels = append(out.Levels, lv)
+	}
+	return out, nil
+}
+
+// Compute classifies a ceiling (ft AGL, nil = no ceiling) and visibility
+// (SM, nil = unknown). With neither value known the result is UNKN.
+func (r Rules) Compute(ceilingFt *int, visSM *float64) string {
+	if ceilingFt == nil && visSM == nil {
+		return UNKN
+	}
+	for _, lv := range r.Levels {
+		if ceilingFt != nil && below(float64(*ceilingFt), float64(lv.CeilingFt), lv.Inclusive) {
+			return lv.Category
+		}
+		if visSM != nil && below(*visSM, lv.VisSM, lv.Inclusive) {
+			return lv.Category
+		}
+	}
+	return VFR
+}
+
+// FromConditions classifies decoded METAR/TAF conditions.
+func (r Rules) FromConditions(c metar.Conditions) string {
+	ceil := c.Ceiling()
+	var vis *float64
+	if c.Visibility != nil {
+		v := c.Visibility.Meters / metar.MetersPerSM
+		vis = &v
+	}
+	if ceil == nil && vis == nil && (c.CAVOK || c.SkyClear != "" || len(c.Clouds) > 0) {
+		// sky known, visibility missing: the ceiling part alone says VFR
+		return VFR
+	}
+	return r.Compute(ceil, vis)
+}
+
+func below(v, limit float64, inclusive bool) bool {
+	if inclusive {
+		return v <= limit
+	}
+	return v < limit
+}
+
+// Color is the conventional map colour for a category.
+func Color(cat string) string {
+	switch cat {
+	case VFR:
+		return "#00a651"
+	case MVFR:
+		return "#0072bc"
+	case IFR:
+		return "#ed1c24"
+	case LIFR:
+		return "#ec008c"
+	}
+	return "#9e9e9e"
+}
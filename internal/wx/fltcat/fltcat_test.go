@@ -0,0 +1,97 @@
+package fltcat
+
+import (
+	"reflect"
+	"testing"
+
+	"SepTaf/internal/testutil"
+	"SepTaf/internal/wx/metar"
+)
+
+func TestParseRules(t *testing.T) {
+	for _, spec := range []string{"", "  ", "FAA", "faa"} {
+		if r, err := ParseRules(spec); err != nil || !reflect.DeepEqual(r, FAA) {
+			t.Errorf("ParseRules(%q) = %+v, %v; want FAA", spec, r, err)
+		}
+	}
+
+	r, err := ParseRules(" lifr<500/1, IFR<800/2,MVFR<=2000/4 ")
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+	testutil.Eq(t, "custom", r, Rules{Name: "custom", Levels: []Level{
+		{Category: LIFR, CeilingFt: 500, VisSM: 1},
+		{Category: IFR, CeilingFt: 800, VisSM: 2},
+		{Category: MVFR, CeilingFt: 2000, VisSM: 4, Inclusive: true},
+	}})
+
+	for _, spec := range []string{"VFR<5000/10", "IFR1000/3", "IFR<1000", "IFR<1000.5/3", "IFR<1000/3SM", "IFR<1000/3,"} {
+		if r, err := ParseRules(spec); err == nil {
+			t.Errorf("ParseRules(%q) = %+v, want error", spec, r)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	custom, err := ParseRules("LIFR<500/1,IFR<800/2,MVFR<=2000/4")
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+	tests := []struct {
+		rules Rules
+		ceil  *int
+		vis   *float64
+		want  string
+	}{
+		{FAA, nil, nil, UNKN},
+		{FAA, nil, testutil.Ptr(10.0), VFR},
+		{FAA, testutil.Ptr(499), nil, LIFR},
+		// "<" levels: the limit itself falls into the next category
+		{FAA, testutil.Ptr(500), nil, IFR},
+		{FAA, nil, testutil.Ptr(1.0), IFR},
+		{FAA, testutil.Ptr(1000), testutil.Ptr(10.0), MVFR},
+		{FAA, nil, testutil.Ptr(3.0), MVFR},
+		// MVFR is inclusive: 3000 ft and 5 SM are still MVFR
+		{FAA, testutil.Ptr(3000), testutil.Ptr(10.0), MVFR},
+		{FAA, testutil.Ptr(3100), testutil.Ptr(5.0), MVFR},
+		{FAA, testutil.Ptr(3100), testutil.Ptr(5.5), VFR},
+		// the worse of ceiling and visibility wins
+		{FAA, testutil.Ptr(5000), testutil.Ptr(0.5), LIFR},
+		{custom, testutil.Ptr(800), nil, MVFR},
+		{custom, testutil.Ptr(799), nil, IFR},
+		{custom, testutil.Ptr(2000), nil, MVFR},
+		{custom, testutil.Ptr(2001), testutil.Ptr(4.5), VFR},
+		{custom, nil, testutil.Ptr(4.0), MVFR},
+	}
+	for _, tc := range tests {
+		if got := tc.rules.Compute(tc.ceil, tc.vis); got != tc.want {
+			t.Errorf("%s Compute(%v, %v) = %s, want %s", tc.rules.Name, deref(tc.ceil), deref(tc.vis), got, tc.want)
+		}
+	}
+}
+
+func TestFromConditions(t *testing.T) {
+	tests := []struct {
+		c    metar.Conditions
+		want string
+	}{
+		{metar.Conditions{}, UNKN},
+		// sky known, visibility missing
+		{metar.Conditions{SkyClear: "SKC"}, VFR},
+		{metar.Conditions{Visibility: &metar.Visibility{Meters: 1500}}, LIFR},
+		{metar.Conditions{Visibility: &metar.Visibility{Meters: 9000}, Clouds: []metar.Cloud{{Cover: "BKN", BaseFt: testutil.Ptr(800)}}}, IFR},
+	}
+	for _, tc := range tests {
+		if got := FAA.FromConditions(tc.c); got != tc.want {
+			t.Errorf("FromConditions(%+v) = %s, want %s", tc.c, got, tc.want)
+		}
+	}
+}
+
+// deref shows an optional value in failure messages.
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,147 @@
+// Package gts splits WMO/GTS alphanumeric bulletin files (WMO-No. 386)
+// into individual METAR, SPECI and TAF reports.
+//
+// A bulletin starts with an abbreviated heading "TTAAii CCCC YYGGgg [BBB]",
+// optionally preceded by SOH/ZCZC and a channel sequence number, and ends
+// at ETX/NNNN or the next heading. Each report is terminated by "=".
+package gts
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/wx/metar"
+)
+
+var (
+	headingRe = regexp.MustCompile(`^([A-Z]{4}\d{2})\s+([A-Z]{4})\s+(\d{2})(\d{2})(\d{2})(?:\s+([A-Z]{3}))?$`)
+	seqRe     = regexp.MustCompile(`^\d{3,5}$`)
+)
+
+// Bulletin is one GTS bulletin and the reports it carries.
+type Bulletin struct {
+	Heading string    // "SAXX99 OIII 101300 RRA"
+	TTAAii  string    // "SAXX99"
+	CCCC    string    // originating centre
+	Time    time.Time // YYGGgg resolved against the reference time
+	BBB     string    // RRx | CCx | AAx, if any
+	Kind    string    // METAR | SPECI | TAF
+	Reports []string  // one raw report each, type word first, without "="
+}
+
+// Kind maps the T1T2 data designator to a report type; other bulletin
+// types return "".
+func Kind(ttaaii string) string {
+	if len(ttaaii) < 2 {
+		return ""
+	}
+	switch ttaaii[:2] {
+	case "SA":
+		return "METAR"
+	case "SP":
+		return "SPECI"
+	case "FC", "FT":
+		return "TAF"
+	}
+	return ""
+}
+
+// Split reads all bulletins in data. Bulletins of other types (SIGMET,
+// synop, ...) are returned with an empty Kind and no reports.
+func Split(data []byte, ref time.Time) []Bulletin {
+	var (
+		out  []Bulletin
+		cur  *Bulletin
+		body []string
+	)
+	flush := func() {
+		if cur != nil {
+			if cur.Kind != "" {
+				cur.Reports = reports(cur.Kind, body)
+			}
+			out = append(out, *cur)
+		}
+		cur, body = nil, nil
+	}
+
+	// ETX ends a bulletin like NNNN does
+	data = bytes.ReplaceAll(data, []byte{'\x03'}, []byte("\nNNNN\n"))
+	data = bytes.Map(func(r rune) rune {
+		switch r {
+		case '\x01', '\r':
+			return '\n'
+		}
+		return r
+	}, data)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.ToUpper(strings.Join(strings.Fields(sc.Text()), " "))
+		switch {
+		case line == "":
+			continue
+		case line == "NNNN":
+			flush()
+			continue
+		case strings.HasPrefix(line, "ZCZC"), seqRe.MatchString(line) && cur == nil:
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			flush()
+			d, _ := strconv.Atoi(m[3])
+			h, _ := strconv.Atoi(m[4])
+			mi, _ := strconv.Atoi(m[5])
+			cur = &Bulletin{
+				Heading: line,
+				TTAAii:  m[1],
+				CCCC:    m[2],
+				Time:    metar.DayTime(ref, d, h, mi),
+				BBB:     m[6],
+				Kind:    Kind(m[1]),
+			}
+			continue
+		}
+		if cur != nil {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return out
+}
+
+// reports splits a bulletin text on "=" and makes every report start with
+// its type word. A leading "METAR"/"TAF [AMD|COR]" line applies to all
+// reports that do not carry their own.
+func reports(kind string, lines []string) []string {
+	text := strings.Join(lines, " ")
+	prefix := kind
+	toks := strings.Fields(text)
+	if len(toks) > 0 && isType(toks[0]) {
+		prefix = toks[0]
+		n := 1
+		if prefix == "TAF" && len(toks) > 1 && (toks[1] == "AMD" || toks[1] == "COR") {
+			prefix += " " + toks[1]
+			n = 2
+		}
+		text = strings.Join(toks[n:], " ")
+	}
+
+	var out []string
+	for _, part := range strings.Split(text, "=") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if f := strings.Fields(part); !isType(f[0]) {
+			part = prefix + " " + part
+		}
+		out = append(out, part)
+	}
+	return out
+}
+
+func isType(tok string) bool { return tok == "METAR" || tok == "SPECI" || tok == "TAF" }
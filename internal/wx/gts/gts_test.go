@@ -0,0 +1,74 @@
+package gts
+
+import (
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+)
+
+const file = "\x01\r\r\n123 \r\r\n" +
+	"SAIR31 OIII 141200\r\r\n" +
+	"METAR OIII 141200Z 27010KT 9999 SCT030 18/02 Q1013=\r\r\n" +
+	"OIMM 141200Z 09005KT CAVOK\r\r\n 20/M01 Q1018 NOSIG=\r\r\n" +
+	"SPECI OIFM 141210Z 30025G35KT 2000 DU=\r\r\n\x03" +
+	"ZCZC 456\n" +
+	"FTIR31 OIII 141100 AAA\n" +
+	"TAF AMD\n" +
+	"OIII 141100Z 1412/1512 27010KT 9999 SCT030=\n" +
+	"TAF OIMM 141100Z 1412/1512 VRB03KT CAVOK=\n" +
+	"NNNN\n" +
+	"WSIR31 OIII 141130\n" +
+	"OIIX SIGMET 1 VALID 141130/141530 OIII-\n" +
+	"OIIX TEHRAN FIR EMBD TS OBS=\n" +
+	"NNNN\n" +
+	"SAIR32 OIII 282300\n" +
+	"OIAW 282300Z 00000KT 9999 FEW030 12/08 Q1015="
+
+func TestSplit(t *testing.T) {
+	bs := Split([]byte(file), testutil.Ref)
+	if len(bs) != 4 {
+		t.Fatalf("Split: %d bulletins, want 4: %+v", len(bs), bs)
+	}
+
+	testutil.Eq(t, "sa", bs[0], Bulletin{
+		Heading: "SAIR31 OIII 141200", TTAAii: "SAIR31", CCCC: "OIII",
+		Time: time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC), Kind: "METAR",
+		Reports: []string{
+			"METAR OIII 141200Z 27010KT 9999 SCT030 18/02 Q1013",
+			"METAR OIMM 141200Z 09005KT CAVOK 20/M01 Q1018 NOSIG",
+			"SPECI OIFM 141210Z 30025G35KT 2000 DU",
+		},
+	})
+
+	// the leading "TAF AMD" line applies to reports without their own type word
+	testutil.Eq(t, "ft bbb", bs[1].BBB, "AAA")
+	testutil.Eq(t, "ft kind", bs[1].Kind, "TAF")
+	testutil.Eq(t, "ft reports", bs[1].Reports, []string{
+		"TAF AMD OIII 141100Z 1412/1512 27010KT 9999 SCT030",
+		"TAF OIMM 141100Z 1412/1512 VRB03KT CAVOK",
+	})
+
+	// other bulletin types are kept but not split
+	testutil.Eq(t, "ws kind", bs[2].Kind, "")
+	testutil.Eq(t, "ws reports", bs[2].Reports, []string(nil))
+
+	// day 28 resolves to the previous month; no trailing NNNN
+	testutil.Eq(t, "previous month", bs[3].Time, time.Date(2025, 2, 28, 23, 0, 0, 0, time.UTC))
+	testutil.Eq(t, "unterminated", bs[3].Reports, []string{"METAR OIAW 282300Z 00000KT 9999 FEW030 12/08 Q1015"})
+}
+
+func TestSplitNoHeading(t *testing.T) {
+	// text before the first heading is not a bulletin
+	if bs := Split([]byte("METAR OIII 141200Z 27010KT 9999=\nNNNN\n"), testutil.Ref); len(bs) != 0 {
+		t.Errorf("Split = %+v, want none", bs)
+	}
+}
+
+func TestKind(t *testing.T) {
+	for in, want := range map[string]string{"SAIR31": "METAR", "SPUS70": "SPECI", "FCIR31": "TAF", "FTUS80": "TAF", "WSIR31": "", "S": ""} {
+		if got := Kind(in); got != want {
+			t.Errorf("Kind(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package iwxxm serialises decoded METAR/SPECI and TAF reports as ICAO
+// IWXXM 3.0 XML. Only what the TAC carries is encoded; RVR, runway state,
+// wind shear and trend forecasts are not mapped yet.
+package iwxxm
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"SepTaf/internal/wx/metar"
+)
+
+const (
+	NSIWXXM   = "http://icao.int/iwxxm/3.0"
+	NSGML     = "http://www.opengis.net/gml/3.2"
+	NSXLink   = "http://www.w3.org/1999/xlink"
+	NSAIXM    = "http://www.aixm.aero/schema/5.1.1"
+	NSCollect = "http://def.wmo.int/collect/2014"
+	Schema    = "http://icao.int/iwxxm/3.0 https://schemas.wmo.int/iwxxm/3.0/iwxxm.xsd"
+
+	ContentType = "application/xml; charset=utf-8"
+
+	codesWeather     = "http://codes.wmo.int/306/4678/"
+	codesCloudAmount = "http://codes.wmo.int/49-2/CloudAmountReportedAtAerodrome/"
+	codesCloudType   = "http://codes.wmo.int/49-2/SigConvectiveCloudType/"
+	nilNOSIG         = "http://codes.wmo.int/common/nil/nothingOfOperationalSignificance"
+	nilNotDetected   = "http://codes.wmo.int/common/nil/notDetectedByAutoSystem"
+	nilMissing       = "http://codes.wmo.int/common/nil/missing"
+)
+
+// node is a minimal XML tree; names carry their prefix ("iwxxm:METAR").
+type node struct {
+	name  string
+	attrs []xml.Attr
+	text  string
+	kids  []*node
+}
+
+func el(name string, kids ...*node) *node { return &node{name: name, kids: kids} }
+
+func (n *node) attr(k, v string) *node {
+	n.attrs = append(n.attrs, xml.Attr{Name: xml.Name{Local: k}, Value: v})
+	return n
+}
+
+func (n *node) add(kids ...*node) *node {
+	for _, k := range kids {
+		if k != nil {
+			n.kids = append(n.kids, k)
+		}
+	}
+	return n
+}
+
+func textEl(name, text string) *node { return &node{name: name, text: text} }
+
+// measure is <name uom="...">v</name>.
+func measure(name string, v float64, uom string) *node {
+	return textEl(name, strconv.FormatFloat(v, 'f', -1, 64)).attr("uom", uom)
+}
+
+func href(name, url string) *node { return el(name).attr("xlink:href", url) }
+
+func encode(root *node) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := root.encode(enc); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func (n *node) encode(enc *xml.Encoder) error {
+	start := xml.StartElement{Name: xml.Name{Local: n.name}, Attr: n.attrs}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if n.text != "" {
+		if err := enc.EncodeToken(xml.CharData(n.text)); err != nil {
+			return err
+		}
+	}
+	for _, k := range n.kids {
+		if err := k.encode(enc); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// withNamespaces adds the xmlns declarations to a document root.
+func withNamespaces(n *node) *node {
+	n.attrs = append([]xml.Attr{
+		{Name: xml.Name{Local: "xmlns:iwxxm"}, Value: NSIWXXM},
+		{Name: xml.Name{Local: "xmlns:gml"}, Value: NSGML},
+		{Name: xml.Name{Local: "xmlns:xlink"}, Value: NSXLink},
+		{Name: xml.Name{Local: "xmlns:aixm"}, Value: NSAIXM},
+		{Name: xml.Name{Local: "xmlns:xsi"}, Value: "http://www.w3.org/2001/XMLSchema-instance"},
+		{Name: xml.Name{Local: "xsi:schemaLocation"}, Value: Schema},
+	}, n.attrs...)
+	return n
+}
+
+// gmlID returns a document-unique gml:id.
+func gmlID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return fmt.Sprintf("uuid.%s-%s-%s-%s-%s", h[:8], h[8:12], h[12:16], h[16:20], h[20:])
+}
+
+func isoTime(t time.Time) string { return t.UTC().Format("2006-01-02T15:04:05Z") }
+
+func timeInstant(name string, t time.Time) *node {
+	return el(name, el("gml:TimeInstant", textEl("gml:timePosition", isoTime(t))).attr("gml:id", gmlID()))
+}
+
+func timePeriod(name string, from, to time.Time) *node {
+	return el(name, el("gml:TimePeriod",
+		textEl("gml:beginPosition", isoTime(from)),
+		textEl("gml:endPosition", isoTime(to)),
+	).attr("gml:id", gmlID()))
+}
+
+func aerodrome(icao string) *node {
+	return el("iwxxm:aerodrome",
+		el("aixm:AirportHeliport",
+			el("aixm:timeSlice",
+				el("aixm:AirportHeliportTimeSlice",
+					el("gml:validTime"),
+					textEl("aixm:interpretation", "SNAPSHOT"),
+					textEl("aixm:locationIndicatorICAO", icao),
+				).attr("gml:id", gmlID()),
+			),
+		).attr("gml:id", gmlID()),
+	)
+}
+
+func speedUOM(unit string) string {
+	switch unit {
+	case "MPS":
+		return "m/s"
+	case "KMH":
+		return "km/h"
+	}
+	return "[kn_i]"
+}
+
+// wind builds AerodromeSurfaceWind (observation) or
+// AerodromeSurfaceWindForecast (TAF).
+func wind(elem string, w *metar.Wind) *node {
+	if w == nil {
+		return nil
+	}
+	n := el(elem).attr("variableWindDirection", strconv.FormatBool(w.Variable || w.Direction == nil))
+	if w.Direction != nil {
+		n.add(measure("iwxxm:meanWindDirection", float64(*w.Direction), "deg"))
+	}
+	spd := measure("iwxxm:meanWindSpeed", float64(w.Speed), speedUOM(w.Unit))
+	n.add(spd)
+	if w.Above {
+		n.add(textEl("iwxxm:meanWindSpeedOperator", "ABOVE"))
+	}
+	if w.Gust != nil {
+		n.add(measure("iwxxm:windGustSpeed", float64(*w.Gust), speedUOM(w.Unit)))
+	}
+	if w.VarFrom != nil && w.VarTo != nil {
+		n.add(
+			measure("iwxxm:extremeClockwiseWindDirection", float64(*w.VarTo), "deg"),
+			measure("iwxxm:extremeCounterClockwiseWindDirection", float64(*w.VarFrom), "deg"),
+		)
+	}
+	return el("iwxxm:surfaceWind", n)
+}
+
+// visibility returns the prevailing visibility elements; 9999/P6SM become
+// 10000 m ABOVE.
+func visibility(v *metar.Visibility) []*node {
+	if v == nil {
+		return nil
+	}
+	m := v.Meters
+	if v.MoreThan && m < 10000 && v.Unit == "M" {
+		m = 10000
+	}
+	out := []*node{measure("iwxxm:prevailingVisibility", float64(int(m+0.5)), "m")}
+	switch {
+	case v.MoreThan:
+		out = append(out, textEl("iwxxm:prevailingVisibilityOperator", "ABOVE"))
+	case v.LessThan:
+		out = append(out, textEl("iwxxm:prevailingVisibilityOperator", "BELOW"))
+	}
+	return out
+}
+
+func weather(elem string, c metar.Conditions) []*node {
+	if c.NSW {
+		return []*node{el(elem).attr("nilReason", nilNOSIG)}
+	}
+	var out []*node
+	for _, w := range c.Weather {
+		out = append(out, href(elem, codesWeather+w.Raw))
+	}
+	return out
+}
+
+// cloud builds the cloud element; layerElem is AerodromeCloud (METAR) or
+// AerodromeCloudForecast (TAF).
+func cloud(layerElem string, c metar.Conditions) *node {
+	switch c.SkyClear {
+	case "NSC", "SKC", "CLR":
+		return el("iwxxm:cloud").attr("nilReason", nilNOSIG)
+	case "NCD":
+		return el("iwxxm:cloud").attr("nilReason", nilNotDetected)
+	}
+	if len(c.Clouds) == 0 && c.VertVisFt == nil {
+		return nil
+	}
+	ac := el(layerElem)
+	if layerElem == "iwxxm:AerodromeCloudForecast" {
+		ac.attr("gml:id", gmlID())
+	}
+	if c.VertVisFt != nil {
+		ac.add(measure("iwxxm:verticalVisibility", float64(*c.VertVisFt), "[ft_i]"))
+	}
+	for _, cl := range c.Clouds {
+		layer := el("iwxxm:CloudLayer", href("iwxxm:amount", codesCloudAmount+cl.Cover))
+		if cl.BaseFt != nil {
+			layer.add(measure("iwxxm:base", float64(*cl.BaseFt), "[ft_i]"))
+		} else {
+			layer.add(el("iwxxm:base").attr("uom", "N/A").attr("xsi:nil", "true").attr("nilReason", nilMissing))
+		}
+		if cl.Type == "CB" || cl.Type == "TCU" {
+			layer.add(href("iwxxm:cloudType", codesCloudType+cl.Type))
+		}
+		ac.add(el("iwxxm:layer", layer))
+	}
+	return el("iwxxm:cloud", ac)
+}
+
+// Bulletin wraps several IWXXM reports (each a full XML document from
+// METAR/TAF) in a WMO collect:MeteorologicalBulletin.
+func Bulletin(id string, docs [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, "<collect:MeteorologicalBulletin xmlns:collect=%q xmlns:gml=%q gml:id=%q>\n", NSCollect, NSGML, gmlID())
+	for _, d := range docs {
+		buf.WriteString("  <collect:meteorologicalInformation>\n")
+		buf.Write(bytes.TrimPrefix(bytes.TrimSpace(d), []byte(xml.Header[:len(xml.Header)-1])))
+		buf.WriteString("\n  </collect:meteorologicalInformation>\n")
+	}
+	fmt.Fprintf(&buf, "  <collect:bulletinIdentifier>%s</collect:bulletinIdentifier>\n", xmlEscape(id))
+	buf.WriteString("</collect:MeteorologicalBulletin>\n")
+	return buf.Bytes(), nil
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
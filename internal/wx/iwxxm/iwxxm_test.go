@@ -0,0 +1,272 @@
+package iwxxm
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+// elem is one decoded element: its slash-joined local-name path, attributes
+// by local name and trimmed text.
+type elem struct {
+	path  string
+	attrs map[string]string
+	text  string
+}
+
+// flatten decodes doc and fails the test if it is not well-formed.
+func flatten(t *testing.T, doc []byte) []elem {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	var stack []string
+	var out []elem
+	var open []int
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decode: %v\n%s", err, doc)
+		}
+		switch tk := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, tk.Name.Local)
+			e := elem{path: strings.Join(stack, "/"), attrs: map[string]string{}}
+			for _, a := range tk.Attr {
+				e.attrs[a.Name.Local] = a.Value
+			}
+			out = append(out, e)
+			open = append(open, len(out)-1)
+		case xml.CharData:
+			if len(open) > 0 {
+				out[open[len(open)-1]].text += strings.TrimSpace(string(tk))
+			}
+		case xml.EndElement:
+			stack, open = stack[:len(stack)-1], open[:len(open)-1]
+		}
+	}
+	return out
+}
+
+// find returns the elements whose path ends with suffix.
+func find(es []elem, suffix string) []elem {
+	var out []elem
+	for _, e := range es {
+		if e.path == suffix || strings.HasSuffix(e.path, "/"+suffix) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// texts returns the "text uom" of the elements matching suffix.
+func texts(es []elem, suffix string) []string {
+	var out []string
+	for _, e := range find(es, suffix) {
+		s := e.text
+		if u := e.attrs["uom"]; u != "" {
+			s += " " + u
+		}
+		if h := e.attrs["href"]; h != "" {
+			s += h
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestMETAR(t *testing.T) {
+	r, err := metar.Parse("METAR COR OIII 141200Z AUTO 24015G27KT 200V280 4000 1200SW -TSRA BR FEW020CB BKN030 18/M02 Q1013 RERA", testutil.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := METAR(r, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := flatten(t, doc)
+
+	root := es[0]
+	testutil.Eq(t, "root", root.path, "METAR")
+	testutil.Eq(t, "status", root.attrs["reportStatus"], "CORRECTION")
+	testutil.Eq(t, "auto", root.attrs["automatedStation"], "true")
+	testutil.Eq(t, "issue = obs", texts(es, "issueTime/TimeInstant/timePosition"), []string{"2025-03-14T12:00:00Z"})
+	testutil.Eq(t, "icao", texts(es, "locationIndicatorICAO"), []string{"OIII"})
+
+	testutil.Eq(t, "temp", texts(es, "airTemperature"), []string{"18 Cel"})
+	testutil.Eq(t, "dew", texts(es, "dewpointTemperature"), []string{"-2 Cel"})
+	testutil.Eq(t, "qnh", texts(es, "qnh"), []string{"1013 hPa"})
+	testutil.Eq(t, "wind dir", texts(es, "meanWindDirection"), []string{"240 deg"})
+	testutil.Eq(t, "wind speed", texts(es, "meanWindSpeed"), []string{"15 [kn_i]"})
+	testutil.Eq(t, "gust", texts(es, "windGustSpeed"), []string{"27 [kn_i]"})
+	testutil.Eq(t, "extremes", append(texts(es, "extremeClockwiseWindDirection"), texts(es, "extremeCounterClockwiseWindDirection")...), []string{"280 deg", "200 deg"})
+	testutil.Eq(t, "vis", texts(es, "prevailingVisibility"), []string{"4000 m"})
+	testutil.Eq(t, "min vis", texts(es, "minimumVisibility"), []string{"1200 m"})
+	testutil.Eq(t, "min vis dir", texts(es, "minimumVisibilityDirection"), []string{"225 deg"})
+	testutil.Eq(t, "weather", texts(es, "presentWeather"), []string{codesWeather + "-TSRA", codesWeather + "BR"})
+	testutil.Eq(t, "recent", texts(es, "recentWeather"), []string{codesWeather + "RA"})
+	testutil.Eq(t, "amounts", texts(es, "CloudLayer/amount"), []string{codesCloudAmount + "FEW", codesCloudAmount + "BKN"})
+	testutil.Eq(t, "bases", texts(es, "CloudLayer/base"), []string{"2000 [ft_i]", "3000 [ft_i]"})
+	testutil.Eq(t, "cloud type", texts(es, "cloudType"), []string{codesCloudType + "CB"})
+
+	// gml:ids are unique within the document
+	seen := map[string]bool{}
+	for _, e := range es {
+		if id := e.attrs["id"]; id != "" {
+			if seen[id] {
+				t.Errorf("duplicate gml:id %s", id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestMETARVariants(t *testing.T) {
+	tests := []struct {
+		name, raw string
+		check     func(t *testing.T, es []elem)
+	}{
+		{"speci cavok", "SPECI OIII 141230Z 00000KT CAVOK 15/05 Q1020", func(t *testing.T, es []elem) {
+			testutil.Eq(t, "root", es[0].path, "SPECI")
+			testutil.Eq(t, "cavok", find(es, "MeteorologicalAerodromeObservation")[0].attrs["cloudAndVisibilityOK"], "true")
+			testutil.Eq(t, "no vis", len(find(es, "visibility")), 0)
+			testutil.Eq(t, "no cloud", len(find(es, "cloud")), 0)
+		}},
+		{"nil report", "METAR OIII 141200Z NIL", func(t *testing.T, es []elem) {
+			obs := find(es, "observation")
+			testutil.Eq(t, "nil obs", len(obs), 1)
+			testutil.Eq(t, "nil reason", obs[0].attrs["nilReason"], nilMissing)
+		}},
+		{"9999 and NSC", "METAR OIII 141200Z VRB03MPS 9999 NSC 20/05 Q1013", func(t *testing.T, es []elem) {
+			testutil.Eq(t, "variable", find(es, "AerodromeSurfaceWind")[0].attrs["variableWindDirection"], "true")
+			testutil.Eq(t, "mps", texts(es, "meanWindSpeed"), []string{"3 m/s"})
+			testutil.Eq(t, "vis", texts(es, "prevailingVisibility"), []string{"10000 m"})
+			testutil.Eq(t, "vis op", texts(es, "prevailingVisibilityOperator"), []string{"ABOVE"})
+			testutil.Eq(t, "nsc", find(es, "cloud")[0].attrs["nilReason"], nilNOSIG)
+		}},
+		{"us units", "METAR KJFK 141151Z 27010KT 1/4SM FG VV002 M01/M02 A2992", func(t *testing.T, es []elem) {
+			testutil.Eq(t, "vis", texts(es, "prevailingVisibility"), []string{"402 m"})
+			testutil.Eq(t, "vv", texts(es, "verticalVisibility"), []string{"200 [ft_i]"})
+			testutil.Eq(t, "qnh", texts(es, "qnh"), []string{"1013.2 hPa"})
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := metar.Parse(tc.raw, testutil.Ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := METAR(r, testutil.Ref.Add(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			es := flatten(t, doc)
+			testutil.Eq(t, "issue", texts(es, "issueTime/TimeInstant/timePosition"), []string{"2025-03-14T12:01:00Z"})
+			tc.check(t, es)
+		})
+	}
+
+	if _, err := METAR(&metar.Report{Station: "OIII"}, testutil.Ref); err == nil {
+		t.Error("METAR without time: expected error")
+	}
+}
+
+func TestTAF(t *testing.T) {
+	tf, err := taf.Parse("TAF AMD OIII 141100Z 1412/1512 27010KT 9999 SCT030 TX22/1412Z TN08/1503Z "+
+		"BECMG 1414/1416 31015G25KT "+
+		"TEMPO 1418/1422 3000 TSRA BKN008 FEW020CB "+
+		"PROB30 TEMPO 1500/1504 0800 FG VV002 "+
+		"FM150600 VRB03KT CAVOK", testutil.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := TAF(tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := flatten(t, doc)
+
+	testutil.Eq(t, "status", es[0].attrs["reportStatus"], "AMENDMENT")
+	testutil.Eq(t, "cancel", es[0].attrs["isCancelReport"], "false")
+	testutil.Eq(t, "valid", append(texts(es, "TAF/validPeriod/TimePeriod/beginPosition"), texts(es, "TAF/validPeriod/TimePeriod/endPosition")...),
+		[]string{"2025-03-14T12:00:00Z", "2025-03-15T12:00:00Z"})
+
+	base := find(es, "baseForecast/MeteorologicalAerodromeForecast")
+	testutil.Eq(t, "base", len(base), 1)
+	var indicators []string
+	for _, e := range find(es, "changeForecast/MeteorologicalAerodromeForecast") {
+		indicators = append(indicators, e.attrs["changeIndicator"])
+	}
+	testutil.Eq(t, "indicators", indicators, []string{"BECOMING", "TEMPORARY_FLUCTUATIONS", "PROBABILITY_30_TEMPORARY_FLUCTUATIONS", "FROM"})
+
+	testutil.Eq(t, "weather", texts(es, "changeForecast/MeteorologicalAerodromeForecast/weather"), []string{codesWeather + "TSRA", codesWeather + "FG"})
+	testutil.Eq(t, "vv", texts(es, "verticalVisibility"), []string{"200 [ft_i]"})
+	testutil.Eq(t, "max temp", texts(es, "maximumAirTemperature"), []string{"22 Cel"})
+	testutil.Eq(t, "min temp time", texts(es, "minimumAirTemperatureTime/TimeInstant/timePosition"), []string{"2025-03-15T03:00:00Z"})
+	// temperatures only on the base forecast
+	testutil.Eq(t, "temp count", len(find(es, "changeForecast/MeteorologicalAerodromeForecast/temperature")), 0)
+
+	// the FM group is CAVOK: no visibility, weather or cloud
+	fm := find(es, "changeForecast/MeteorologicalAerodromeForecast")[3]
+	testutil.Eq(t, "fm cavok", fm.attrs["cloudAndVisibilityOK"], "true")
+}
+
+func TestTAFCancelAndNil(t *testing.T) {
+	cnl, err := taf.Parse("TAF AMD OIII 141500Z 1412/1512 CNL", testutil.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := TAF(cnl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := flatten(t, doc)
+	testutil.Eq(t, "cancel", es[0].attrs["isCancelReport"], "true")
+	testutil.Eq(t, "cancelled period", len(find(es, "cancelledReportValidPeriod")), 1)
+	testutil.Eq(t, "no forecast", len(find(es, "baseForecast")), 0)
+
+	nilTAF, err := taf.Parse("TAF OIII 141100Z NIL", testutil.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err = TAF(nilTAF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es = flatten(t, doc)
+	testutil.Eq(t, "nil base", find(es, "baseForecast")[0].attrs["nilReason"], nilMissing)
+
+	if _, err := TAF(&taf.TAF{Station: "OIII"}); err == nil {
+		t.Error("TAF without issue time: expected error")
+	}
+}
+
+func TestBulletin(t *testing.T) {
+	r, err := metar.Parse("METAR OIII 141200Z 27010KT 9999 SCT030 18/02 Q1013", testutil.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := METAR(r, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Bulletin("A_LAIR31OIII141200_C_OIII_<&>", [][]byte{m, m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := flatten(t, doc)
+	testutil.Eq(t, "root", es[0].path, "MeteorologicalBulletin")
+	testutil.Eq(t, "members", len(find(es, "meteorologicalInformation/METAR")), 2)
+	testutil.Eq(t, "id", texts(es, "bulletinIdentifier"), []string{"A_LAIR31OIII141200_C_OIII_<&>"})
+	if bytes.Count(doc, []byte("<?xml")) != 1 {
+		t.Error("member XML declarations not stripped")
+	}
+}
@@ -0,0 +1,178 @@
+package iwxxm
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+// METAR encodes a decoded METAR or SPECI. issued is the report (or
+// receipt) time used for issueTime; zero means the observation time.
+func METAR(r *metar.Report, issued time.Time) ([]byte, error) {
+	if r == nil || r.Station == "" || r.Time.IsZero() {
+		return nil, errors.New("iwxxm: metar needs station and time")
+	}
+	if issued.IsZero() {
+		issued = r.Time
+	}
+	root := el("iwxxm:METAR")
+	if r.Type == "SPECI" {
+		root.name = "iwxxm:SPECI"
+	}
+	status := "NORMAL"
+	if r.Corrected {
+		status = "CORRECTION"
+	}
+	withNamespaces(root).
+		attr("gml:id", gmlID()).
+		attr("reportStatus", status).
+		attr("permissibleUsage", "OPERATIONAL").
+		attr("automatedStation", strconv.FormatBool(r.Auto))
+
+	root.add(
+		timeInstant("iwxxm:issueTime", issued),
+		aerodrome(r.Station),
+		timeInstant("iwxxm:observationTime", r.Time),
+	)
+	if r.Nil {
+		root.add(el("iwxxm:observation").attr("nilReason", nilMissing))
+		return encode(root)
+	}
+
+	obs := el("iwxxm:MeteorologicalAerodromeObservation").
+		attr("cloudAndVisibilityOK", strconv.FormatBool(r.CAVOK))
+	if r.Temperature != nil {
+		obs.add(measure("iwxxm:airTemperature", float64(*r.Temperature), "Cel"))
+	}
+	if r.Dewpoint != nil {
+		obs.add(measure("iwxxm:dewpointTemperature", float64(*r.Dewpoint), "Cel"))
+	}
+	if r.Altimeter != nil {
+		obs.add(measure("iwxxm:qnh", r.Altimeter.HPa, "hPa"))
+	}
+	obs.add(wind("iwxxm:AerodromeSurfaceWind", r.Wind))
+	if !r.CAVOK && r.Visibility != nil {
+		vis := el("iwxxm:AerodromeHorizontalVisibility").add(visibility(r.Visibility)...)
+		if r.Visibility.MinMeters != nil {
+			vis.add(measure("iwxxm:minimumVisibility", float64(*r.Visibility.MinMeters), "m"))
+			if d, ok := compassDeg[r.Visibility.MinDirection]; ok {
+				vis.add(measure("iwxxm:minimumVisibilityDirection", d, "deg"))
+			}
+		}
+		obs.add(el("iwxxm:visibility", vis))
+	}
+	if !r.CAVOK {
+		for _, w := range r.Weather {
+			obs.add(href("iwxxm:presentWeather", codesWeather+w.Raw))
+		}
+		obs.add(cloud("iwxxm:AerodromeCloud", r.Conditions))
+	}
+	for _, w := range r.RecentWeather {
+		obs.add(href("iwxxm:recentWeather", codesWeather+w.Raw))
+	}
+	root.add(el("iwxxm:observation", obs))
+	return encode(root)
+}
+
+var compassDeg = map[string]float64{"N": 0, "NE": 45, "E": 90, "SE": 135, "S": 180, "SW": 225, "W": 270, "NW": 315}
+
+// TAF encodes a decoded TAF including its change groups.
+func TAF(t *taf.TAF) ([]byte, error) {
+	if t == nil || t.Station == "" || t.IssueTime.IsZero() {
+		return nil, errors.New("iwxxm: taf needs station and issue time")
+	}
+	status := "NORMAL"
+	switch {
+	case t.Corrected:
+		status = "CORRECTION"
+	case t.Amended:
+		status = "AMENDMENT"
+	}
+	root := withNamespaces(el("iwxxm:TAF")).
+		attr("gml:id", gmlID()).
+		attr("reportStatus", status).
+		attr("permissibleUsage", "OPERATIONAL").
+		attr("isCancelReport", strconv.FormatBool(t.Cancelled))
+	root.add(timeInstant("iwxxm:issueTime", t.IssueTime), aerodrome(t.Station))
+
+	if t.Nil {
+		root.add(el("iwxxm:baseForecast").attr("nilReason", nilMissing))
+		return encode(root)
+	}
+	if t.Cancelled {
+		root.add(timePeriod("iwxxm:cancelledReportValidPeriod", t.ValidFrom, t.ValidTo))
+		return encode(root)
+	}
+	root.add(timePeriod("iwxxm:validPeriod", t.ValidFrom, t.ValidTo))
+
+	for _, g := range t.Groups {
+		fc := forecast(t, g)
+		if g.Type == taf.Base {
+			root.add(el("iwxxm:baseForecast", fc))
+		} else {
+			root.add(el("iwxxm:changeForecast", fc))
+		}
+	}
+	return encode(root)
+}
+
+func forecast(t *taf.TAF, g taf.Group) *node {
+	fc := el("iwxxm:MeteorologicalAerodromeForecast").
+		attr("gml:id", gmlID()).
+		attr("cloudAndVisibilityOK", strconv.FormatBool(g.CAVOK))
+	if ci := changeIndicator(g); ci != "" {
+		fc.attr("changeIndicator", ci)
+	}
+	fc.add(timePeriod("iwxxm:phenomenonTime", g.From, g.To))
+	if !g.CAVOK {
+		fc.add(visibility(g.Visibility)...)
+	}
+	fc.add(wind("iwxxm:AerodromeSurfaceWindForecast", g.Wind))
+	if !g.CAVOK {
+		fc.add(weather("iwxxm:weather", g.Conditions)...)
+		fc.add(cloud("iwxxm:AerodromeCloudForecast", g.Conditions))
+	}
+	if g.Type == taf.Base {
+		fc.add(temperatures(t)...)
+	}
+	return fc
+}
+
+func changeIndicator(g taf.Group) string {
+	switch g.Type {
+	case taf.BECMG:
+		return "BECOMING"
+	case taf.FM:
+		return "FROM"
+	case taf.TEMPO:
+		if g.Probability > 0 {
+			return "PROBABILITY_" + strconv.Itoa(g.Probability) + "_TEMPORARY_FLUCTUATIONS"
+		}
+		return "TEMPORARY_FLUCTUATIONS"
+	case taf.PROB:
+		return "PROBABILITY_" + strconv.Itoa(g.Probability)
+	}
+	return ""
+}
+
+// temperatures pairs TX/TN groups into AerodromeAirTemperatureForecast.
+func temperatures(t *taf.TAF) []*node {
+	var out []*node
+	n := len(t.MaxTemp)
+	if len(t.MinTemp) < n {
+		n = len(t.MinTemp)
+	}
+	for i := 0; i < n; i++ {
+		mx, mn := t.MaxTemp[i], t.MinTemp[i]
+		out = append(out, el("iwxxm:temperature", el("iwxxm:AerodromeAirTemperatureForecast",
+			measure("iwxxm:maximumAirTemperature", float64(mx.Celsius), "Cel"),
+			timeInstant("iwxxm:maximumAirTemperatureTime", mx.At),
+			measure("iwxxm:minimumAirTemperature", float64(mn.Celsius), "Cel"),
+			timeInstant("iwxxm:minimumAirTemperatureTime", mn.At),
+		)))
+	}
+	return out
+}
@@ -0,0 +1,273 @@
+package metar
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"SepTaf/internal/geo"
+)
+
+const (
+	HPaPerInHg  = 33.8639
+	MetersPerSM = 1609.344
+	MPSPerKt    = 0.514444
+	KMHPerKt    = 1.852
+)
+
+// Conditions holds the groups shared by the observation body, trend groups
+// and TAF change groups: wind, visibility, weather and sky.
+type Conditions struct {
+	Wind       *Wind       `json:"wind,omitempty"`
+	Visibility *Visibility `json:"visibility,omitempty"`
+	CAVOK      bool        `json:"cavok,omitempty"`
+	Weather    []Weather   `json:"weather,omitempty"`
+	NSW        bool        `json:"nsw,omitempty"` // no significant weather
+	Clouds     []Cloud     `json:"clouds,omitempty"`
+	VertVisFt  *int        `json:"vertVisFt,omitempty"`
+	SkyClear   string      `json:"skyClear,omitempty"` // SKC | CLR | NSC | NCD
+}
+
+type Wind struct {
+	Direction *int   `json:"direction,omitempty"` // degrees true, nil for VRB
+	Variable  bool   `json:"variable,omitempty"`  // VRB
+	Speed     int    `json:"speed"`
+	Gust      *int   `json:"gust,omitempty"`
+	Unit      string `json:"unit"`            // KT | MPS | KMH
+	Above     bool   `json:"above,omitempty"` // P99KT
+	VarFrom   *int   `json:"varFrom,omitempty"`
+	VarTo     *int   `json:"varTo,omitempty"`
+}
+
+// SpeedKt returns the mean speed converted to knots.
+func (w Wind) SpeedKt() float64 { return toKt(float64(w.Speed), w.Unit) }
+
+// GustKt returns the gust converted to knots, or 0 when there is none.
+func (w Wind) GustKt() float64 {
+	if w.Gust == nil {
+		return 0
+	}
+	return toKt(float64(*w.Gust), w.Unit)
+}
+
+// Crosswind is the crosswind component in kt for a runway heading, using
+// the gust when reported. Variable wind is treated as full crosswind.
+func (w Wind) Crosswind(runwayHdg float64) float64 {
+	spd := math.Max(w.SpeedKt(), w.GustKt())
+	if w.Direction == nil || w.Variable {
+		return math.Round(spd)
+	}
+	_, cross := geo.WindComponents(float64(*w.Direction), spd, runwayHdg)
+	return math.Round(math.Abs(cross))
+}
+
+func toKt(v float64, unit string) float64 {
+	switch unit {
+	case "MPS":
+		return v / MPSPerKt
+	case "KMH":
+		return v / KMHPerKt
+	}
+	return v
+}
+
+// Visibility is the prevailing visibility; Meters is always filled so
+// consumers don't need to care whether the station reports SM or metres.
+type Visibility struct {
+	Value    float64 `json:"value"`
+	Unit     string  `json:"unit"` // M | SM
+	Meters   float64 `json:"meters"`
+	LessThan bool    `json:"lessThan,omitempty"` // M1/4SM
+	MoreThan bool    `json:"moreThan,omitempty"` // P6SM, 9999
+	NDV      bool    `json:"ndv,omitempty"`
+	// minimum directional visibility (e.g. "1500SW" after the prevailing group)
+	MinMeters    *int   `json:"minMeters,omitempty"`
+	MinDirection string `json:"minDirection,omitempty"`
+}
+
+// Weather is a present/forecast weather group such as "-SHRA" or "VCTS".
+type Weather struct {
+	Raw        string   `json:"raw"`
+	Intensity  string   `json:"intensity,omitempty"`  // - | + | VC
+	Descriptor string   `json:"descriptor,omitempty"` // MI BC PR DR BL SH TS FZ
+	Phenomena  []string `json:"phenomena,omitempty"`  // RA, SN, BR, ...
+}
+
+type Cloud struct {
+	Cover  string `json:"cover"`            // FEW | SCT | BKN | OVC
+	BaseFt *int   `json:"baseFt,omitempty"` // ft AGL, nil when "///"
+	Type   string `json:"type,omitempty"`   // CB | TCU
+}
+
+// IsCeiling reports whether the layer counts as a ceiling (BKN/OVC).
+func (c Cloud) IsCeiling() bool { return c.Cover == "BKN" || c.Cover == "OVC" }
+
+var (
+	windRe    = regexp.MustCompile(`^(\d{3}|VRB|///)(P)?(\d{2,3}|//)(?:G(P)?(\d{2,3}))?(KT|MPS|KMH)$`)
+	windVarRe = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	visMRe    = regexp.MustCompile(`^(\d{4})(NDV|N|NE|E|SE|S|SW|W|NW)?$`)
+	visSMRe   = regexp.MustCompile(`^([PM])?(\d{1,2}|\d/\d{1,2}|\d{1,2}/\d{1,2})SM$`)
+	wholeRe   = regexp.MustCompile(`^\d$`)
+	fracSMRe  = regexp.MustCompile(`^\d/\d{1,2}SM$`)
+	cloudRe   = regexp.MustCompile(`^(FEW|SCT|BKN|OVC)(\d{3}|///)(CB|TCU|///)?$`)
+	vvRe      = regexp.MustCompile(`^VV(\d{3}|///)$`)
+	wxRe      = regexp.MustCompile(`^(\+|-|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+)
+
+var compass = map[string]bool{"N": true, "NE": true, "E": true, "SE": true, "S": true, "SW": true, "W": true, "NW": true}
+
+// ParseGroup tries to decode toks[i] (and, for split visibility such as
+// "1 1/2SM", the following token) into c. It returns the number of tokens
+// consumed, or 0 if the group is not one of the shared condition groups.
+func (c *Conditions) ParseGroup(toks []string, i int) int {
+	tok := toks[i]
+	switch {
+	case tok == "CAVOK":
+		c.CAVOK = true
+		c.Visibility = &Visibility{Value: 9999, Unit: "M", Meters: 10000, MoreThan: true}
+		return 1
+	case tok == "NSW":
+		c.NSW = true
+		return 1
+	case tok == "SKC" || tok == "CLR" || tok == "NSC" || tok == "NCD":
+		c.SkyClear = tok
+		return 1
+	case windRe.MatchString(tok):
+		c.Wind = parseWind(tok)
+		return 1
+	case windVarRe.MatchString(tok) && c.Wind != nil:
+		m := windVarRe.FindStringSubmatch(tok)
+		from, _ := strconv.Atoi(m[1])
+		to, _ := strconv.Atoi(m[2])
+		c.Wind.VarFrom, c.Wind.VarTo = &from, &to
+		return 1
+	case wholeRe.MatchString(tok) && i+1 < len(toks) && fracSMRe.MatchString(toks[i+1]):
+		whole, _ := strconv.Atoi(tok)
+		frac, _ := parseFrac(strings.TrimSuffix(toks[i+1], "SM"))
+		v := float64(whole) + frac
+		c.Visibility = &Visibility{Value: v, Unit: "SM", Meters: math.Round(v * MetersPerSM)}
+		return 2
+	case visSMRe.MatchString(tok):
+		m := visSMRe.FindStringSubmatch(tok)
+		v, _ := parseFrac(m[2])
+		c.Visibility = &Visibility{
+			Value: v, Unit: "SM", Meters: math.Round(v * MetersPerSM),
+			LessThan: m[1] == "M", MoreThan: m[1] == "P",
+		}
+		return 1
+	case visMRe.MatchString(tok):
+		m := visMRe.FindStringSubmatch(tok)
+		v, _ := strconv.Atoi(m[1])
+		// a second 4-digit group with a direction is the minimum visibility
+		if c.Visibility != nil && c.Visibility.Unit == "M" && compass[m[2]] {
+			c.Visibility.MinMeters = &v
+			c.Visibility.MinDirection = m[2]
+			return 1
+		}
+		vis := &Visibility{Value: float64(v), Unit: "M", Meters: float64(v), NDV: m[2] == "NDV"}
+		if v == 9999 {
+			vis.Meters = 10000
+			vis.MoreThan = true
+		}
+		c.Visibility = vis
+		return 1
+	case cloudRe.MatchString(tok):
+		m := cloudRe.FindStringSubmatch(tok)
+		cl := Cloud{Cover: m[1]}
+		if m[2] != "///" {
+			h, _ := strconv.Atoi(m[2])
+			h *= 100
+			cl.BaseFt = &h
+		}
+		if m[3] != "///" {
+			cl.Type = m[3]
+		}
+		c.Clouds = append(c.Clouds, cl)
+		return 1
+	case vvRe.MatchString(tok):
+		m := vvRe.FindStringSubmatch(tok)
+		if m[1] != "///" {
+			h, _ := strconv.Atoi(m[1])
+			h *= 100
+			c.VertVisFt = &h
+		}
+		return 1
+	}
+	if w, ok := ParseWeather(tok); ok {
+		c.Weather = append(c.Weather, w)
+		return 1
+	}
+	return 0
+}
+
+// ParseWeather decodes a single weather group. It rejects tokens that only
+// carry an intensity or descriptor without a phenomenon (except TS, which is
+// valid on its own).
+func ParseWeather(tok string) (Weather, bool) {
+	m := wxRe.FindStringSubmatch(tok)
+	if m == nil {
+		return Weather{}, false
+	}
+	w := Weather{Raw: tok, Intensity: m[1], Descriptor: m[2]}
+	for p := m[3]; len(p) >= 2; p = p[2:] {
+		w.Phenomena = append(w.Phenomena, p[:2])
+	}
+	if len(w.Phenomena) == 0 && w.Descriptor != "TS" && !(w.Intensity == "VC" && w.Descriptor == "SH") {
+		return Weather{}, false
+	}
+	return w, true
+}
+
+// Ceiling returns the lowest BKN/OVC base or vertical visibility in ft.
+func (c Conditions) Ceiling() *int {
+	var out *int
+	for _, cl := range c.Clouds {
+		if cl.IsCeiling() && cl.BaseFt != nil && (out == nil || *cl.BaseFt < *out) {
+			v := *cl.BaseFt
+			out = &v
+		}
+	}
+	if c.VertVisFt != nil && (out == nil || *c.VertVisFt < *out) {
+		v := *c.VertVisFt
+		out = &v
+	}
+	return out
+}
+
+func parseWind(tok string) *Wind {
+	m := windRe.FindStringSubmatch(tok)
+	w := &Wind{Unit: m[6], Above: m[2] == "P"}
+	switch m[1] {
+	case "VRB":
+		w.Variable = true
+	case "///":
+	default:
+		d, _ := strconv.Atoi(m[1])
+		w.Direction = &d
+	}
+	if m[3] != "//" {
+		w.Speed, _ = strconv.Atoi(m[3])
+	}
+	if m[5] != "" {
+		g, _ := strconv.Atoi(m[5])
+		w.Gust = &g
+	}
+	return w
+}
+
+func parseFrac(s string) (float64, bool) {
+	if a, b, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.Atoi(a)
+		d, err2 := strconv.Atoi(b)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return float64(n) / float64(d), true
+	}
+	n, err := strconv.Atoi(s)
+	return float64(n), err == nil
+}
+
+func round1(v float64) float64 { return math.Round(v*10) / 10 }
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
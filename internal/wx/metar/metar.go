@@ -0,0 +1,274 @@
+package metar
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report is a decoded METAR/SPECI built from the raw observation text.
+type Report struct {
+	Raw       string    `json:"raw"`
+	Type      string    `json:"type"` // METAR | SPECI
+	Station   string    `json:"station"`
+	Time      time.Time `json:"time,omitempty"`
+	Auto      bool      `json:"auto,omitempty"`
+	Corrected bool      `json:"corrected,omitempty"`
+	Nil       bool      `json:"nil,omitempty"`
+	Conditions
+	RVR           []RVR      `json:"rvr,omitempty"`
+	Temperature   *int       `json:"temperature,omitempty"` // °C
+	Dewpoint      *int       `json:"dewpoint,omitempty"`    // °C
+	Altimeter     *Altimeter `json:"altimeter,omitempty"`
+	RecentWeather []Weather  `json:"recentWeather,omitempty"`
+	WindShear     []string   `json:"windShear,omitempty"` // e.g. "WS R24L", "WS ALL RWY"
+	Trends        []Trend    `json:"trends,omitempty"`
+	Remarks       string     `json:"remarks,omitempty"`
+	Unparsed      []string   `json:"unparsed,omitempty"`
+}
+
+// Trend is a NOSIG/BECMG/TEMPO group appended to the observation.
+type Trend struct {
+	Type  string `json:"type"`            // NOSIG | BECMG | TEMPO
+	From  string `json:"from,omitempty"`  // FMhhmm
+	Until string `json:"until,omitempty"` // TLhhmm
+	At    string `json:"at,omitempty"`    // AThhmm
+	Conditions
+}
+
+type Altimeter struct {
+	Value float64 `json:"value"` // as reported, in Unit
+	Unit  string  `json:"unit"`  // hPa | inHg
+	HPa   float64 `json:"hpa"`
+	InHg  float64 `json:"inHg"`
+}
+
+// RVR is a runway visual range group (R24L/1200FT, R08/0600V1000U).
+type RVR struct {
+	Runway   string `json:"runway"`
+	Min      int    `json:"min"`
+	Max      *int   `json:"max,omitempty"` // set for variable RVR
+	Unit     string `json:"unit"`          // M | FT
+	LessThan bool   `json:"lessThan,omitempty"`
+	MoreThan bool   `json:"moreThan,omitempty"`
+	Tendency string `json:"tendency,omitempty"` // U | D | N
+}
+
+var (
+	typeRe   = regexp.MustCompile(`^(METAR|SPECI)$`)
+	icaoRe   = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	timeRe   = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	rvrRe    = regexp.MustCompile(`^R(\d{2}[LCR]?)/([PM])?(\d{4})(?:V([PM])?(\d{4}))?(FT)?/?([UDN])?$`)
+	tempRe   = regexp.MustCompile(`^(M)?(\d{2}|//)/(M)?(\d{2}|//)?$`)
+	altimRe  = regexp.MustCompile(`^([QA])(\d{4})$`)
+	trendTRe = regexp.MustCompile(`^(FM|TL|AT)(\d{4})$`)
+)
+
+// ErrEmpty is returned for blank input.
+var ErrEmpty = errors.New("empty metar")
+
+// Parse decodes a raw METAR/SPECI. ref anchors the day-of-month in the
+// report to a full date (usually the observation or receipt time).
+// Groups that cannot be recognised are kept in Unparsed instead of failing.
+func Parse(raw string, ref time.Time) (*Report, error) {
+	toks := Tokens(raw)
+	if len(toks) == 0 {
+		return nil, ErrEmpty
+	}
+	r := &Report{Raw: strings.Join(toks, " "), Type: "METAR"}
+
+	i := 0
+	if typeRe.MatchString(toks[i]) {
+		r.Type = toks[i]
+		i++
+	}
+	if i < len(toks) && toks[i] == "COR" {
+		r.Corrected = true
+		i++
+	}
+	if i >= len(toks) || !icaoRe.MatchString(toks[i]) {
+		return nil, errors.New("missing station identifier")
+	}
+	r.Station = toks[i]
+	i++
+	if i < len(toks) {
+		if m := timeRe.FindStringSubmatch(toks[i]); m != nil {
+			d, _ := strconv.Atoi(m[1])
+			h, _ := strconv.Atoi(m[2])
+			mi, _ := strconv.Atoi(m[3])
+			r.Time = DayTime(ref, d, h, mi)
+			i++
+		}
+	}
+
+	var trend *Trend
+	for i < len(toks) {
+		tok := toks[i]
+		switch {
+		case tok == "RMK":
+			r.Remarks = strings.Join(toks[i+1:], " ")
+			i = len(toks)
+			continue
+		case tok == "NOSIG" || tok == "BECMG" || tok == "TEMPO":
+			r.Trends = append(r.Trends, Trend{Type: tok})
+			trend = &r.Trends[len(r.Trends)-1]
+			i++
+			continue
+		}
+
+		if trend != nil {
+			if m := trendTRe.FindStringSubmatch(tok); m != nil {
+				switch m[1] {
+				case "FM":
+					trend.From = m[2]
+				case "TL":
+					trend.Until = m[2]
+				case "AT":
+					trend.At = m[2]
+				}
+				i++
+				continue
+			}
+			if n := trend.Conditions.ParseGroup(toks, i); n > 0 {
+				i += n
+				continue
+			}
+			r.Unparsed = append(r.Unparsed, tok)
+			i++
+			continue
+		}
+
+		switch {
+		case tok == "AUTO":
+			r.Auto = true
+		case tok == "COR":
+			r.Corrected = true
+		case tok == "NIL":
+			r.Nil = true
+		case tok == "WS":
+			n := windShear(toks, i)
+			r.WindShear = append(r.WindShear, strings.Join(toks[i:i+n], " "))
+			i += n
+			continue
+		case rvrRe.MatchString(tok):
+			r.RVR = append(r.RVR, parseRVR(tok))
+		case tempRe.MatchString(tok):
+			r.Temperature, r.Dewpoint = parseTemp(tok)
+		case altimRe.MatchString(tok):
+			r.Altimeter = parseAltimeter(tok)
+		case strings.HasPrefix(tok, "RE") && len(tok) > 2:
+			if w, ok := ParseWeather(tok[2:]); ok {
+				r.RecentWeather = append(r.RecentWeather, w)
+			} else {
+				r.Unparsed = append(r.Unparsed, tok)
+			}
+		default:
+			if n := r.Conditions.ParseGroup(toks, i); n > 0 {
+				i += n
+				continue
+			}
+			if tok != "/////" && tok != "//" && tok != "////" && tok != "//////" {
+				r.Unparsed = append(r.Unparsed, tok)
+			}
+		}
+		i++
+	}
+	return r, nil
+}
+
+// Tokens normalises a raw report into whitespace-separated groups and
+// drops the trailing "=" terminator used in bulletins.
+func Tokens(raw string) []string {
+	raw = strings.ToUpper(strings.TrimSpace(raw))
+	raw = strings.TrimSuffix(raw, "=")
+	return strings.Fields(raw)
+}
+
+// DayTime resolves a DDHHMM group against ref, picking the closest month so
+// that reports issued around a month boundary land on the right date.
+func DayTime(ref time.Time, day, hour, minute int) time.Time {
+	if ref.IsZero() {
+		ref = time.Now()
+	}
+	ref = ref.UTC()
+	best := time.Time{}
+	for _, off := range []int{0, -1, 1} {
+		y, m, _ := ref.AddDate(0, off, 0).Date()
+		// time.Date normalises day 31 in a 30-day month, so skip those candidates.
+		// hour/minute are added afterwards so "24" (end of day in TAFs) works.
+		t := time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+		if t.Day() != day {
+			continue
+		}
+		t = t.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		if best.IsZero() || absDur(t.Sub(ref)) < absDur(best.Sub(ref)) {
+			best = t
+		}
+	}
+	return best
+}
+
+func absDur(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func windShear(toks []string, i int) int {
+	// WS R24L | WS RWY24L | WS ALL RWY
+	if i+2 < len(toks) && toks[i+1] == "ALL" && toks[i+2] == "RWY" {
+		return 3
+	}
+	if i+1 < len(toks) {
+		return 2
+	}
+	return 1
+}
+
+func parseRVR(tok string) RVR {
+	m := rvrRe.FindStringSubmatch(tok)
+	out := RVR{Runway: m[1], Unit: "M", Tendency: m[7]}
+	out.Min, _ = strconv.Atoi(m[3])
+	out.LessThan = m[2] == "M"
+	out.MoreThan = m[2] == "P"
+	if m[5] != "" {
+		mx, _ := strconv.Atoi(m[5])
+		out.Max = &mx
+		if m[4] == "P" {
+			out.MoreThan = true
+		}
+	}
+	if m[6] == "FT" {
+		out.Unit = "FT"
+	}
+	return out
+}
+
+func parseTemp(tok string) (*int, *int) {
+	m := tempRe.FindStringSubmatch(tok)
+	conv := func(neg, v string) *int {
+		if v == "" || v == "//" {
+			return nil
+		}
+		n, _ := strconv.Atoi(v)
+		if neg == "M" {
+			n = -n
+		}
+		return &n
+	}
+	return conv(m[1], m[2]), conv(m[3], m[4])
+}
+
+func parseAltimeter(tok string) *Altimeter {
+	m := altimRe.FindStringSubmatch(tok)
+	v, _ := strconv.Atoi(m[2])
+	if m[1] == "Q" {
+		hpa := float64(v)
+		return &Altimeter{Value: hpa, Unit: "hPa", HPa: hpa, InHg: round2(hpa / HPaPerInHg)}
+	}
+	in := float64(v) / 100
+	return &Altimeter{Value: in, Unit: "inHg", HPa: round1(in * HPaPerInHg), InHg: in}
+}
@@ -0,0 +1,184 @@
+package metar
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		check func(t *testing.T, r *Report)
+	}{
+		{
+			name: "icao metric",
+			raw:  "METAR OIII 141200Z 27015G25KT 240V300 9999 -RA FEW030 SCT040CB BKN100 18/M02 Q1013 NOSIG=",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "station", r.Station, "OIII")
+				testutil.Eq(t, "time", r.Time, time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC))
+				testutil.Eq(t, "wind", *r.Wind, Wind{Direction: testutil.Ptr(270), Speed: 15, Gust: testutil.Ptr(25), Unit: "KT", VarFrom: testutil.Ptr(240), VarTo: testutil.Ptr(300)})
+				testutil.Eq(t, "vis", r.Visibility.Meters, 10000.0)
+				testutil.Eq(t, "vis more", r.Visibility.MoreThan, true)
+				testutil.Eq(t, "weather", r.Weather, []Weather{{Raw: "-RA", Intensity: "-", Phenomena: []string{"RA"}}})
+				testutil.Eq(t, "clouds", len(r.Clouds), 3)
+				testutil.Eq(t, "cb", r.Clouds[1].Type, "CB")
+				testutil.Eq(t, "ceiling", *r.Ceiling(), 10000)
+				testutil.Eq(t, "temp", *r.Temperature, 18)
+				testutil.Eq(t, "dew", *r.Dewpoint, -2)
+				testutil.Eq(t, "altimeter", *r.Altimeter, Altimeter{Value: 1013, Unit: "hPa", HPa: 1013, InHg: 29.91})
+				testutil.Eq(t, "trend", r.Trends, []Trend{{Type: "NOSIG"}})
+				testutil.Eq(t, "unparsed", len(r.Unparsed), 0)
+			},
+		},
+		{
+			name: "us statute miles",
+			raw:  "SPECI KJFK 141151Z AUTO 00000KT 1 1/2SM R04R/2200V4000FT/U BR OVC004 M01/M02 A2992 RMK AO2 SLP132",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "type", r.Type, "SPECI")
+				testutil.Eq(t, "auto", r.Auto, true)
+				testutil.Eq(t, "vis", *r.Visibility, Visibility{Value: 1.5, Unit: "SM", Meters: 2414})
+				testutil.Eq(t, "rvr", r.RVR, []RVR{{Runway: "04R", Min: 2200, Max: testutil.Ptr(4000), Unit: "FT", Tendency: "U"}})
+				testutil.Eq(t, "ceiling", *r.Ceiling(), 400)
+				testutil.Eq(t, "temp", *r.Temperature, -1)
+				testutil.Eq(t, "altimeter", *r.Altimeter, Altimeter{Value: 29.92, Unit: "inHg", HPa: 1013.2, InHg: 29.92})
+				testutil.Eq(t, "remarks", r.Remarks, "AO2 SLP132")
+			},
+		},
+		{
+			name: "cavok and recent weather",
+			raw:  "METAR COR LFPG 132330Z VRB02KT CAVOK 05/03 Q1021 RETSRA WS R27L BECMG TL0100 3000 BR",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "corrected", r.Corrected, true)
+				testutil.Eq(t, "time", r.Time, time.Date(2025, 3, 13, 23, 30, 0, 0, time.UTC))
+				testutil.Eq(t, "variable", r.Wind.Variable, true)
+				testutil.Eq(t, "cavok", r.CAVOK, true)
+				testutil.Eq(t, "recent", r.RecentWeather, []Weather{{Raw: "TSRA", Descriptor: "TS", Phenomena: []string{"RA"}}})
+				testutil.Eq(t, "windshear", r.WindShear, []string{"WS R27L"})
+				testutil.Eq(t, "trend type", r.Trends[0].Type, "BECMG")
+				testutil.Eq(t, "trend until", r.Trends[0].Until, "0100")
+				testutil.Eq(t, "trend vis", r.Trends[0].Visibility.Meters, 3000.0)
+				testutil.Eq(t, "trend wx", r.Trends[0].Weather[0].Phenomena, []string{"BR"})
+			},
+		},
+		{
+			name: "vertical visibility and min visibility",
+			raw:  "OIIE 140300Z 31008MPS 0400 1500SW R29L/M0050N +SN VV002 M05/M06 Q0998",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "type", r.Type, "METAR")
+				testutil.Eq(t, "speed kt", round1(r.Wind.SpeedKt()), 15.6)
+				testutil.Eq(t, "vis", r.Visibility.Meters, 400.0)
+				testutil.Eq(t, "min vis", *r.Visibility.MinMeters, 1500)
+				testutil.Eq(t, "min dir", r.Visibility.MinDirection, "SW")
+				testutil.Eq(t, "rvr", r.RVR[0], RVR{Runway: "29L", Min: 50, Unit: "M", LessThan: true, Tendency: "N"})
+				testutil.Eq(t, "vv", *r.VertVisFt, 200)
+				testutil.Eq(t, "ceiling", *r.Ceiling(), 200)
+			},
+		},
+		{
+			name: "nil and unknown groups",
+			raw:  "METAR OIMM 140000Z NIL",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "nil", r.Nil, true)
+			},
+		},
+		{
+			name: "unparsed kept",
+			raw:  "METAR OIKB 141000Z 18005KT 8000 XYZ12 NSC 30/20 Q1008",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "unparsed", r.Unparsed, []string{"XYZ12"})
+				testutil.Eq(t, "sky", r.SkyClear, "NSC")
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Parse(tc.raw, testutil.Ref)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tc.check(t, r)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, raw := range []string{"", "  =", "METAR 12", "METAR"} {
+		if _, err := Parse(raw, testutil.Ref); err == nil {
+			t.Errorf("Parse(%q): expected error", raw)
+		}
+	}
+}
+
+func TestDayTime(t *testing.T) {
+	tests := []struct {
+		ref       time.Time
+		day, h, m int
+		want      time.Time
+	}{
+		{testutil.Ref, 14, 12, 0, time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)},
+		// report from the end of last month
+		{time.Date(2025, 3, 1, 0, 10, 0, 0, time.UTC), 28, 23, 50, time.Date(2025, 2, 28, 23, 50, 0, 0, time.UTC)},
+		// day 31 does not exist in April
+		{time.Date(2025, 4, 1, 0, 10, 0, 0, time.UTC), 31, 23, 0, time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)},
+		// TAF end hour 24
+		{testutil.Ref, 14, 24, 0, time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		if got := DayTime(tc.ref, tc.day, tc.h, tc.m); !got.Equal(tc.want) {
+			t.Errorf("DayTime(%v, %d, %d, %d) = %v, want %v", tc.ref, tc.day, tc.h, tc.m, got, tc.want)
+		}
+	}
+}
+
+func TestParseWeather(t *testing.T) {
+	tests := []struct {
+		tok  string
+		ok   bool
+		want Weather
+	}{
+		{"+TSRAGR", true, Weather{Raw: "+TSRAGR", Intensity: "+", Descriptor: "TS", Phenomena: []string{"RA", "GR"}}},
+		{"VCSH", true, Weather{Raw: "VCSH", Intensity: "VC", Descriptor: "SH"}},
+		{"TS", true, Weather{Raw: "TS", Descriptor: "TS"}},
+		{"FZFG", true, Weather{Raw: "FZFG", Descriptor: "FZ", Phenomena: []string{"FG"}}},
+		{"-", false, Weather{}},
+		{"SH", false, Weather{}},
+		{"Q1013", false, Weather{}},
+	}
+	for _, tc := range tests {
+		got, ok := ParseWeather(tc.tok)
+		if ok != tc.ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseWeather(%q) = %+v, %v; want %+v, %v", tc.tok, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCrosswind(t *testing.T) {
+	tests := []struct {
+		wind string
+		hdg  float64
+		want float64
+	}{
+		{"29020KT", 290, 0},
+		{"32020KT", 290, 10},
+		{"26020KT", 290, 10}, // from the left counts the same
+		{"20015KT", 290, 15},
+		{"32010G30KT", 290, 15}, // gust used
+		{"10020KT", 290, 3},     // tailwind side
+		{"VRB05KT", 290, 5},
+		{"27010KT 240V300", 290, 3},
+		{"32010MPS", 290, 10}, // 19.4 kt
+	}
+	for _, tc := range tests {
+		r, err := Parse("METAR OIII 141200Z "+tc.wind+" 9999 SCT030 18/02 Q1013", testutil.Ref)
+		if err != nil || r.Wind == nil {
+			t.Fatalf("Parse(%q): %v", tc.wind, err)
+		}
+		if got := r.Wind.Crosswind(tc.hdg); got != tc.want {
+			t.Errorf("Crosswind(%s, %v) = %v, want %v", tc.wind, tc.hdg, got, tc.want)
+		}
+	}
+}
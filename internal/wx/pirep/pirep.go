@@ -0,0 +1,465 @@
+package pirep
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/wx/metar"
+)
+
+const (
+	KindPIREP  = "PIREP"
+	KindUrgent = "UUA"
+	KindAIREP  = "AIREP"
+)
+
+// Report is a decoded pilot report. PIREPs (UA/UUA) use the slash-separated
+// /OV /TM /FL ... fields; AIREPs are decoded from their positional layout.
+type Report struct {
+	Raw          string          `json:"raw"`
+	Kind         string          `json:"kind"` // PIREP | UUA | AIREP
+	Station      string          `json:"station,omitempty"`
+	Location     string          `json:"location,omitempty"` // /OV as reported, e.g. DEN090020
+	Lat          *float64        `json:"lat,omitempty"`      // AIREP positions only
+	Lon          *float64        `json:"lon,omitempty"`
+	Time         time.Time       `json:"time,omitempty"`
+	FlightLevel  *int            `json:"flightLevel,omitempty"`  // hundreds of feet
+	FlightPhase  string          `json:"flightPhase,omitempty"`  // DURC | DURD | UNKN
+	AircraftType string          `json:"aircraftType,omitempty"` // /TP
+	Callsign     string          `json:"callsign,omitempty"`     // AIREP
+	Sky          []SkyLayer      `json:"sky,omitempty"`
+	FlightVisSM  *int            `json:"flightVisSM,omitempty"`
+	Weather      []metar.Weather `json:"weather,omitempty"`
+	TempC        *int            `json:"tempC,omitempty"`
+	Wind         *Wind           `json:"wind,omitempty"`
+	Turbulence   []Turbulence    `json:"turbulence,omitempty"`
+	Icing        []Icing         `json:"icing,omitempty"`
+	Remarks      string          `json:"remarks,omitempty"`
+	Unparsed     []string        `json:"unparsed,omitempty"`
+}
+
+// SkyLayer is one /SK layer; bases and tops are in feet MSL.
+type SkyLayer struct {
+	Cover  string `json:"cover"`
+	BaseFt *int   `json:"baseFt,omitempty"`
+	TopFt  *int   `json:"topFt,omitempty"`
+}
+
+type Wind struct {
+	Direction int `json:"direction"`
+	SpeedKt   int `json:"speedKt"`
+}
+
+// Turbulence is one /TB condition. Levels are flight levels (hundreds of
+// feet); Below/Above mark open ranges such as "BLO 080".
+type Turbulence struct {
+	Raw       string `json:"raw"`
+	Intensity string `json:"intensity,omitempty"` // NEG, LGT, LGT-MOD, MOD, SEV, EXTM ...
+	Type      string `json:"type,omitempty"`      // CAT | CHOP | LLWS | MWAVE
+	Frequency string `json:"frequency,omitempty"` // OCNL | INTMT | CONS
+	BaseFL    *int   `json:"baseFL,omitempty"`
+	TopFL     *int   `json:"topFL,omitempty"`
+	Below     bool   `json:"below,omitempty"`
+	Above     bool   `json:"above,omitempty"`
+}
+
+// Icing is one /IC condition.
+type Icing struct {
+	Raw       string `json:"raw"`
+	Intensity string `json:"intensity,omitempty"` // NEG, TRC, LGT, MOD, SEV ...
+	Type      string `json:"type,omitempty"`      // RIME | CLR | MX
+	BaseFL    *int   `json:"baseFL,omitempty"`
+	TopFL     *int   `json:"topFL,omitempty"`
+	Below     bool   `json:"below,omitempty"`
+	Above     bool   `json:"above,omitempty"`
+}
+
+// ErrEmpty is returned for blank input.
+var ErrEmpty = errors.New("empty pirep")
+
+var (
+	fieldRe   = regexp.MustCompile(`/(OV|TM|FL|TP|SK|WX|TA|WV|TB|IC|RM)\s*`)
+	hhmmRe    = regexp.MustCompile(`^(\d{2})(\d{2})Z?$`)
+	levelRe   = regexp.MustCompile(`^(\d{3})$`)
+	rangeRe   = regexp.MustCompile(`^(\d{3})-(\d{3})$`)
+	skyRe     = regexp.MustCompile(`^(SKC|CLR|FEW|SCT|BKN|OVC|OVX)(\d{3}|UNKN)?(?:-?TOP(\d{3}|UNKN))?$`)
+	skyAltRe  = regexp.MustCompile(`^(\d{3}|UNKN)(FEW|SCT|BKN|OVC)(\d{3}|UNKN)?$`)
+	visRe     = regexp.MustCompile(`^FV(\d{1,2})SM$`)
+	tempRe    = regexp.MustCompile(`^([MP-])?(\d{1,2})$`)
+	wvRe      = regexp.MustCompile(`^(\d{3})(\d{2,3})(KT)?$`)
+	airepPos  = regexp.MustCompile(`^(\d{2})(\d{2})?([NS])\s?(\d{3})(\d{2})?([EW])$`)
+	airepFL   = regexp.MustCompile(`^F(\d{3})$`)
+	airepTemp = regexp.MustCompile(`^(MS|PS)(\d{2})$`)
+	airepWind = regexp.MustCompile(`^(\d{3})/(\d{2,3})(KT)?$`)
+)
+
+var intensities = map[string]int{
+	"NEG": 0, "SMTH": 1, "SMOOTH": 1, "TRC": 1, "LGT": 2, "MOD": 3, "SEV": 4, "HVY": 4, "EXTM": 5,
+}
+
+// IntensityRank orders turbulence/icing intensities: NEG=0, TRC/SMTH=1,
+// LGT=2, MOD=3, SEV=4, EXTM=5. Ranges such as "LGT-MOD" rank as their upper
+// end; unknown values return -1.
+func IntensityRank(s string) int {
+	best := -1
+	for _, p := range strings.Split(s, "-") {
+		if n, ok := intensities[p]; ok && n > best {
+			best = n
+		}
+	}
+	return best
+}
+
+// MaxTurbulence returns the strongest reported turbulence intensity, or "".
+func (r *Report) MaxTurbulence() string {
+	best, out := -1, ""
+	for _, t := range r.Turbulence {
+		if n := IntensityRank(t.Intensity); n > best {
+			best, out = n, t.Intensity
+		}
+	}
+	return out
+}
+
+// MaxIcing returns the strongest reported icing intensity, or "".
+func (r *Report) MaxIcing() string {
+	best, out := -1, ""
+	for _, ic := range r.Icing {
+		if n := IntensityRank(ic.Intensity); n > best {
+			best, out = n, ic.Intensity
+		}
+	}
+	return out
+}
+
+// Parse decodes a raw PIREP or AIREP. ref anchors the hhmm report time to a
+// full date (the closest matching time to ref is used).
+func Parse(raw string, ref time.Time) (*Report, error) {
+	s := strings.Join(strings.Fields(strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(raw), "="))), " ")
+	if s == "" {
+		return nil, ErrEmpty
+	}
+	if strings.HasPrefix(s, "ARP ") || strings.HasPrefix(s, "AIREP ") {
+		return parseAIREP(s, ref), nil
+	}
+
+	r := &Report{Raw: s, Kind: KindPIREP}
+	locs := fieldRe.FindAllStringSubmatchIndex(s, -1)
+	head := s
+	if len(locs) > 0 {
+		head = s[:locs[0][0]]
+	}
+	for _, tok := range strings.Fields(head) {
+		switch tok {
+		case "UA":
+		case "UUA":
+			r.Kind = KindUrgent
+		default:
+			if r.Station == "" && len(tok) >= 3 && len(tok) <= 4 {
+				r.Station = tok
+			} else {
+				r.Unparsed = append(r.Unparsed, tok)
+			}
+		}
+	}
+
+	for i, loc := range locs {
+		end := len(s)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		name := s[loc[2]:loc[3]]
+		val := strings.TrimSpace(s[loc[1]:end])
+		r.field(name, val, ref)
+	}
+	return r, nil
+}
+
+func (r *Report) field(name, val string, ref time.Time) {
+	switch name {
+	case "OV":
+		r.Location = val
+	case "TM":
+		if m := hhmmRe.FindStringSubmatch(val); m != nil {
+			h, _ := strconv.Atoi(m[1])
+			mi, _ := strconv.Atoi(m[2])
+			r.Time = ClosestTime(ref, h, mi)
+		} else {
+			r.Unparsed = append(r.Unparsed, "/TM "+val)
+		}
+	case "FL":
+		switch {
+		case levelRe.MatchString(val):
+			n, _ := strconv.Atoi(val)
+			r.FlightLevel = &n
+		case val == "DURC" || val == "DURD" || val == "UNKN":
+			r.FlightPhase = val
+		default:
+			r.Unparsed = append(r.Unparsed, "/FL "+val)
+		}
+	case "TP":
+		r.AircraftType = val
+	case "SK":
+		r.parseSky(val)
+	case "WX":
+		for _, tok := range strings.Fields(val) {
+			if m := visRe.FindStringSubmatch(tok); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				r.FlightVisSM = &n
+			} else if wx, ok := metar.ParseWeather(tok); ok {
+				r.Weather = append(r.Weather, wx)
+			} else {
+				r.Unparsed = append(r.Unparsed, "/WX "+tok)
+			}
+		}
+	case "TA":
+		if m := tempRe.FindStringSubmatch(val); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			if m[1] == "M" || m[1] == "-" {
+				n = -n
+			}
+			r.TempC = &n
+		} else {
+			r.Unparsed = append(r.Unparsed, "/TA "+val)
+		}
+	case "WV":
+		if m := wvRe.FindStringSubmatch(strings.ReplaceAll(val, " ", "")); m != nil {
+			d, _ := strconv.Atoi(m[1])
+			sp, _ := strconv.Atoi(m[2])
+			r.Wind = &Wind{Direction: d, SpeedKt: sp}
+		} else {
+			r.Unparsed = append(r.Unparsed, "/WV "+val)
+		}
+	case "TB":
+		for _, part := range splitConditions(val) {
+			r.Turbulence = append(r.Turbulence, parseTurbulence(part))
+		}
+	case "IC":
+		for _, part := range splitConditions(val) {
+			r.Icing = append(r.Icing, parseIcing(part))
+		}
+	case "RM":
+		r.Remarks = val
+	}
+}
+
+func (r *Report) parseSky(val string) {
+	// "BKN020 TOP035" و "BKN020-TOP035" هر دو رایج‌اند
+	val = strings.ReplaceAll(val, " TOP", "-TOP")
+	val = strings.ReplaceAll(val, "--TOP", "-TOP")
+	for _, tok := range strings.Fields(val) {
+		if m := skyRe.FindStringSubmatch(tok); m != nil {
+			r.Sky = append(r.Sky, SkyLayer{Cover: m[1], BaseFt: hundreds(m[2]), TopFt: hundreds(m[3])})
+		} else if m := skyAltRe.FindStringSubmatch(tok); m != nil {
+			r.Sky = append(r.Sky, SkyLayer{Cover: m[2], BaseFt: hundreds(m[1]), TopFt: hundreds(m[3])})
+		} else {
+			r.Unparsed = append(r.Unparsed, "/SK "+tok)
+		}
+	}
+}
+
+// splitConditions separates multiple /TB or /IC conditions. They are
+// usually joined by ";" or "," but sometimes only by a new altitude range.
+func splitConditions(val string) []string {
+	var out []string
+	for _, p := range strings.FieldsFunc(val, func(r rune) bool { return r == ';' || r == ',' }) {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func parseTurbulence(s string) Turbulence {
+	t := Turbulence{Raw: s}
+	toks := strings.Fields(s)
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		switch {
+		case IntensityRank(tok) >= 0:
+			t.Intensity = joinIntensity(t.Intensity, tok)
+		case tok == "CAT" || tok == "CHOP" || tok == "LLWS" || tok == "MWAVE":
+			t.Type = tok
+		case tok == "OCNL" || tok == "INTMT" || tok == "CONS":
+			t.Frequency = tok
+		case tok == "TURB" || tok == "TB":
+		default:
+			i += levels(toks, i, &t.BaseFL, &t.TopFL, &t.Below, &t.Above)
+		}
+	}
+	return t
+}
+
+func parseIcing(s string) Icing {
+	ic := Icing{Raw: s}
+	toks := strings.Fields(s)
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		switch {
+		case IntensityRank(tok) >= 0:
+			ic.Intensity = joinIntensity(ic.Intensity, tok)
+		case tok == "RIME" || tok == "CLR" || tok == "MX" || tok == "MXD":
+			ic.Type = strings.TrimSuffix(tok, "D")
+		case tok == "ICE" || tok == "IC" || tok == "ICG":
+		default:
+			i += levels(toks, i, &ic.BaseFL, &ic.TopFL, &ic.Below, &ic.Above)
+		}
+	}
+	return ic
+}
+
+// joinIntensity handles "LGT MOD" written with a space instead of a dash.
+func joinIntensity(prev, tok string) string {
+	if prev == "" {
+		return tok
+	}
+	return prev + "-" + tok
+}
+
+// levels reads "080-100", "080", "BLO 080" or "ABV 100" at toks[i] and
+// returns how many extra tokens were consumed.
+func levels(toks []string, i int, base, top **int, below, above *bool) int {
+	tok := toks[i]
+	if m := rangeRe.FindStringSubmatch(tok); m != nil {
+		*base, *top = atoiPtr(m[1]), atoiPtr(m[2])
+		return 0
+	}
+	if levelRe.MatchString(tok) {
+		*base, *top = atoiPtr(tok), atoiPtr(tok)
+		return 0
+	}
+	if (tok == "BLO" || tok == "BLW" || tok == "ABV") && i+1 < len(toks) && levelRe.MatchString(toks[i+1]) {
+		if tok == "ABV" {
+			*above, *base = true, atoiPtr(toks[i+1])
+		} else {
+			*below, *top = true, atoiPtr(toks[i+1])
+		}
+		return 1
+	}
+	return 0
+}
+
+// parseAIREP decodes the positional AIREP layout, e.g.
+// "ARP UAL123 4520N 12030W 1530 F350 MS45 270/050KT TB MOD".
+func parseAIREP(s string, ref time.Time) *Report {
+	r := &Report{Raw: s, Kind: KindAIREP}
+	toks := strings.Fields(s)[1:]
+	if len(toks) > 0 && !airepPos.MatchString(toks[0]) {
+		r.Callsign, toks = toks[0], toks[1:]
+	}
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		// موقعیت گاهی به دو توکن lat و lon شکسته می‌شود
+		if r.Lat == nil && i+1 < len(toks) {
+			if lat, lon, ok := airepLatLon(tok + toks[i+1]); ok {
+				r.Lat, r.Lon = &lat, &lon
+				i++
+				continue
+			}
+		}
+		if r.Lat == nil {
+			if lat, lon, ok := airepLatLon(tok); ok {
+				r.Lat, r.Lon = &lat, &lon
+				continue
+			}
+		}
+		switch {
+		case r.Time.IsZero() && hhmmRe.MatchString(tok):
+			m := hhmmRe.FindStringSubmatch(tok)
+			h, _ := strconv.Atoi(m[1])
+			mi, _ := strconv.Atoi(m[2])
+			r.Time = ClosestTime(ref, h, mi)
+		case airepFL.MatchString(tok):
+			n, _ := strconv.Atoi(tok[1:])
+			r.FlightLevel = &n
+		case airepTemp.MatchString(tok):
+			n, _ := strconv.Atoi(tok[2:])
+			if strings.HasPrefix(tok, "MS") {
+				n = -n
+			}
+			r.TempC = &n
+		case airepWind.MatchString(tok):
+			m := airepWind.FindStringSubmatch(tok)
+			d, _ := strconv.Atoi(m[1])
+			sp, _ := strconv.Atoi(m[2])
+			r.Wind = &Wind{Direction: d, SpeedKt: sp}
+		case tok == "TB" || tok == "TURB":
+			j := i + 1
+			for j < len(toks) && (IntensityRank(toks[j]) >= 0 || toks[j] == "OCNL" || toks[j] == "CONS" || toks[j] == "INTMT" || toks[j] == "CAT" || toks[j] == "CHOP") {
+				j++
+			}
+			r.Turbulence = append(r.Turbulence, parseTurbulence(strings.Join(toks[i+1:j], " ")))
+			i = j - 1
+		case tok == "IC" || tok == "ICE":
+			j := i + 1
+			for j < len(toks) && (IntensityRank(toks[j]) >= 0 || toks[j] == "RIME" || toks[j] == "CLR" || toks[j] == "MX") {
+				j++
+			}
+			r.Icing = append(r.Icing, parseIcing(strings.Join(toks[i+1:j], " ")))
+			i = j - 1
+		case tok == "RM":
+			r.Remarks = strings.Join(toks[i+1:], " ")
+			i = len(toks)
+		default:
+			r.Unparsed = append(r.Unparsed, tok)
+		}
+	}
+	return r
+}
+
+func airepLatLon(tok string) (lat, lon float64, ok bool) {
+	m := airepPos.FindStringSubmatch(tok)
+	if m == nil {
+		return 0, 0, false
+	}
+	ld, _ := strconv.Atoi(m[1])
+	lm, _ := strconv.Atoi(m[2])
+	od, _ := strconv.Atoi(m[4])
+	om, _ := strconv.Atoi(m[5])
+	lat = float64(ld) + float64(lm)/60
+	lon = float64(od) + float64(om)/60
+	if m[3] == "S" {
+		lat = -lat
+	}
+	if m[6] == "W" {
+		lon = -lon
+	}
+	return lat, lon, lat <= 90 && lon <= 180
+}
+
+// ClosestTime returns the hh:mm UTC instant nearest to ref.
+func ClosestTime(ref time.Time, hour, minute int) time.Time {
+	ref = ref.UTC()
+	t := time.Date(ref.Year(), ref.Month(), ref.Day(), hour, minute, 0, 0, time.UTC)
+	switch d := t.Sub(ref); {
+	case d > 12*time.Hour:
+		t = t.Add(-24 * time.Hour)
+	case d < -12*time.Hour:
+		t = t.Add(24 * time.Hour)
+	}
+	return t
+}
+
+// hundreds turns a 3-digit group in hundreds of feet into feet.
+func hundreds(s string) *int {
+	if s == "" || s == "UNKN" {
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	n *= 100
+	return &n
+}
+
+func atoiPtr(s string) *int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
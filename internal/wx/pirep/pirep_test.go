@@ -0,0 +1,129 @@
+package pirep
+
+import (
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+	"SepTaf/internal/wx/metar"
+)
+
+var ref = time.Date(2025, 3, 14, 0, 30, 0, 0, time.UTC)
+
+func TestParsePIREP(t *testing.T) {
+	raw := "DEN UA /OV DEN090020/TM 2345/FL110/TP B737/SK BKN020 TOP035/WX FV05SM -RA BR/TA M05/WV 27045KT/TB LGT-MOD CHOP 080-100; OCNL MOD BLO 060/IC LGT RIME 090-110/RM SMOOTH ABV 120"
+	r, err := Parse(raw, ref)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	testutil.Eq(t, "kind", r.Kind, KindPIREP)
+	testutil.Eq(t, "station", r.Station, "DEN")
+	testutil.Eq(t, "location", r.Location, "DEN090020")
+	// 2345 belongs to the previous day
+	testutil.Eq(t, "time", r.Time, time.Date(2025, 3, 13, 23, 45, 0, 0, time.UTC))
+	testutil.Eq(t, "fl", *r.FlightLevel, 110)
+	testutil.Eq(t, "type", r.AircraftType, "B737")
+	testutil.Eq(t, "sky", r.Sky, []SkyLayer{{Cover: "BKN", BaseFt: testutil.Ptr(2000), TopFt: testutil.Ptr(3500)}})
+	testutil.Eq(t, "vis", *r.FlightVisSM, 5)
+	testutil.Eq(t, "weather", r.Weather, []metar.Weather{
+		{Raw: "-RA", Intensity: "-", Phenomena: []string{"RA"}},
+		{Raw: "BR", Phenomena: []string{"BR"}},
+	})
+	testutil.Eq(t, "temp", *r.TempC, -5)
+	testutil.Eq(t, "wind", *r.Wind, Wind{Direction: 270, SpeedKt: 45})
+	testutil.Eq(t, "turbulence", r.Turbulence, []Turbulence{
+		{Raw: "LGT-MOD CHOP 080-100", Intensity: "LGT-MOD", Type: "CHOP", BaseFL: testutil.Ptr(80), TopFL: testutil.Ptr(100)},
+		{Raw: "OCNL MOD BLO 060", Intensity: "MOD", Frequency: "OCNL", TopFL: testutil.Ptr(60), Below: true},
+	})
+	testutil.Eq(t, "icing", r.Icing, []Icing{{Raw: "LGT RIME 090-110", Intensity: "LGT", Type: "RIME", BaseFL: testutil.Ptr(90), TopFL: testutil.Ptr(110)}})
+	testutil.Eq(t, "max turb", r.MaxTurbulence(), "LGT-MOD")
+	testutil.Eq(t, "max icing", r.MaxIcing(), "LGT")
+	testutil.Eq(t, "remarks", r.Remarks, "SMOOTH ABV 120")
+	testutil.Eq(t, "unparsed", len(r.Unparsed), 0)
+}
+
+func TestParsePIREPVariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		check func(t *testing.T, r *Report)
+	}{
+		{
+			name: "urgent with severe icing",
+			raw:  "UUA /OV OKC/TM 0015/FL DURD/TP C172/IC SEV MXD ICE 040-060",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "kind", r.Kind, KindUrgent)
+				testutil.Eq(t, "station", r.Station, "")
+				testutil.Eq(t, "phase", r.FlightPhase, "DURD")
+				testutil.Eq(t, "time", r.Time, time.Date(2025, 3, 14, 0, 15, 0, 0, time.UTC))
+				testutil.Eq(t, "icing type", r.Icing[0].Type, "MX")
+				testutil.Eq(t, "max icing", r.MaxIcing(), "SEV")
+			},
+		},
+		{
+			name: "sky with spaced tops and base-first layer",
+			raw:  "OKC UA /OV OKC180010/TM 0020/FL080/SK 015BKN040 OVC060-TOPUNKN/TA 5",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "sky", r.Sky, []SkyLayer{
+					{Cover: "BKN", BaseFt: testutil.Ptr(1500), TopFt: testutil.Ptr(4000)},
+					{Cover: "OVC", BaseFt: testutil.Ptr(6000)},
+				})
+				testutil.Eq(t, "temp", *r.TempC, 5)
+			},
+		},
+		{
+			name: "bad fields kept",
+			raw:  "ABQ UA /OV ABQ/TM 25X/FL ABC/TA XX/WV 9",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "unparsed", r.Unparsed, []string{"/TM 25X", "/FL ABC", "/TA XX", "/WV 9"})
+			},
+		},
+		{
+			name: "airep",
+			raw:  "ARP UAL123 4520N 12030W 0010 F350 MS45 270/050KT TB MOD CAT RM NIL=",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "kind", r.Kind, KindAIREP)
+				testutil.Eq(t, "callsign", r.Callsign, "UAL123")
+				testutil.Eq(t, "lat", *r.Lat, 45+20.0/60)
+				testutil.Eq(t, "lon", *r.Lon, -(120 + 30.0/60))
+				testutil.Eq(t, "time", r.Time, time.Date(2025, 3, 14, 0, 10, 0, 0, time.UTC))
+				testutil.Eq(t, "fl", *r.FlightLevel, 350)
+				testutil.Eq(t, "temp", *r.TempC, -45)
+				testutil.Eq(t, "wind", *r.Wind, Wind{Direction: 270, SpeedKt: 50})
+				testutil.Eq(t, "turb", r.Turbulence, []Turbulence{{Raw: "MOD CAT", Intensity: "MOD", Type: "CAT"}})
+				testutil.Eq(t, "remarks", r.Remarks, "NIL")
+			},
+		},
+		{
+			name: "airep without callsign, joined position",
+			raw:  "AIREP 35S17420E 2355 F200 PS05 IC LGT RIME",
+			check: func(t *testing.T, r *Report) {
+				testutil.Eq(t, "callsign", r.Callsign, "")
+				testutil.Eq(t, "lat", *r.Lat, -35.0)
+				testutil.Eq(t, "lon", *r.Lon, 174+20.0/60)
+				testutil.Eq(t, "temp", *r.TempC, 5)
+				testutil.Eq(t, "icing", r.Icing[0].Type, "RIME")
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Parse(tc.raw, ref)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tc.check(t, r)
+		})
+	}
+	if _, err := Parse("  =", ref); err != ErrEmpty {
+		t.Errorf("Parse(blank) err = %v, want ErrEmpty", err)
+	}
+}
+
+func TestIntensityRank(t *testing.T) {
+	for s, want := range map[string]int{"NEG": 0, "SMTH": 1, "LGT": 2, "LGT-MOD": 3, "MOD-SEV": 4, "EXTM": 5, "XYZ": -1} {
+		if got := IntensityRank(s); got != want {
+			t.Errorf("IntensityRank(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
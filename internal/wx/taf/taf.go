@@ -0,0 +1,241 @@
+package taf
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/wx/metar"
+)
+
+// Group types. PROBxx TEMPO is reported as TEMPO with Probability set.
+const (
+	Base  = "BASE"
+	FM    = "FM"
+	BECMG = "BECMG"
+	TEMPO = "TEMPO"
+	PROB  = "PROB"
+)
+
+// TAF is a decoded terminal aerodrome forecast.
+type TAF struct {
+	Raw       string         `json:"raw"`
+	Station   string         `json:"station"`
+	Amended   bool           `json:"amended,omitempty"`
+	Corrected bool           `json:"corrected,omitempty"`
+	Cancelled bool           `json:"cancelled,omitempty"`
+	Nil       bool           `json:"nil,omitempty"`
+	IssueTime time.Time      `json:"issueTime"`
+	ValidFrom time.Time      `json:"validFrom"`
+	ValidTo   time.Time      `json:"validTo"`
+	Groups    []Group        `json:"groups"`
+	MaxTemp   []TempForecast `json:"maxTemp,omitempty"`
+	MinTemp   []TempForecast `json:"minTemp,omitempty"`
+	Remarks   string         `json:"remarks,omitempty"`
+	Unparsed  []string       `json:"unparsed,omitempty"`
+}
+
+// Group is one change group. From/To are the period the group applies to;
+// for BECMG the change happens somewhere inside it and persists afterwards.
+type Group struct {
+	Type        string    `json:"type"` // BASE | FM | BECMG | TEMPO | PROB
+	Probability int       `json:"probability,omitempty"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	metar.Conditions
+	WindShear *WindShear `json:"windShear,omitempty"`
+	Unparsed  []string   `json:"unparsed,omitempty"`
+}
+
+// WindShear is the non-convective LLWS group (WS020/24045KT).
+type WindShear struct {
+	HeightFt  int `json:"heightFt"`
+	Direction int `json:"direction"`
+	SpeedKt   int `json:"speedKt"`
+}
+
+type TempForecast struct {
+	Celsius int       `json:"celsius"`
+	At      time.Time `json:"at"`
+}
+
+var (
+	issueRe  = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	periodRe = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	fmRe     = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	fmOldRe  = regexp.MustCompile(`^FM(\d{2})(\d{2})$`)
+	probRe   = regexp.MustCompile(`^PROB(\d{2})$`)
+	tempRe   = regexp.MustCompile(`^T([XN])(M)?(\d{2})/(\d{2})(\d{2})Z$`)
+	wsRe     = regexp.MustCompile(`^WS(\d{3})/(\d{3})(\d{2,3})KT$`)
+	stationR = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+)
+
+// Parse decodes a raw TAF. ref anchors the issue day-of-month to a full
+// date; AWC's issueTime is the natural choice. A BECMG/TEMPO/PROB group
+// without its DDHH/DDHH period cannot be placed in time; it is kept whole
+// in TAF.Unparsed instead of being applied over the entire validity.
+func Parse(raw string, ref time.Time) (*TAF, error) {
+	toks := metar.Tokens(raw)
+	if len(toks) == 0 {
+		return nil, errors.New("empty taf")
+	}
+	t := &TAF{Raw: strings.Join(toks, " ")}
+
+	i := 0
+	for i < len(toks) && (toks[i] == "TAF" || toks[i] == "AMD" || toks[i] == "COR") {
+		switch toks[i] {
+		case "AMD":
+			t.Amended = true
+		case "COR":
+			t.Corrected = true
+		}
+		i++
+	}
+	if i >= len(toks) || !stationR.MatchString(toks[i]) {
+		return nil, errors.New("missing station identifier")
+	}
+	t.Station = toks[i]
+	i++
+
+	if i < len(toks) {
+		if m := issueRe.FindStringSubmatch(toks[i]); m != nil {
+			t.IssueTime = metar.DayTime(ref, atoi(m[1]), atoi(m[2]), atoi(m[3]))
+			i++
+		}
+	}
+	if t.IssueTime.IsZero() {
+		t.IssueTime = ref.UTC()
+	}
+	if i < len(toks) && toks[i] == "NIL" {
+		t.Nil = true
+		return t, nil
+	}
+	if i >= len(toks) || !periodRe.MatchString(toks[i]) {
+		return nil, errors.New("missing validity period")
+	}
+	t.ValidFrom, t.ValidTo = t.period(toks[i])
+	i++
+
+	t.Groups = []Group{{Type: Base, From: t.ValidFrom, To: t.ValidTo}}
+	g := &t.Groups[0]
+	// start of a change group without a period; its elements are parsed
+	// into a throwaway group and the raw text goes to Unparsed
+	orphan := -1
+	var dropped Group
+	flush := func(end int) {
+		if orphan >= 0 {
+			t.Unparsed = append(t.Unparsed, strings.Join(toks[orphan:end], " "))
+			orphan = -1
+		}
+	}
+	for i < len(toks) {
+		tok := toks[i]
+		if tok == "RMK" || tok == BECMG || tok == TEMPO || probRe.MatchString(tok) ||
+			fmRe.MatchString(tok) || fmOldRe.MatchString(tok) || tempRe.MatchString(tok) {
+			flush(i)
+		}
+		switch {
+		case tok == "RMK":
+			t.Remarks = strings.Join(toks[i+1:], " ")
+			i = len(toks)
+			continue
+		case tok == "CNL":
+			t.Cancelled = true
+		case fmRe.MatchString(tok) || fmOldRe.MatchString(tok):
+			t.Groups = append(t.Groups, Group{Type: FM, From: t.fmTime(tok), To: t.ValidTo})
+			g = &t.Groups[len(t.Groups)-1]
+		case tok == BECMG || tok == TEMPO:
+			if i+1 >= len(toks) || !periodRe.MatchString(toks[i+1]) {
+				orphan, dropped, g = i, Group{}, &dropped
+				break
+			}
+			ng := Group{Type: tok}
+			ng.From, ng.To = t.period(toks[i+1])
+			i++
+			t.Groups = append(t.Groups, ng)
+			g = &t.Groups[len(t.Groups)-1]
+		case probRe.MatchString(tok):
+			start := i
+			ng := Group{Type: PROB, Probability: atoi(probRe.FindStringSubmatch(tok)[1])}
+			if i+1 < len(toks) && toks[i+1] == TEMPO {
+				ng.Type = TEMPO
+				i++
+			}
+			if i+1 >= len(toks) || !periodRe.MatchString(toks[i+1]) {
+				orphan, dropped, g = start, Group{}, &dropped
+				break
+			}
+			ng.From, ng.To = t.period(toks[i+1])
+			i++
+			t.Groups = append(t.Groups, ng)
+			g = &t.Groups[len(t.Groups)-1]
+		case tempRe.MatchString(tok):
+			m := tempRe.FindStringSubmatch(tok)
+			c := atoi(m[3])
+			if m[2] == "M" {
+				c = -c
+			}
+			tf := TempForecast{Celsius: c, At: metar.DayTime(t.IssueTime, atoi(m[4]), atoi(m[5]), 0)}
+			if m[1] == "X" {
+				t.MaxTemp = append(t.MaxTemp, tf)
+			} else {
+				t.MinTemp = append(t.MinTemp, tf)
+			}
+		case wsRe.MatchString(tok):
+			m := wsRe.FindStringSubmatch(tok)
+			g.WindShear = &WindShear{HeightFt: atoi(m[1]) * 100, Direction: atoi(m[2]), SpeedKt: atoi(m[3])}
+		default:
+			if n := g.Conditions.ParseGroup(toks, i); n > 0 {
+				i += n
+				continue
+			}
+			g.Unparsed = append(g.Unparsed, tok)
+		}
+		i++
+	}
+	flush(len(toks))
+
+	// FM groups run until the next FM (or the end of validity); the base
+	// forecast runs until the first FM.
+	prev := 0
+	for k := 1; k < len(t.Groups); k++ {
+		if t.Groups[k].Type != FM {
+			continue
+		}
+		t.Groups[prev].To = t.Groups[k].From
+		prev = k
+	}
+	return t, nil
+}
+
+// period resolves a DDHH/DDHH group relative to the issue time.
+func (t *TAF) period(tok string) (time.Time, time.Time) {
+	m := periodRe.FindStringSubmatch(tok)
+	from := metar.DayTime(t.IssueTime, atoi(m[1]), atoi(m[2]), 0)
+	to := metar.DayTime(t.IssueTime, atoi(m[3]), atoi(m[4]), 0)
+	if to.Before(from) {
+		to = to.AddDate(0, 1, 0)
+	}
+	return from, to
+}
+
+func (t *TAF) fmTime(tok string) time.Time {
+	if m := fmRe.FindStringSubmatch(tok); m != nil {
+		return metar.DayTime(t.IssueTime, atoi(m[1]), atoi(m[2]), atoi(m[3]))
+	}
+	// old-style FMhhmm: first occurrence of that time within validity
+	m := fmOldRe.FindStringSubmatch(tok)
+	base := t.ValidFrom.Truncate(24 * time.Hour)
+	at := base.Add(time.Duration(atoi(m[1]))*time.Hour + time.Duration(atoi(m[2]))*time.Minute)
+	if at.Before(t.ValidFrom) {
+		at = at.Add(24 * time.Hour)
+	}
+	return at
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
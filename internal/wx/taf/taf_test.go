@@ -0,0 +1,181 @@
+package taf
+
+import (
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+)
+
+var ref = time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)
+
+func utc(d, h, m int) time.Time { return time.Date(2025, 3, d, h, m, 0, 0, time.UTC) }
+
+const oiii = "TAF OIII 141100Z 1412/1512 27010KT 9999 SCT030 TX22/1412Z TN08/1504Z " +
+	"BECMG 1414/1416 31020G30KT " +
+	"TEMPO 1418/1422 3000 TSRA BKN015CB " +
+	"FM150300 VRB03KT 6000 BR NSC " +
+	"PROB30 TEMPO 1505/1508 0800 FG VV002 " +
+	"WS020/24045KT="
+
+func TestParse(t *testing.T) {
+	tf, err := Parse(oiii, ref)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	testutil.Eq(t, "station", tf.Station, "OIII")
+	testutil.Eq(t, "issue", tf.IssueTime, utc(14, 11, 0))
+	testutil.Eq(t, "valid", [2]time.Time{tf.ValidFrom, tf.ValidTo}, [2]time.Time{utc(14, 12, 0), utc(15, 12, 0)})
+	testutil.Eq(t, "max temp", tf.MaxTemp, []TempForecast{{Celsius: 22, At: utc(14, 12, 0)}})
+	testutil.Eq(t, "min temp", tf.MinTemp, []TempForecast{{Celsius: 8, At: utc(15, 4, 0)}})
+
+	type grp struct {
+		Type     string
+		Prob     int
+		From, To time.Time
+	}
+	var got []grp
+	for _, g := range tf.Groups {
+		got = append(got, grp{g.Type, g.Probability, g.From, g.To})
+	}
+	testutil.Eq(t, "groups", got, []grp{
+		{Base, 0, utc(14, 12, 0), utc(15, 3, 0)}, // cut by the FM group
+		{BECMG, 0, utc(14, 14, 0), utc(14, 16, 0)},
+		{TEMPO, 0, utc(14, 18, 0), utc(14, 22, 0)},
+		{FM, 0, utc(15, 3, 0), utc(15, 12, 0)},
+		{TEMPO, 30, utc(15, 5, 0), utc(15, 8, 0)},
+	})
+	testutil.Eq(t, "base wind", tf.Groups[0].Wind.Speed, 10)
+	testutil.Eq(t, "tempo ceiling", *tf.Groups[2].Ceiling(), 1500)
+	testutil.Eq(t, "fm vis", tf.Groups[3].Visibility.Meters, 6000.0)
+	testutil.Eq(t, "prob vv", *tf.Groups[4].VertVisFt, 200)
+	testutil.Eq(t, "windshear", *tf.Groups[4].WindShear, WindShear{HeightFt: 2000, Direction: 240, SpeedKt: 45})
+	for _, g := range tf.Groups {
+		if len(g.Unparsed) > 0 {
+			t.Errorf("%s group: unparsed %v", g.Type, g.Unparsed)
+		}
+	}
+}
+
+func TestParseVariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		check func(t *testing.T, tf *TAF)
+	}{
+		{
+			name: "amended over month end",
+			raw:  "TAF AMD OIII 302300Z 3100/0106 18005KT CAVOK",
+			check: func(t *testing.T, tf *TAF) {
+				testutil.Eq(t, "amended", tf.Amended, true)
+				testutil.Eq(t, "from", tf.ValidFrom, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+				testutil.Eq(t, "to", tf.ValidTo, time.Date(2025, 4, 1, 6, 0, 0, 0, time.UTC))
+			},
+		},
+		{
+			name: "cancelled",
+			raw:  "TAF COR OIII 141100Z 1412/1512 CNL",
+			check: func(t *testing.T, tf *TAF) {
+				testutil.Eq(t, "corrected", tf.Corrected, true)
+				testutil.Eq(t, "cancelled", tf.Cancelled, true)
+			},
+		},
+		{
+			name: "nil",
+			raw:  "TAF OIII 141100Z NIL",
+			check: func(t *testing.T, tf *TAF) {
+				testutil.Eq(t, "nil", tf.Nil, true)
+				testutil.Eq(t, "groups", len(tf.Groups), 0)
+			},
+		},
+		{
+			name: "old style FM and PROB alone",
+			raw:  "TAF KJFK 141120Z 1412/1518 20012KT P6SM FEW250 FM2000 22015KT 3SM -RA OVC010 PROB40 1502/1506 1SM +TSRA RMK NXT FCST BY 18Z",
+			check: func(t *testing.T, tf *TAF) {
+				testutil.Eq(t, "fm from", tf.Groups[1].From, utc(14, 20, 0))
+				testutil.Eq(t, "prob", tf.Groups[2].Type, PROB)
+				testutil.Eq(t, "prob value", tf.Groups[2].Probability, 40)
+				testutil.Eq(t, "remarks", tf.Remarks, "NXT FCST BY 18Z")
+			},
+		},
+		{
+			name: "unknown group kept",
+			raw:  "TAF OIII 141100Z 1412/1512 27010KT 9999 QNH1013",
+			check: func(t *testing.T, tf *TAF) {
+				testutil.Eq(t, "unparsed", tf.Groups[0].Unparsed, []string{"QNH1013"})
+			},
+		},
+		{
+			name: "change groups without a period dropped",
+			raw:  "TAF OIII 141100Z 1412/1512 27010KT 9999 SCT030 BECMG 0800 FG PROB30 TEMPO TSRA TEMPO 1418/1422 3000 BR",
+			check: func(t *testing.T, tf *TAF) {
+				testutil.Eq(t, "groups", len(tf.Groups), 2)
+				testutil.Eq(t, "tempo", label(tf.Groups[1]), "TEMPO 1418/1422")
+				testutil.Eq(t, "unparsed", tf.Unparsed, []string{"BECMG 0800 FG", "PROB30 TEMPO TSRA"})
+				testutil.Eq(t, "base vis", tf.At(utc(15, 6, 0)).Visibility.Meters, 10000.0)
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tf, err := Parse(tc.raw, ref)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tc.check(t, tf)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, raw := range []string{"", "TAF", "TAF 1234", "TAF OIII 141100Z 27010KT"} {
+		if _, err := Parse(raw, ref); err == nil {
+			t.Errorf("Parse(%q): expected error", raw)
+		}
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	tf, err := Parse(oiii, ref)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	// BECMG 1414/1416 counts as prevailing once finished
+	testutil.Eq(t, "at 1413 wind", tf.At(utc(14, 13, 0)).Wind.Speed, 10)
+	testutil.Eq(t, "at 1416 wind", tf.At(utc(14, 16, 0)).Wind.Speed, 20)
+	// FM resets, and the BECMG before it no longer applies
+	testutil.Eq(t, "at 1503 wind", tf.At(utc(15, 3, 0)).Wind.Variable, true)
+
+	slots := tf.Timeline(utc(14, 18, 30), utc(14, 21, 0), time.Hour)
+	testutil.Eq(t, "slots", len(slots), 3)
+	s := slots[0]
+	testutil.Eq(t, "slot from", s.From, utc(14, 18, 0))
+	testutil.Eq(t, "prevailing vis", s.Prevailing.Visibility.Meters, 10000.0)
+	testutil.Eq(t, "worst vis", s.WorstCase.Visibility.Meters, 3000.0)
+	testutil.Eq(t, "worst ceiling", *s.WorstCase.Ceiling(), 1500)
+	testutil.Eq(t, "worst wind", s.WorstCase.Wind.Speed, 20)
+	testutil.Eq(t, "groups", s.Groups, []string{"TEMPO 1418/1422"})
+
+	prob := tf.Timeline(utc(15, 5, 0), utc(15, 6, 0), time.Hour)[0]
+	testutil.Eq(t, "prob", prob.Probability, 30)
+	testutil.Eq(t, "prob vis", prob.WorstCase.Visibility.Meters, 800.0)
+	testutil.Eq(t, "prob groups", prob.Groups, []string{"PROB30 TEMPO 1505/1508"})
+
+	// clamped to validity
+	all := tf.Timeline(time.Time{}, time.Time{}, 6*time.Hour)
+	testutil.Eq(t, "all slots", len(all), 4)
+	testutil.Eq(t, "last to", all[3].To, utc(15, 12, 0))
+}
+
+func TestOverlayCAVOK(t *testing.T) {
+	tf, err := Parse("TAF OIII 141100Z 1412/1512 27010KT 4000 RA BKN010 BECMG 1412/1413 CAVOK", ref)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	c := tf.At(utc(14, 14, 0))
+	testutil.Eq(t, "cavok", c.CAVOK, true)
+	testutil.Eq(t, "weather", len(c.Weather), 0)
+	testutil.Eq(t, "clouds", len(c.Clouds), 0)
+	testutil.Eq(t, "wind kept", c.Wind.Speed, 10)
+}
@@ -0,0 +1,180 @@
+package taf
+
+import (
+	"fmt"
+	"time"
+
+	"SepTaf/internal/wx/metar"
+)
+
+// Slot is the resolved forecast for one interval of a timeline.
+// Prevailing is what the base/FM/BECMG groups say at the start of the slot;
+// WorstCase additionally folds in every TEMPO/PROB group and unfinished
+// BECMG transition overlapping the slot.
+type Slot struct {
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Prevailing  metar.Conditions `json:"prevailing"`
+	WorstCase   metar.Conditions `json:"worstCase"`
+	Probability int              `json:"probability,omitempty"` // highest PROBxx contributing to WorstCase
+	Groups      []string         `json:"groups,omitempty"`      // temporary groups that contributed
+}
+
+// Timeline resolves the TAF into consecutive slots of length step between
+// from and to (clamped to the validity period).
+func (t *TAF) Timeline(from, to time.Time, step time.Duration) []Slot {
+	if step <= 0 {
+		step = time.Hour
+	}
+	if from.IsZero() || from.Before(t.ValidFrom) {
+		from = t.ValidFrom
+	}
+	if to.IsZero() || to.After(t.ValidTo) {
+		to = t.ValidTo
+	}
+	from = from.UTC().Truncate(step)
+
+	var out []Slot
+	for s := from; s.Before(to); s = s.Add(step) {
+		e := s.Add(step)
+		if e.After(to) {
+			e = to
+		}
+		out = append(out, t.slot(s, e))
+	}
+	return out
+}
+
+// At returns the prevailing conditions at a single instant.
+func (t *TAF) At(at time.Time) metar.Conditions {
+	var cur metar.Conditions
+	for _, g := range t.Groups {
+		switch g.Type {
+		case Base, FM:
+			if !g.From.After(at) {
+				cur = g.Conditions
+			}
+		case BECMG:
+			// a transition counts once it has finished
+			if !g.To.After(at) {
+				cur = Overlay(cur, g.Conditions)
+			}
+		}
+	}
+	return cur
+}
+
+func (t *TAF) slot(from, to time.Time) Slot {
+	s := Slot{From: from, To: to, Prevailing: t.At(from)}
+	worst := s.Prevailing
+
+	for _, g := range t.Groups {
+		overlaps := g.From.Before(to) && g.To.After(from)
+		switch g.Type {
+		case FM:
+			// a new FM starting inside the slot
+			if g.From.After(from) && g.From.Before(to) {
+				worst = Worst(worst, t.At(g.From))
+			}
+		case BECMG:
+			if overlaps {
+				worst = Worst(worst, Overlay(s.Prevailing, g.Conditions))
+				s.Groups = append(s.Groups, label(g))
+			}
+		case TEMPO, PROB:
+			if overlaps {
+				worst = Worst(worst, Overlay(s.Prevailing, g.Conditions))
+				s.Groups = append(s.Groups, label(g))
+				if g.Probability > s.Probability {
+					s.Probability = g.Probability
+				}
+			}
+		}
+	}
+	s.WorstCase = worst
+	return s
+}
+
+func label(g Group) string {
+	name := g.Type
+	if g.Probability > 0 {
+		if g.Type == TEMPO {
+			name = fmt.Sprintf("PROB%d TEMPO", g.Probability)
+		} else {
+			name = fmt.Sprintf("PROB%d", g.Probability)
+		}
+	}
+	return fmt.Sprintf("%s %s/%s", name, g.From.Format("0215"), g.To.Format("0215"))
+}
+
+// Overlay applies a change group on top of base: every element the change
+// group states replaces the corresponding element of base.
+func Overlay(base, chg metar.Conditions) metar.Conditions {
+	out := base
+	if chg.Wind != nil {
+		out.Wind = chg.Wind
+	}
+	if chg.Visibility != nil {
+		out.Visibility = chg.Visibility
+		out.CAVOK = false
+	}
+	if chg.NSW {
+		out.Weather = nil
+		out.NSW = true
+	}
+	if len(chg.Weather) > 0 {
+		out.Weather = chg.Weather
+		out.NSW = false
+	}
+	if len(chg.Clouds) > 0 || chg.VertVisFt != nil || chg.SkyClear != "" {
+		out.Clouds, out.VertVisFt, out.SkyClear = chg.Clouds, chg.VertVisFt, chg.SkyClear
+		out.CAVOK = false
+	}
+	if chg.CAVOK {
+		out.CAVOK = true
+		out.Visibility = chg.Visibility
+		out.Weather = nil
+		out.Clouds, out.VertVisFt, out.SkyClear = nil, nil, ""
+	}
+	return out
+}
+
+// Worst combines two sets of conditions element by element, keeping the
+// more restrictive value of each (stronger wind, lower visibility, lower
+// ceiling, all weather phenomena).
+func Worst(a, b metar.Conditions) metar.Conditions {
+	out := a
+	if b.Wind != nil && (a.Wind == nil || windScore(*b.Wind) > windScore(*a.Wind)) {
+		out.Wind = b.Wind
+	}
+	if b.Visibility != nil && (a.Visibility == nil || b.Visibility.Meters < a.Visibility.Meters) {
+		out.Visibility = b.Visibility
+		out.CAVOK = false
+	}
+	if cb := b.Ceiling(); cb != nil {
+		if ca := a.Ceiling(); ca == nil || *cb < *ca {
+			out.Clouds, out.VertVisFt, out.SkyClear = b.Clouds, b.VertVisFt, b.SkyClear
+			out.CAVOK = false
+		}
+	}
+	seen := map[string]bool{}
+	out.Weather = nil
+	for _, w := range append(append([]metar.Weather{}, a.Weather...), b.Weather...) {
+		if !seen[w.Raw] {
+			seen[w.Raw] = true
+			out.Weather = append(out.Weather, w)
+		}
+	}
+	if len(out.Weather) > 0 {
+		out.NSW = false
+		out.CAVOK = false
+	}
+	return out
+}
+
+func windScore(w metar.Wind) float64 {
+	if g := w.GustKt(); g > 0 {
+		return g
+	}
+	return w.SpeedKt()
+}
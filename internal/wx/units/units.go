@@ -0,0 +1,243 @@
+// Package units converts decoded METAR/TAF conditions into one consistent
+// unit system (metric, imperial, aviation-ICAO or aviation-FAA).
+package units
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+const FtPerM = 3.28084
+
+// System names the unit used for each kind of quantity.
+type System struct {
+	Name        string `json:"name"`
+	Speed       string `json:"speed"`       // kt | m/s | km/h | mph
+	Visibility  string `json:"visibility"`  // m | km | SM | mi
+	Pressure    string `json:"pressure"`    // hPa | inHg
+	Temperature string `json:"temperature"` // C | F
+	Height      string `json:"height"`      // ft | m
+}
+
+var (
+	Metric       = System{Name: "metric", Speed: "m/s", Visibility: "km", Pressure: "hPa", Temperature: "C", Height: "m"}
+	Imperial     = System{Name: "imperial", Speed: "mph", Visibility: "mi", Pressure: "inHg", Temperature: "F", Height: "ft"}
+	AviationICAO = System{Name: "aviation-icao", Speed: "kt", Visibility: "m", Pressure: "hPa", Temperature: "C", Height: "ft"}
+	AviationFAA  = System{Name: "aviation-faa", Speed: "kt", Visibility: "SM", Pressure: "inHg", Temperature: "C", Height: "ft"}
+)
+
+// Parse reads a units= value; "icao" and "faa" are accepted as short forms.
+func Parse(s string) (System, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "metric":
+		return Metric, nil
+	case "imperial":
+		return Imperial, nil
+	case "aviation-icao", "icao":
+		return AviationICAO, nil
+	case "aviation-faa", "faa":
+		return AviationFAA, nil
+	}
+	return System{}, fmt.Errorf("unknown units %q (metric, imperial, aviation-icao, aviation-faa)", s)
+}
+
+type Value struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+type Wind struct {
+	Direction *int   `json:"direction,omitempty"` // degrees true
+	Variable  bool   `json:"variable,omitempty"`
+	Speed     Value  `json:"speed"`
+	Gust      *Value `json:"gust,omitempty"`
+	VarFrom   *int   `json:"varFrom,omitempty"`
+	VarTo     *int   `json:"varTo,omitempty"`
+}
+
+type Visibility struct {
+	Value
+	LessThan bool `json:"lessThan,omitempty"`
+	MoreThan bool `json:"moreThan,omitempty"`
+}
+
+type Cloud struct {
+	Cover string `json:"cover"`
+	Base  *Value `json:"base,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+type Conditions struct {
+	Wind       *Wind       `json:"wind,omitempty"`
+	Visibility *Visibility `json:"visibility,omitempty"`
+	CAVOK      bool        `json:"cavok,omitempty"`
+	Weather    []string    `json:"weather,omitempty"`
+	Clouds     []Cloud     `json:"clouds,omitempty"`
+	VertVis    *Value      `json:"vertVis,omitempty"`
+	Ceiling    *Value      `json:"ceiling,omitempty"`
+}
+
+type Metar struct {
+	Units System `json:"units"`
+	Conditions
+	Temperature *Value `json:"temperature,omitempty"`
+	Dewpoint    *Value `json:"dewpoint,omitempty"`
+	Altimeter   *Value `json:"altimeter,omitempty"`
+	Elevation   *Value `json:"elevation,omitempty"`
+}
+
+type TafGroup struct {
+	Type        string    `json:"type"`
+	Probability int       `json:"probability,omitempty"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Conditions
+}
+
+type Taf struct {
+	Units  System     `json:"units"`
+	Groups []TafGroup `json:"groups"`
+}
+
+type Slot struct {
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	Prevailing Conditions `json:"prevailing"`
+	WorstCase  Conditions `json:"worstCase"`
+}
+
+// Metar converts a decoded report; elevM (station elevation in metres) is
+// optional.
+func (s System) Metar(r *metar.Report, elevM *float64) Metar {
+	out := Metar{Units: s, Conditions: s.Conditions(r.Conditions)}
+	if r.Temperature != nil {
+		out.Temperature = s.temp(float64(*r.Temperature))
+	}
+	if r.Dewpoint != nil {
+		out.Dewpoint = s.temp(float64(*r.Dewpoint))
+	}
+	if r.Altimeter != nil {
+		out.Altimeter = s.pressure(r.Altimeter.HPa)
+	}
+	if elevM != nil {
+		out.Elevation = s.height(*elevM * FtPerM)
+	}
+	return out
+}
+
+func (s System) TAF(t *taf.TAF) Taf {
+	out := Taf{Units: s, Groups: make([]TafGroup, 0, len(t.Groups))}
+	for _, g := range t.Groups {
+		out.Groups = append(out.Groups, TafGroup{
+			Type:        g.Type,
+			Probability: g.Probability,
+			From:        g.From,
+			To:          g.To,
+			Conditions:  s.Conditions(g.Conditions),
+		})
+	}
+	return out
+}
+
+func (s System) Slots(in []taf.Slot) []Slot {
+	out := make([]Slot, 0, len(in))
+	for _, sl := range in {
+		out = append(out, Slot{From: sl.From, To: sl.To, Prevailing: s.Conditions(sl.Prevailing), WorstCase: s.Conditions(sl.WorstCase)})
+	}
+	return out
+}
+
+func (s System) Conditions(c metar.Conditions) Conditions {
+	out := Conditions{CAVOK: c.CAVOK}
+	if c.Wind != nil {
+		w := &Wind{
+			Direction: c.Wind.Direction,
+			Variable:  c.Wind.Variable,
+			Speed:     s.speed(c.Wind.SpeedKt()),
+			VarFrom:   c.Wind.VarFrom,
+			VarTo:     c.Wind.VarTo,
+		}
+		if c.Wind.Gust != nil {
+			g := s.speed(c.Wind.GustKt())
+			w.Gust = &g
+		}
+		out.Wind = w
+	}
+	if c.Visibility != nil {
+		out.Visibility = &Visibility{
+			Value:    s.visibility(c.Visibility.Meters),
+			LessThan: c.Visibility.LessThan,
+			MoreThan: c.Visibility.MoreThan,
+		}
+	}
+	for _, w := range c.Weather {
+		out.Weather = append(out.Weather, w.Raw)
+	}
+	for _, cl := range c.Clouds {
+		oc := Cloud{Cover: cl.Cover, Type: cl.Type}
+		if cl.BaseFt != nil {
+			oc.Base = s.height(float64(*cl.BaseFt))
+		}
+		out.Clouds = append(out.Clouds, oc)
+	}
+	if c.VertVisFt != nil {
+		out.VertVis = s.height(float64(*c.VertVisFt))
+	}
+	if ceil := c.Ceiling(); ceil != nil {
+		out.Ceiling = s.height(float64(*ceil))
+	}
+	return out
+}
+
+func (s System) speed(kt float64) Value {
+	switch s.Speed {
+	case "m/s":
+		return Value{round(kt*metar.MPSPerKt, 1), s.Speed}
+	case "km/h":
+		return Value{round(kt*metar.KMHPerKt, 0), s.Speed}
+	case "mph":
+		return Value{round(kt*1.15078, 0), s.Speed}
+	}
+	return Value{round(kt, 0), "kt"}
+}
+
+func (s System) visibility(m float64) Value {
+	switch s.Visibility {
+	case "km":
+		return Value{round(m/1000, 1), s.Visibility}
+	case "SM", "mi":
+		return Value{round(m/metar.MetersPerSM, 2), s.Visibility}
+	}
+	return Value{round(m, 0), "m"}
+}
+
+func (s System) pressure(hPa float64) *Value {
+	if s.Pressure == "inHg" {
+		return &Value{round(hPa/metar.HPaPerInHg, 2), s.Pressure}
+	}
+	return &Value{round(hPa, 1), "hPa"}
+}
+
+func (s System) temp(c float64) *Value {
+	if s.Temperature == "F" {
+		return &Value{round(c*9/5+32, 1), "F"}
+	}
+	return &Value{round(c, 1), "C"}
+}
+
+func (s System) height(ft float64) *Value {
+	if s.Height == "m" {
+		return &Value{round(ft/FtPerM, 0), "m"}
+	}
+	return &Value{round(ft, 0), "ft"}
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
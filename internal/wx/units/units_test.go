@@ -0,0 +1,101 @@
+package units
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+var ref = time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+
+func vp(v float64, unit string) *Value { return &Value{v, unit} }
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]System{
+		"metric": Metric, " Imperial ": Imperial, "icao": AviationICAO,
+		"aviation-icao": AviationICAO, "FAA": AviationFAA, "aviation-faa": AviationFAA,
+	} {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", in, got.Name, err, want.Name)
+		}
+	}
+	if _, err := Parse("si"); err == nil {
+		t.Error("Parse(si): expected error")
+	}
+}
+
+func TestMetar(t *testing.T) {
+	r, err := metar.Parse("METAR OIII 141200Z 27015G25KT 9999 -RA BKN030 18/M02 Q1013", ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elev := 1208.0
+
+	tests := []struct {
+		sys                    System
+		speed, gust, vis, ceil Value
+		temp, dew, altim, elev Value
+	}{
+		{Metric, Value{7.7, "m/s"}, Value{12.9, "m/s"}, Value{10, "km"}, Value{914, "m"},
+			Value{18, "C"}, Value{-2, "C"}, Value{1013, "hPa"}, Value{1208, "m"}},
+		{Imperial, Value{17, "mph"}, Value{29, "mph"}, Value{6.21, "mi"}, Value{3000, "ft"},
+			Value{64.4, "F"}, Value{28.4, "F"}, Value{29.91, "inHg"}, Value{3963, "ft"}},
+		{AviationICAO, Value{15, "kt"}, Value{25, "kt"}, Value{10000, "m"}, Value{3000, "ft"},
+			Value{18, "C"}, Value{-2, "C"}, Value{1013, "hPa"}, Value{3963, "ft"}},
+		{AviationFAA, Value{15, "kt"}, Value{25, "kt"}, Value{6.21, "SM"}, Value{3000, "ft"},
+			Value{18, "C"}, Value{-2, "C"}, Value{29.91, "inHg"}, Value{3963, "ft"}},
+	}
+	for _, tc := range tests {
+		m := tc.sys.Metar(r, &elev)
+		got := []Value{m.Wind.Speed, *m.Wind.Gust, m.Visibility.Value, *m.Ceiling, *m.Temperature, *m.Dewpoint, *m.Altimeter, *m.Elevation}
+		want := []Value{tc.speed, tc.gust, tc.vis, tc.ceil, tc.temp, tc.dew, tc.altim, tc.elev}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s:\n got  %v\n want %v", tc.sys.Name, got, want)
+		}
+		if !m.Visibility.MoreThan || !reflect.DeepEqual(m.Weather, []string{"-RA"}) || m.Units != tc.sys {
+			t.Errorf("%s: visibility/weather/units = %+v %v %v", tc.sys.Name, m.Visibility, m.Weather, m.Units.Name)
+		}
+	}
+}
+
+func TestSourceUnits(t *testing.T) {
+	// MPS and SM in the report are converted, not relabelled
+	r, err := metar.Parse("SPECI KJFK 141151Z 27008MPS 1 1/2SM BR OVC004 M01/M02 A2992", ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := Metric.Metar(r, nil)
+	if m.Wind.Speed != (Value{8, "m/s"}) || m.Visibility.Value != (Value{2.4, "km"}) || *m.Altimeter != (Value{1013.2, "hPa"}) {
+		t.Errorf("Metric = wind %v vis %v altim %v", m.Wind.Speed, m.Visibility.Value, *m.Altimeter)
+	}
+	if m.Elevation != nil {
+		t.Errorf("Elevation = %v, want nil", m.Elevation)
+	}
+	if !reflect.DeepEqual(m.Clouds, []Cloud{{Cover: "OVC", Base: vp(122, "m")}}) {
+		t.Errorf("Clouds = %+v", m.Clouds)
+	}
+}
+
+func TestTAF(t *testing.T) {
+	tf, err := taf.Parse("TAF OIII 141100Z 1412/1512 27010KT 9999 SCT030 TEMPO 1418/1422 3000 TSRA VV005", ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := AviationFAA.TAF(tf)
+	if len(out.Groups) != 2 || out.Groups[1].Type != taf.TEMPO {
+		t.Fatalf("Groups = %+v", out.Groups)
+	}
+	g := out.Groups[1]
+	if g.Visibility.Value != (Value{1.86, "SM"}) || *g.VertVis != (Value{500, "ft"}) || *g.Ceiling != (Value{500, "ft"}) {
+		t.Errorf("TEMPO = vis %v vv %v ceil %v", g.Visibility.Value, g.VertVis, g.Ceiling)
+	}
+
+	slots := Metric.Slots(tf.Timeline(time.Date(2025, 3, 14, 18, 0, 0, 0, time.UTC), time.Date(2025, 3, 14, 19, 0, 0, 0, time.UTC), time.Hour))
+	if len(slots) != 1 || slots[0].Prevailing.Visibility.Value != (Value{10, "km"}) || slots[0].WorstCase.Visibility.Value != (Value{3, "km"}) {
+		t.Errorf("Slots = %+v", slots)
+	}
+}
@@ -0,0 +1,274 @@
+// Package verify scores a TAF against the METARs observed during its
+// validity period.
+package verify
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"SepTaf/internal/wx/fltcat"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+const (
+	BucketHours   = 6  // lead-time bucket width
+	MaxLeadHours  = 30 // longer leads fold into the last bucket
+	WindSpeedTol  = 10 // kt
+	WindDirTol    = 30 // degrees
+	WindDirMinSpd = 10 // kt; direction is not scored below this (either side)
+)
+
+// Score counts hits per element. Each element has its own N because an
+// element is only scored when both the forecast and the observation state it.
+type Score struct {
+	Samples         int `json:"samples"`
+	CategoryN       int `json:"categoryN"`
+	CategoryHits    int `json:"categoryHits"`
+	CategoryInTempo int `json:"categoryInTempo"` // observed within prevailing..worst-case incl. TEMPO/PROB
+	CeilingN        int `json:"ceilingN"`
+	CeilingHits     int `json:"ceilingHits"`
+	VisibilityN     int `json:"visibilityN"`
+	VisibilityHits  int `json:"visibilityHits"`
+	WindN           int `json:"windN"`
+	WindHits        int `json:"windHits"`
+	WeatherN        int `json:"weatherN"`
+	WeatherHits     int `json:"weatherHits"`
+}
+
+// LeadScore is the score of the observations whose lead time (observation
+// minus TAF issue time) falls in [FromH, ToH).
+type LeadScore struct {
+	FromH int `json:"fromH"`
+	ToH   int `json:"toH"`
+	Score
+}
+
+// Accuracy is a Score as hit rates (0..1); nil where nothing was scored.
+type Accuracy struct {
+	Samples         int      `json:"samples"`
+	Category        *float64 `json:"category,omitempty"`
+	CategoryInTempo *float64 `json:"categoryInTempo,omitempty"`
+	Ceiling         *float64 `json:"ceiling,omitempty"`
+	Visibility      *float64 `json:"visibility,omitempty"`
+	Wind            *float64 `json:"wind,omitempty"`
+	Weather         *float64 `json:"weather,omitempty"`
+}
+
+// Result is the verification of one TAF.
+type Result struct {
+	Total  Score       `json:"total"`
+	ByLead []LeadScore `json:"byLead"`
+}
+
+// Verify compares t with every observation inside [t.ValidFrom, until).
+// until is usually the validity end or the issue time of the TAF that
+// superseded t.
+func Verify(t *taf.TAF, obs []*metar.Report, until time.Time, rules fltcat.Rules) Result {
+	if until.IsZero() || until.After(t.ValidTo) {
+		until = t.ValidTo
+	}
+	leads := map[int]*LeadScore{}
+	var res Result
+	for _, o := range obs {
+		if o == nil || o.Nil || o.Time.Before(t.ValidFrom) || !o.Time.Before(until) {
+			continue
+		}
+		slots := t.Timeline(o.Time, o.Time.Add(time.Minute), time.Minute)
+		if len(slots) == 0 {
+			continue
+		}
+		s := Compare(slots[0].Prevailing, slots[0].WorstCase, o.Conditions, rules)
+
+		b := int(o.Time.Sub(t.IssueTime).Hours()) / BucketHours * BucketHours
+		if b < 0 {
+			b = 0
+		}
+		if b >= MaxLeadHours {
+			b = MaxLeadHours - BucketHours
+		}
+		ls, ok := leads[b]
+		if !ok {
+			ls = &LeadScore{FromH: b, ToH: b + BucketHours}
+			leads[b] = ls
+		}
+		ls.Add(s)
+		res.Total.Add(s)
+	}
+	for _, ls := range leads {
+		res.ByLead = append(res.ByLead, *ls)
+	}
+	sort.Slice(res.ByLead, func(i, j int) bool { return res.ByLead[i].FromH < res.ByLead[j].FromH })
+	return res
+}
+
+// Compare scores a single observation against the prevailing and worst-case
+// forecast for the same time.
+func Compare(prev, worst, ob metar.Conditions, rules fltcat.Rules) Score {
+	s := Score{Samples: 1}
+
+	fc, oc := rules.FromConditions(prev), rules.FromConditions(ob)
+	if fc != fltcat.UNKN && oc != fltcat.UNKN {
+		s.CategoryN = 1
+		if fc == oc {
+			s.CategoryHits = 1
+		}
+		if between(rank(oc), rank(fc), rank(rules.FromConditions(worst))) {
+			s.CategoryInTempo = 1
+		}
+	}
+	if fc, oc := ceilingCat(prev, rules), ceilingCat(ob, rules); fc != fltcat.UNKN && oc != fltcat.UNKN {
+		s.CeilingN = 1
+		if fc == oc {
+			s.CeilingHits = 1
+		}
+	}
+	if fc, oc := visibilityCat(prev, rules), visibilityCat(ob, rules); fc != fltcat.UNKN && oc != fltcat.UNKN {
+		s.VisibilityN = 1
+		if fc == oc {
+			s.VisibilityHits = 1
+		}
+	}
+	if prev.Wind != nil && ob.Wind != nil {
+		s.WindN = 1
+		if windHit(*prev.Wind, *ob.Wind) {
+			s.WindHits = 1
+		}
+	}
+	// TAF بدون گروه هوا یعنی «هوای قابل توجهی پیش‌بینی نشده»
+	s.WeatherN = 1
+	if weatherHit(prev, ob) {
+		s.WeatherHits = 1
+	}
+	return s
+}
+
+// Add accumulates o into s.
+func (s *Score) Add(o Score) {
+	s.Samples += o.Samples
+	s.CategoryN += o.CategoryN
+	s.CategoryHits += o.CategoryHits
+	s.CategoryInTempo += o.CategoryInTempo
+	s.CeilingN += o.CeilingN
+	s.CeilingHits += o.CeilingHits
+	s.VisibilityN += o.VisibilityN
+	s.VisibilityHits += o.VisibilityHits
+	s.WindN += o.WindN
+	s.WindHits += o.WindHits
+	s.WeatherN += o.WeatherN
+	s.WeatherHits += o.WeatherHits
+}
+
+// Accuracy converts the counts to hit rates.
+func (s Score) Accuracy() Accuracy {
+	return Accuracy{
+		Samples:         s.Samples,
+		Category:        rate(s.CategoryHits, s.CategoryN),
+		CategoryInTempo: rate(s.CategoryInTempo, s.CategoryN),
+		Ceiling:         rate(s.CeilingHits, s.CeilingN),
+		Visibility:      rate(s.VisibilityHits, s.VisibilityN),
+		Wind:            rate(s.WindHits, s.WindN),
+		Weather:         rate(s.WeatherHits, s.WeatherN),
+	}
+}
+
+func rate(hits, n int) *float64 {
+	if n == 0 {
+		return nil
+	}
+	v := math.Round(float64(hits)/float64(n)*1000) / 1000
+	return &v
+}
+
+// ceilingCat is the category from the ceiling alone; a known sky with no
+// BKN/OVC layer is VFR.
+func ceilingCat(c metar.Conditions, rules fltcat.Rules) string {
+	if ceil := c.Ceiling(); ceil != nil {
+		return rules.Compute(ceil, nil)
+	}
+	if c.CAVOK || c.SkyClear != "" || len(c.Clouds) > 0 {
+		return fltcat.VFR
+	}
+	return fltcat.UNKN
+}
+
+func visibilityCat(c metar.Conditions, rules fltcat.Rules) string {
+	if c.Visibility != nil {
+		v := c.Visibility.Meters / metar.MetersPerSM
+		return rules.Compute(nil, &v)
+	}
+	if c.CAVOK {
+		return fltcat.VFR
+	}
+	return fltcat.UNKN
+}
+
+func rank(cat string) int {
+	switch cat {
+	case fltcat.LIFR:
+		return 3
+	case fltcat.IFR:
+		return 2
+	case fltcat.MVFR:
+		return 1
+	}
+	return 0
+}
+
+func between(v, a, b int) bool {
+	if a > b {
+		a, b = b, a
+	}
+	return v >= a && v <= b
+}
+
+func windHit(f, o metar.Wind) bool {
+	fs, ws := f.SpeedKt(), o.SpeedKt()
+	if math.Abs(fs-ws) > WindSpeedTol {
+		return false
+	}
+	if fs < WindDirMinSpd || ws < WindDirMinSpd || f.Direction == nil || o.Direction == nil {
+		return true
+	}
+	d := math.Abs(float64(*f.Direction - *o.Direction))
+	if d > 180 {
+		d = 360 - d
+	}
+	return d <= WindDirTol
+}
+
+// weatherHit: both quiet, or both with weather sharing at least one
+// phenomenon (thunderstorm counts as a phenomenon of its own).
+func weatherHit(fc, ob metar.Conditions) bool {
+	fw, ow := phenomena(fc), phenomena(ob)
+	if len(fw) == 0 || len(ow) == 0 {
+		return len(fw) == len(ow)
+	}
+	for p := range ow {
+		if fw[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// phenomena collects the weather codes, ignoring vicinity groups.
+func phenomena(c metar.Conditions) map[string]bool {
+	out := map[string]bool{}
+	if c.NSW {
+		return out
+	}
+	for _, w := range c.Weather {
+		if w.Intensity == "VC" {
+			continue
+		}
+		if w.Descriptor == "TS" {
+			out["TS"] = true
+		}
+		for _, p := range w.Phenomena {
+			out[p] = true
+		}
+	}
+	return out
+}
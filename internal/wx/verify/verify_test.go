@@ -0,0 +1,126 @@
+package verify
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"SepTaf/internal/wx/fltcat"
+	"SepTaf/internal/wx/metar"
+	"SepTaf/internal/wx/taf"
+)
+
+var ref = time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)
+
+func mustTAF(t *testing.T, raw string) *taf.TAF {
+	t.Helper()
+	tf, err := taf.Parse(raw, ref)
+	if err != nil {
+		t.Fatalf("taf.Parse(%q): %v", raw, err)
+	}
+	return tf
+}
+
+func mustMETARs(t *testing.T, raws ...string) []*metar.Report {
+	t.Helper()
+	var out []*metar.Report
+	for _, raw := range raws {
+		r, err := metar.Parse(raw, ref)
+		if err != nil {
+			t.Fatalf("metar.Parse(%q): %v", raw, err)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+const oiii = "TAF OIII 141100Z 1412/1512 27010KT 9999 SCT030 TEMPO 1418/1422 3000 TSRA BKN008"
+
+var obs = []string{
+	"METAR OIII 141100Z 27010KT 9999 SCT030 20/05 Q1013", // before validity: ignored
+	"METAR OIII 141300Z 27012KT 9999 SCT030 20/05 Q1013", // all hits
+	"METAR OIII 141900Z 28015KT 4000 TSRA BKN009 18/10 Q1010",
+	"METAR OIII 142000Z NIL",
+	"METAR OIII 151000Z VRB02KT CAVOK 15/05 Q1015",
+}
+
+func TestVerify(t *testing.T) {
+	res := Verify(mustTAF(t, oiii), mustMETARs(t, obs...), time.Time{}, fltcat.FAA)
+
+	want := Score{
+		Samples:   3,
+		CategoryN: 3, CategoryHits: 2, CategoryInTempo: 3, // the IFR hour is inside the TEMPO
+		CeilingN: 3, CeilingHits: 2,
+		VisibilityN: 3, VisibilityHits: 2,
+		WindN: 3, WindHits: 3,
+		WeatherN: 3, WeatherHits: 2,
+	}
+	if res.Total != want {
+		t.Errorf("Total = %+v\nwant    %+v", res.Total, want)
+	}
+
+	var buckets [][2]int
+	for _, ls := range res.ByLead {
+		buckets = append(buckets, [2]int{ls.FromH, ls.Samples})
+	}
+	if !reflect.DeepEqual(buckets, [][2]int{{0, 1}, {6, 1}, {18, 1}}) {
+		t.Errorf("ByLead (fromH, samples) = %v", buckets)
+	}
+
+	acc := res.Total.Accuracy()
+	if acc.Category == nil || *acc.Category != 0.667 || *acc.Wind != 1 {
+		t.Errorf("Accuracy = %+v", acc)
+	}
+}
+
+func TestVerifyUntil(t *testing.T) {
+	// superseded at 1418: the 1900 and later observations do not count
+	res := Verify(mustTAF(t, oiii), mustMETARs(t, obs...), time.Date(2025, 3, 14, 18, 0, 0, 0, time.UTC), fltcat.FAA)
+	if res.Total.Samples != 1 || res.Total.CategoryHits != 1 {
+		t.Errorf("Total = %+v, want one hit", res.Total)
+	}
+}
+
+func TestVerifyLeadCap(t *testing.T) {
+	tf := mustTAF(t, "TAF OIII 140500Z 1406/1512 27010KT 9999 SCT030")
+	res := Verify(tf, mustMETARs(t, "METAR OIII 151100Z 27010KT 9999 SCT030 20/05 Q1013"), time.Time{}, fltcat.FAA)
+	if len(res.ByLead) != 1 || res.ByLead[0].FromH != MaxLeadHours-BucketHours {
+		t.Errorf("ByLead = %+v, want the last bucket", res.ByLead)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cond := func(group string) metar.Conditions {
+		r, err := metar.Parse("METAR OIII 141200Z "+group, ref)
+		if err != nil {
+			t.Fatalf("metar.Parse(%q): %v", group, err)
+		}
+		return r.Conditions
+	}
+	tests := []struct {
+		name     string
+		fc, ob   string
+		wind, wx bool
+	}{
+		{"direction within tolerance", "35020KT", "01025KT", true, true},
+		{"direction off", "27020KT", "32020KT", false, true},
+		{"speed off", "27010KT", "27025KT", false, true},
+		{"light wind ignores direction", "27005KT", "09008KT", true, true},
+		{"shared phenomenon", "27010KT -SHRA", "27010KT RA BR", true, true},
+		{"thunder vs rain", "27010KT TS", "27010KT RA", true, false},
+		{"vicinity ignored", "27010KT", "27010KT VCSH", true, true},
+		{"nsw is quiet", "27010KT NSW", "27010KT", true, true},
+	}
+	for _, tc := range tests {
+		s := Compare(cond(tc.fc), cond(tc.fc), cond(tc.ob), fltcat.FAA)
+		if (s.WindHits == 1) != tc.wind || (s.WeatherHits == 1) != tc.wx {
+			t.Errorf("%s: wind hit %d, weather hit %d; want %v, %v", tc.name, s.WindHits, s.WeatherHits, tc.wind, tc.wx)
+		}
+	}
+
+	// nothing known about sky/visibility: only wind and weather are scored
+	s := Compare(cond("27010KT"), cond("27010KT"), cond("27010KT"), fltcat.FAA)
+	if s.CategoryN != 0 || s.CeilingN != 0 || s.VisibilityN != 0 || s.WindN != 1 || s.WeatherN != 1 {
+		t.Errorf("Compare without sky = %+v", s)
+	}
+}
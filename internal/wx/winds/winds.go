@@ -0,0 +1,274 @@
+package winds
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"SepTaf/internal/geo"
+	"SepTaf/internal/wx/metar"
+)
+
+// Bulletin is one decoded FD (winds and temperatures aloft) product.
+type Bulletin struct {
+	BasedOn time.Time `json:"basedOn,omitempty"`
+	Valid   time.Time `json:"valid,omitempty"`
+	UseFrom time.Time `json:"useFrom,omitempty"`
+	UseTo   time.Time `json:"useTo,omitempty"`
+	Levels  []int     `json:"levels"` // ft, from the FT header line
+	// Stations keeps the bulletin order.
+	Stations []Station `json:"stations"`
+}
+
+type Station struct {
+	ID     string  `json:"id"`
+	Levels []Level `json:"levels"`
+}
+
+// Level is one decoded group. Direction is nil for light and variable
+// (9900); TempC is nil where the FD omits it (3000 ft, near the surface).
+type Level struct {
+	AltitudeFt    int    `json:"altitudeFt"`
+	Raw           string `json:"raw"`
+	Direction     *int   `json:"direction,omitempty"` // degrees true
+	SpeedKt       int    `json:"speedKt"`
+	LightVariable bool   `json:"lightVariable,omitempty"`
+	AtLeast       bool   `json:"atLeast,omitempty"` // 199 kt or more (…99 with dd>50)
+	TempC         *int   `json:"tempC,omitempty"`
+}
+
+// Estimate is a wind/temperature value produced by interpolation.
+type Estimate struct {
+	DirectionDeg float64  `json:"directionDeg"`
+	SpeedKt      float64  `json:"speedKt"`
+	TempC        *float64 `json:"tempC,omitempty"`
+}
+
+// ErrNoData is returned when the text has no FT header or station lines.
+var ErrNoData = errors.New("no FD data")
+
+var (
+	basedRe = regexp.MustCompile(`DATA BASED ON (\d{2})(\d{2})(\d{2})Z`)
+	validRe = regexp.MustCompile(`VALID (\d{2})(\d{2})(\d{2})Z`)
+	useRe   = regexp.MustCompile(`FOR USE (\d{2})(\d{2})-(\d{2})(\d{2})Z`)
+	groupRe = regexp.MustCompile(`^(\d{2})(\d{2})([+-]\d{2}|\d{2})?$`)
+	idRe    = regexp.MustCompile(`^[A-Z0-9]{3,4}$`)
+)
+
+// Parse decodes the FD text product (AWC /windtemp). ref anchors the
+// DDHHMM times to a full date.
+//
+// Missing groups in an FD line are always the lowest levels (within 1500 ft
+// of the station, or 3000 ft temps), so the groups of a line are matched to
+// the header levels from the right instead of by fixed column.
+func Parse(text string, ref time.Time) (*Bulletin, error) {
+	b := &Bulletin{}
+	inData := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		up := strings.ToUpper(strings.TrimRight(line, " "))
+		if m := basedRe.FindStringSubmatch(up); m != nil {
+			b.BasedOn = ddhhmm(ref, m[1], m[2], m[3])
+		}
+		if m := validRe.FindStringSubmatch(up); m != nil {
+			b.Valid = ddhhmm(ref, m[1], m[2], m[3])
+			if u := useRe.FindStringSubmatch(up); u != nil {
+				b.UseFrom, b.UseTo = useWindow(b.Valid, u)
+			}
+		}
+		f := strings.Fields(up)
+		if len(f) == 0 {
+			continue
+		}
+		if f[0] == "FT" {
+			b.Levels = b.Levels[:0]
+			for _, s := range f[1:] {
+				if n, err := strconv.Atoi(s); err == nil {
+					b.Levels = append(b.Levels, n)
+				}
+			}
+			inData = len(b.Levels) > 0
+			continue
+		}
+		if !inData || !idRe.MatchString(f[0]) || len(f) < 2 || len(f)-1 > len(b.Levels) {
+			continue
+		}
+		st := Station{ID: f[0]}
+		off := len(b.Levels) - (len(f) - 1)
+		ok := true
+		for i, g := range f[1:] {
+			lv, good := decodeGroup(g, b.Levels[off+i])
+			if !good {
+				ok = false
+				break
+			}
+			st.Levels = append(st.Levels, lv)
+		}
+		if ok {
+			b.Stations = append(b.Stations, st)
+		}
+	}
+	if len(b.Levels) == 0 || len(b.Stations) == 0 {
+		return nil, ErrNoData
+	}
+	return b, nil
+}
+
+// decodeGroup reads DDff, DDffTT or DDff±TT. DD>50 means speed+100 kt and
+// direction DD-50; 9900 is light and variable; unsigned temps are negative
+// (used above FL240).
+func decodeGroup(g string, alt int) (Level, bool) {
+	m := groupRe.FindStringSubmatch(g)
+	if m == nil {
+		return Level{}, false
+	}
+	lv := Level{AltitudeFt: alt, Raw: g}
+	dd, _ := strconv.Atoi(m[1])
+	ff, _ := strconv.Atoi(m[2])
+	switch {
+	case dd == 99 && ff == 0:
+		lv.LightVariable = true
+	case dd > 50:
+		dir := (dd - 50) * 10
+		lv.Direction = &dir
+		lv.SpeedKt = ff + 100
+		lv.AtLeast = ff == 99
+	default:
+		dir := dd * 10
+		lv.Direction = &dir
+		lv.SpeedKt = ff
+	}
+	if m[3] != "" {
+		t, _ := strconv.Atoi(m[3])
+		if !strings.HasPrefix(m[3], "+") && !strings.HasPrefix(m[3], "-") {
+			t = -t
+		}
+		lv.TempC = &t
+	}
+	return lv, true
+}
+
+func ddhhmm(ref time.Time, d, h, m string) time.Time {
+	day, _ := strconv.Atoi(d)
+	hour, _ := strconv.Atoi(h)
+	min, _ := strconv.Atoi(m)
+	return metar.DayTime(ref, day, hour, min)
+}
+
+// useWindow resolves "FOR USE hhmm-hhmmZ" around the valid time.
+func useWindow(valid time.Time, m []string) (time.Time, time.Time) {
+	at := func(h, mi string) time.Time {
+		hh, _ := strconv.Atoi(h)
+		mm, _ := strconv.Atoi(mi)
+		return time.Date(valid.Year(), valid.Month(), valid.Day(), 0, 0, 0, 0, time.UTC).
+			Add(time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute)
+	}
+	from, to := at(m[1], m[2]), at(m[3], m[4])
+	if from.After(valid) {
+		from = from.Add(-24 * time.Hour)
+	}
+	if !to.After(from) {
+		to = to.Add(24 * time.Hour)
+	}
+	return from, to
+}
+
+// Station returns the station with the given FD id (e.g. "DEN").
+func (b *Bulletin) Station(id string) (Station, bool) {
+	for _, s := range b.Stations {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Station{}, false
+}
+
+// At interpolates the station's wind (as a vector) and temperature linearly
+// between the two reported levels around altFt. It does not extrapolate.
+func (s Station) At(altFt int) (Estimate, bool) {
+	for i, lv := range s.Levels {
+		if lv.AltitudeFt == altFt {
+			return lv.estimate(), true
+		}
+		if lv.AltitudeFt > altFt {
+			if i == 0 {
+				return Estimate{}, false
+			}
+			lo := s.Levels[i-1]
+			f := float64(altFt-lo.AltitudeFt) / float64(lv.AltitudeFt-lo.AltitudeFt)
+			return Blend([]Estimate{lo.estimate(), lv.estimate()}, []float64{1 - f, f}), true
+		}
+	}
+	return Estimate{}, false
+}
+
+func (lv Level) estimate() Estimate {
+	e := Estimate{SpeedKt: float64(lv.SpeedKt)}
+	if lv.Direction != nil {
+		e.DirectionDeg = float64(*lv.Direction)
+	}
+	if lv.TempC != nil {
+		t := float64(*lv.TempC)
+		e.TempC = &t
+	}
+	return e
+}
+
+// Blend is the weighted mean of several estimates; winds are averaged as
+// u/v vectors. Temperature is only returned when every input has one.
+func Blend(es []Estimate, weights []float64) Estimate {
+	var u, v, t, wsum float64
+	haveT := true
+	for i, e := range es {
+		w := weights[i]
+		eu, ev := toUV(e.DirectionDeg, e.SpeedKt)
+		u += w * eu
+		v += w * ev
+		if e.TempC == nil {
+			haveT = false
+		} else {
+			t += w * *e.TempC
+		}
+		wsum += w
+	}
+	if wsum == 0 {
+		return Estimate{}
+	}
+	dir, spd := fromUV(u/wsum, v/wsum)
+	out := Estimate{DirectionDeg: round1(dir), SpeedKt: round1(spd)}
+	if haveT {
+		tc := round1(t / wsum)
+		out.TempC = &tc
+	}
+	return out
+}
+
+// Components splits the wind into along-track (positive = tailwind) and
+// cross-track (positive = from the right) parts for a true track.
+func Components(e Estimate, trackDeg float64) (tail, cross float64) {
+	tail, cross = geo.WindComponents(e.DirectionDeg, e.SpeedKt, trackDeg)
+	return round1(tail), round1(cross)
+}
+
+// toUV converts a meteorological wind (direction it blows from) to the
+// vector it blows towards.
+func toUV(dirDeg, spd float64) (u, v float64) {
+	r := dirDeg * math.Pi / 180
+	return -spd * math.Sin(r), -spd * math.Cos(r)
+}
+
+func fromUV(u, v float64) (dirDeg, spd float64) {
+	spd = math.Hypot(u, v)
+	if spd < 0.05 {
+		return 0, 0
+	}
+	dirDeg = math.Mod(math.Atan2(-u, -v)*180/math.Pi+360, 360)
+	if dirDeg == 0 {
+		dirDeg = 360
+	}
+	return dirDeg, spd
+}
+
+func round1(v float64) float64 { return math.Round(v*10) / 10 }
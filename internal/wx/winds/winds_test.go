@@ -0,0 +1,142 @@
+package winds
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"SepTaf/internal/testutil"
+)
+
+const fd = `000
+FBUS31 KWNO 141359
+FD1US1
+DATA BASED ON 141200Z
+VALID 141800Z   FOR USE 1400-2100Z. TEMPS NEG ABV 24000
+
+FT  3000    6000    9000   12000   18000   24000  30000  34000  39000
+ABI         2114+10 2519+04 2627-01 2637-14 2549-26 255442 256052 256161
+DEN              9900+02 2715-05 2831-18 2751-30 760945 761255 751862
+BFF 3207    3215+05 9900+02 2212-03 2422-16 2526-27 244043 244453 244859
+XXX 3207 BROKEN
+`
+
+func TestParse(t *testing.T) {
+	b, err := Parse(fd, testutil.Ref)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	testutil.Eq(t, "based on", b.BasedOn, time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC))
+	testutil.Eq(t, "valid", b.Valid, time.Date(2025, 3, 14, 18, 0, 0, 0, time.UTC))
+	testutil.Eq(t, "use", [2]time.Time{b.UseFrom, b.UseTo}, [2]time.Time{
+		time.Date(2025, 3, 14, 14, 0, 0, 0, time.UTC), time.Date(2025, 3, 14, 21, 0, 0, 0, time.UTC),
+	})
+	testutil.Eq(t, "levels", b.Levels, []int{3000, 6000, 9000, 12000, 18000, 24000, 30000, 34000, 39000})
+	// the malformed XXX line is dropped
+	testutil.Eq(t, "stations", len(b.Stations), 3)
+
+	abi, _ := b.Station("ABI")
+	testutil.Eq(t, "abi first alt", abi.Levels[0].AltitudeFt, 6000) // no 3000 ft group
+	testutil.Eq(t, "abi 6000", abi.Levels[0], Level{AltitudeFt: 6000, Raw: "2114+10", Direction: testutil.Ptr(210), SpeedKt: 14, TempC: testutil.Ptr(10)})
+	// unsigned temps above FL240 are negative
+	testutil.Eq(t, "abi 30000", abi.Levels[5], Level{AltitudeFt: 30000, Raw: "255442", Direction: testutil.Ptr(250), SpeedKt: 54, TempC: testutil.Ptr(-42)})
+
+	den, _ := b.Station("DEN")
+	testutil.Eq(t, "den first alt", den.Levels[0].AltitudeFt, 9000)
+	testutil.Eq(t, "den light variable", den.Levels[0].LightVariable, true)
+	testutil.Eq(t, "den light variable dir", den.Levels[0].Direction, (*int)(nil))
+	// DD>50: direction DD-50, speed ff+100
+	testutil.Eq(t, "den 34000", den.Levels[5], Level{AltitudeFt: 34000, Raw: "761255", Direction: testutil.Ptr(260), SpeedKt: 112, TempC: testutil.Ptr(-55)})
+
+	bff, _ := b.Station("BFF")
+	testutil.Eq(t, "bff 3000", bff.Levels[0], Level{AltitudeFt: 3000, Raw: "3207", Direction: testutil.Ptr(320), SpeedKt: 7})
+
+	if _, ok := b.Station("ZZZ"); ok {
+		t.Error("Station(ZZZ) found")
+	}
+}
+
+func TestParseNoData(t *testing.T) {
+	for _, text := range []string{"", "FT 3000 6000\n", "ABI 2114+10 2519+04\n"} {
+		if _, err := Parse(text, testutil.Ref); err != ErrNoData {
+			t.Errorf("Parse(%q) err = %v, want ErrNoData", text, err)
+		}
+	}
+}
+
+func TestDecodeGroup(t *testing.T) {
+	tests := []struct {
+		g    string
+		want Level
+		ok   bool
+	}{
+		{"9900", Level{AltitudeFt: 9000, Raw: "9900", LightVariable: true}, true},
+		{"7799", Level{AltitudeFt: 9000, Raw: "7799", Direction: testutil.Ptr(270), SpeedKt: 199, AtLeast: true}, true},
+		{"0512-07", Level{AltitudeFt: 9000, Raw: "0512-07", Direction: testutil.Ptr(50), SpeedKt: 12, TempC: testutil.Ptr(-7)}, true},
+		{"05X2", Level{}, false},
+	}
+	for _, tc := range tests {
+		got, ok := decodeGroup(tc.g, 9000)
+		if ok != tc.ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("decodeGroup(%q) = %+v, %v; want %+v, %v", tc.g, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestStationAt(t *testing.T) {
+	b, err := Parse(fd, testutil.Ref)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	abi, _ := b.Station("ABI")
+
+	e, ok := abi.At(9000)
+	testutil.Eq(t, "exact ok", ok, true)
+	testutil.Eq(t, "exact", e.SpeedKt, 19.0)
+
+	// halfway between 2627-01 (12000) and 2637-14 (18000)
+	e, ok = abi.At(15000)
+	testutil.Eq(t, "interp ok", ok, true)
+	testutil.Eq(t, "interp dir", e.DirectionDeg, 260.0)
+	testutil.Eq(t, "interp speed", e.SpeedKt, 32.0)
+	testutil.Eq(t, "interp temp", *e.TempC, -7.5)
+
+	// no extrapolation below the first or above the last level
+	if _, ok := abi.At(3000); ok {
+		t.Error("At(3000) extrapolated")
+	}
+	if _, ok := abi.At(45000); ok {
+		t.Error("At(45000) extrapolated")
+	}
+}
+
+func TestBlend(t *testing.T) {
+	tc := 10.0
+	// opposite winds of equal strength cancel out
+	e := Blend([]Estimate{{DirectionDeg: 90, SpeedKt: 20, TempC: &tc}, {DirectionDeg: 270, SpeedKt: 20}}, []float64{1, 1})
+	testutil.Eq(t, "cancel", e, Estimate{})
+
+	// averaging across north does not give 180
+	e = Blend([]Estimate{{DirectionDeg: 350, SpeedKt: 20}, {DirectionDeg: 10, SpeedKt: 20}}, []float64{1, 1})
+	testutil.Eq(t, "north dir", e.DirectionDeg, 360.0)
+	testutil.Eq(t, "north speed", e.SpeedKt, 19.7)
+}
+
+func TestComponents(t *testing.T) {
+	tests := []struct {
+		dir, spd, track   float64
+		wantTail, wantXwd float64
+	}{
+		{270, 20, 90, 20, 0},     // straight tailwind
+		{90, 20, 90, -20, 0},     // headwind
+		{180, 20, 90, 0, 20},     // from the right
+		{0, 20, 90, 0, -20},      // from the left
+		{240, 30, 270, -26, -15}, // quartering headwind from the left
+	}
+	for _, tc := range tests {
+		tail, cross := Components(Estimate{DirectionDeg: tc.dir, SpeedKt: tc.spd}, tc.track)
+		if tail != tc.wantTail || cross != tc.wantXwd {
+			t.Errorf("Components(%v/%v, track %v) = %v, %v; want %v, %v", tc.dir, tc.spd, tc.track, tail, cross, tc.wantTail, tc.wantXwd)
+		}
+	}
+}